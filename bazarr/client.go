@@ -0,0 +1,276 @@
+package bazarr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// httpTimeout is long because a subtitle search waits for the providers.
+	httpTimeout = 2 * time.Minute
+	wantedSize  = 100
+)
+
+type Language struct {
+	Name   string `json:"name"`
+	Code2  string `json:"code2"`
+	Code3  string `json:"code3"`
+	Forced bool   `json:"forced"`
+	HI     bool   `json:"hi"`
+}
+
+// Item is a movie or an episode known to Bazarr. Episodes have a series ID.
+type Item struct {
+	Title     string
+	MovieID   int
+	SeriesID  int
+	EpisodeID int
+	Missing   []Language
+}
+
+// Lacks reports whether the item is missing subtitles in the language, given
+// as a two or three letter code.
+func (i Item) Lacks(language string) bool {
+	for _, l := range i.Missing {
+		if strings.EqualFold(l.Code2, language) || strings.EqualFold(l.Code3, language) {
+			return true
+		}
+	}
+	return false
+}
+
+type movie struct {
+	Title            string     `json:"title"`
+	RadarrID         int        `json:"radarrId"`
+	MissingSubtitles []Language `json:"missing_subtitles"`
+}
+
+type series struct {
+	Title          string `json:"title"`
+	SonarrSeriesID int    `json:"sonarrSeriesId"`
+}
+
+type episode struct {
+	Title            string     `json:"title"`
+	SeriesTitle      string     `json:"seriesTitle"`
+	EpisodeNumber    string     `json:"episode_number"`
+	EpisodeTitle     string     `json:"episodeTitle"`
+	Season           int        `json:"season"`
+	Episode          int        `json:"episode"`
+	SonarrSeriesID   int        `json:"sonarrSeriesId"`
+	SonarrEpisodeID  int        `json:"sonarrEpisodeId"`
+	MissingSubtitles []Language `json:"missing_subtitles"`
+}
+
+type Client struct {
+	token   string
+	baseURL *url.URL
+	http    http.Client
+}
+
+func NewClient(host, token string) (*Client, error) {
+	// Hosts without a scheme are reached over HTTPS.
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	base, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse host %q: %v", host, err)
+	}
+	httpClient := http.Client{
+		Timeout: httpTimeout,
+	}
+	return &Client{
+		token:   token,
+		baseURL: base,
+		http:    httpClient,
+	}, nil
+}
+
+// Wanted returns the movies and episodes missing subtitles in the languages
+// of their Bazarr language profile.
+func (c *Client) Wanted(ctx context.Context) ([]Item, error) {
+	query := url.Values{
+		"start":  {"0"},
+		"length": {strconv.Itoa(wantedSize)},
+	}
+
+	var movies struct {
+		Data []movie `json:"data"`
+	}
+	if err := c.get(ctx, fmt.Sprintf("api/movies/wanted?%s", query.Encode()), &movies); err != nil {
+		return nil, err
+	}
+	var episodes struct {
+		Data []episode `json:"data"`
+	}
+	if err := c.get(ctx, fmt.Sprintf("api/episodes/wanted?%s", query.Encode()), &episodes); err != nil {
+		return nil, err
+	}
+
+	var items []Item
+	for _, m := range movies.Data {
+		items = append(items, m.item())
+	}
+	for _, e := range episodes.Data {
+		item := e.item()
+		item.Title = fmt.Sprintf("%s %s - %s", e.SeriesTitle, e.EpisodeNumber, e.EpisodeTitle)
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Movie returns the movie with the title, or the only one whose title
+// contains it.
+func (c *Client) Movie(ctx context.Context, title string) (Item, error) {
+	var movies struct {
+		Data []movie `json:"data"`
+	}
+	if err := c.get(ctx, "api/movies", &movies); err != nil {
+		return Item{}, err
+	}
+
+	var matches []movie
+	for _, m := range movies.Data {
+		if strings.EqualFold(m.Title, title) {
+			return m.item(), nil
+		}
+		if strings.Contains(strings.ToLower(m.Title), strings.ToLower(title)) {
+			matches = append(matches, m)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return Item{}, fmt.Errorf("no movie %q in Bazarr", title)
+	case 1:
+		return matches[0].item(), nil
+	default:
+		return Item{}, fmt.Errorf("%d movies in Bazarr match %q, be more specific", len(matches), title)
+	}
+}
+
+// Episode returns the episode of the series with the title.
+func (c *Client) Episode(ctx context.Context, title string, season int, number int) (Item, error) {
+	var shows struct {
+		Data []series `json:"data"`
+	}
+	if err := c.get(ctx, "api/series", &shows); err != nil {
+		return Item{}, err
+	}
+
+	var matches []series
+	for _, s := range shows.Data {
+		if strings.EqualFold(s.Title, title) {
+			matches = []series{s}
+			break
+		}
+		if strings.Contains(strings.ToLower(s.Title), strings.ToLower(title)) {
+			matches = append(matches, s)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Item{}, fmt.Errorf("no series %q in Bazarr", title)
+	case 1:
+	default:
+		return Item{}, fmt.Errorf("%d series in Bazarr match %q, be more specific", len(matches), title)
+	}
+	show := matches[0]
+
+	var episodes struct {
+		Data []episode `json:"data"`
+	}
+	query := url.Values{"seriesid[]": {strconv.Itoa(show.SonarrSeriesID)}}
+	if err := c.get(ctx, fmt.Sprintf("api/episodes?%s", query.Encode()), &episodes); err != nil {
+		return Item{}, err
+	}
+
+	for _, e := range episodes.Data {
+		if e.Season == season && e.Episode == number {
+			item := e.item()
+			item.Title = fmt.Sprintf("%s S%02dE%02d - %s", show.Title, season, number, e.Title)
+			return item, nil
+		}
+	}
+	return Item{}, fmt.Errorf("%s has no episode S%02dE%02d in Bazarr", show.Title, season, number)
+}
+
+// SearchSubtitles searches the providers for subtitles of the item in the
+// language and downloads the best match. It returns once the search is done.
+func (c *Client) SearchSubtitles(ctx context.Context, item Item, language Language) error {
+	query := url.Values{
+		"language": {language.Code2},
+		"forced":   {strings.Title(strconv.FormatBool(language.Forced))},
+		"hi":       {strings.Title(strconv.FormatBool(language.HI))},
+	}
+	path := "api/movies/subtitles"
+	if item.SeriesID != 0 {
+		path = "api/episodes/subtitles"
+		query.Set("seriesid", strconv.Itoa(item.SeriesID))
+		query.Set("episodeid", strconv.Itoa(item.EpisodeID))
+	} else {
+		query.Set("radarrid", strconv.Itoa(item.MovieID))
+	}
+
+	_, err := c.do(ctx, "PATCH", fmt.Sprintf("%s?%s", path, query.Encode()))
+	return err
+}
+
+func (m movie) item() Item {
+	return Item{
+		Title:   m.Title,
+		MovieID: m.RadarrID,
+		Missing: m.MissingSubtitles,
+	}
+}
+
+func (e episode) item() Item {
+	return Item{
+		Title:     e.Title,
+		SeriesID:  e.SonarrSeriesID,
+		EpisodeID: e.SonarrEpisodeID,
+		Missing:   e.MissingSubtitles,
+	}
+}
+
+func (c *Client) get(ctx context.Context, path string, v interface{}) error {
+	body, err := c.do(ctx, "GET", path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+func (c *Client) do(ctx context.Context, method string, path string) ([]byte, error) {
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/%s", c.baseURL, path), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("X-API-KEY", c.token)
+	req = req.WithContext(ctx)
+	response, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("bazarr returned %s for %s %s", response.Status, method, strings.SplitN(path, "?", 2)[0])
+	}
+
+	return body, nil
+}
@@ -4,27 +4,166 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 )
 
 type config struct {
-	LogLevel string `json:"loglevel"`
-	Slack    struct {
-		BotToken  string `json:"bottoken"`
-		BotID     string `json:"botid"`
-		ChannelID string `json:"channelid"`
+	LogLevel    string `json:"loglevel"`
+	DataDir     string `json:"datadir"`
+	MediaServer string `json:"mediaserver"`
+	Slack       struct {
+		BotToken       string   `json:"bottoken"`
+		BotID          string   `json:"botid"`
+		ChannelID      string   `json:"channelid"`
+		AdminChannelID string   `json:"adminchannelid"`
+		Admins         []string `json:"admins"`
+		// SigningSecret checks that events and actions posted to the HTTP
+		// endpoints come from Slack.
+		SigningSecret string `json:"signingsecret"`
+		// AppToken, if set, receives events over Socket Mode instead of
+		// the HTTP endpoints.
+		AppToken string `json:"apptoken"`
 	} `json:"slack"`
+	Discord struct {
+		ApplicationID string `json:"applicationid"`
+		// PublicKey is the hex encoded key interactions are signed with.
+		PublicKey string `json:"publickey"`
+		BotToken  string `json:"bottoken"`
+		// GuildID is the server the commands are registered in. They are
+		// registered globally when empty.
+		GuildID string `json:"guildid"`
+	} `json:"discord"`
+	Matrix struct {
+		Homeserver string `json:"homeserver"`
+		Token      string `json:"token"`
+		// Rooms are the IDs or aliases of the rooms commands are answered
+		// in.
+		Rooms []string `json:"rooms"`
+	} `json:"matrix"`
+	Telegram struct {
+		BotToken string `json:"bottoken"`
+		// URL is where the daemon is reached from the internet. Updates are
+		// posted to URL/telegram/Secret if set, and polled for otherwise.
+		URL    string `json:"url"`
+		Secret string `json:"secret"`
+		// Users are the IDs of the Telegram users allowed to use the bot.
+		Users []int64 `json:"users"`
+	} `json:"telegram"`
+	Mattermost struct {
+		Path     string `json:"path"`
+		BotToken string `json:"bottoken"`
+		// CommandToken is the token of the slash command.
+		CommandToken string `json:"commandtoken"`
+		// URL is where Mattermost reaches the daemon, for its buttons.
+		URL string `json:"url"`
+	} `json:"mattermost"`
 	Emby struct {
-		AdminID string `json:"adminid"`
+		AdminID      string `json:"adminid"`
+		Path         string `json:"path"`
+		Token        string `json:"token"`
+		InvitePolicy struct {
+			Libraries                []string `json:"libraries"`
+			SimultaneousStreamLimit  int      `json:"simultaneousstreamlimit"`
+			RemoteClientBitrateLimit int      `json:"remoteclientbitratelimit"`
+		} `json:"invitepolicy"`
+	} `json:"emby"`
+	Jellyfin struct {
+		Path   string `json:"path"`
+		Token  string `json:"token"`
+		UserID string `json:"userid"`
+	} `json:"jellyfin"`
+	Plex struct {
 		Path    string `json:"path"`
 		Token   string `json:"token"`
-	} `json:"emby"`
+		Posters bool   `json:"posters"`
+	} `json:"plex"`
 	Radarr struct {
 		Path   string `json:"path"`
 		APIKey string `json:"apikey"`
 	} `json:"radarr"`
+	Lidarr struct {
+		Path              string `json:"path"`
+		APIKey            string `json:"apikey"`
+		QualityProfileID  int    `json:"qualityprofileid"`
+		MetadataProfileID int    `json:"metadataprofileid"`
+		RootFolderPath    string `json:"rootfolderpath"`
+	} `json:"lidarr"`
+	Readarr      readarrConfig `json:"readarr"`
+	ReadarrAudio readarrConfig `json:"readarraudio"`
+	Downloader   struct {
+		Type     string `json:"type"`
+		Path     string `json:"path"`
+		Username string `json:"username"`
+		Password string `json:"password"`
+	} `json:"downloader"`
+	Usenet struct {
+		Type          string `json:"type"`
+		Path          string `json:"path"`
+		APIKey        string `json:"apikey"`
+		Username      string `json:"username"`
+		Password      string `json:"password"`
+		FailureAlerts bool   `json:"failurealerts"`
+	} `json:"usenet"`
+	Bazarr struct {
+		Path      string   `json:"path"`
+		APIKey    string   `json:"apikey"`
+		Languages []string `json:"languages"`
+	} `json:"bazarr"`
+	Torznab struct {
+		Path   string `json:"path"`
+		APIKey string `json:"apikey"`
+	} `json:"torznab"`
+	Notifications []notificationConfig `json:"notifications"`
+	// RequestQuota is how many titles each user may request a week.
+	RequestQuota int `json:"requestquota"`
+	// Voting requests movies put up for a vote once Threshold people
+	// reacted to them within Hours, 24 by default.
+	Voting struct {
+		Threshold int `json:"threshold"`
+		Hours     int `json:"hours"`
+	} `json:"voting"`
+	Watchlist struct {
+		// AutoRequest requests movies on the watchlist once they are out.
+		AutoRequest bool `json:"autorequest"`
+	} `json:"watchlist"`
+	// APIUsers may use the Overseerr compatible request API.
+	APIUsers []struct {
+		APIKey    string `json:"apikey"`
+		SlackUser string `json:"slackuser"`
+		Name      string `json:"name"`
+	} `json:"apiusers"`
+	WeeklyReport struct {
+		Weekday string `json:"weekday"`
+		Hour    int    `json:"hour"`
+	} `json:"weeklyreport"`
 	TLSConfig TLSConfig `json:"tlsconfig"`
 }
 
+// notificationConfig is a notification sink. The fields used depend on its
+// type.
+type notificationConfig struct {
+	Type     string   `json:"type"`
+	URL      string   `json:"url"`
+	Topic    string   `json:"topic"`
+	Token    string   `json:"token"`
+	Priority int      `json:"priority"`
+	Address  string   `json:"address"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+	Events   []string `json:"events"`
+}
+
+type readarrConfig struct {
+	Path              string `json:"path"`
+	APIKey            string `json:"apikey"`
+	QualityProfileID  int    `json:"qualityprofileid"`
+	MetadataProfileID int    `json:"metadataprofileid"`
+	RootFolderPath    string `json:"rootfolderpath"`
+}
+
 func loadConfig(file string) (*config, error) {
 	var config config
 	configFile, err := os.Open(file)
@@ -40,3 +179,13 @@ func loadConfig(file string) (*config, error) {
 
 	return &config, nil
 }
+
+// weekday parses the name of a day of the week, ignoring case.
+func weekday(name string) (time.Weekday, error) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(d.String(), name) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid weekday %q", name)
+}
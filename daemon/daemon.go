@@ -1,24 +1,53 @@
 package daemon
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 
+	"warezbot/bazarr"
+	"warezbot/discord"
+	"warezbot/downloader"
 	"warezbot/emby"
+	"warezbot/jellyfin"
+	"warezbot/lidarr"
+	"warezbot/matrix"
+	"warezbot/mattermost"
+	"warezbot/media"
+	"warezbot/notify"
+	"warezbot/plex"
 	"warezbot/radarr"
+	"warezbot/readarr"
 	"warezbot/slack"
+	"warezbot/telegram"
+	"warezbot/torznab"
 	"warezbot/warez"
 )
 
 type WarezDaemon struct {
 	*HTTPSDaemon
-	apiKey string
-	logger log.Logger
+	apiKey           string
+	discordPublicKey string
+	slackSecret      string
+	telegramSecret   string
+	logger           log.Logger
+	// mediaServer is the media server picked in the config, whose webhook
+	// is the only one served.
+	mediaServer string
+	// stopSocketMode closes the Slack Socket Mode connection, if any.
+	stopSocketMode context.CancelFunc
+	// slackSocketMode is set when Slack is reached over Socket Mode, which
+	// leaves the HTTP endpoints of Slack unused.
+	slackSocketMode bool
 }
 
-func NewWarezDaemon(logger log.Logger, requestLogPath string, config string) (*WarezDaemon, error) {
+func NewWarezDaemon(logger log.Logger, requestLogPath string, auditLogPath string, config string) (*WarezDaemon, error) {
 	cfg, err := loadConfig(config)
 	if err != nil {
 		return nil, err
@@ -26,7 +55,7 @@ func NewWarezDaemon(logger log.Logger, requestLogPath string, config string) (*W
 
 	logger = SetLoggerLevel(logger, cfg.LogLevel)
 
-	embyClient, err := emby.NewClient(cfg.Emby.Path, cfg.Emby.Token, cfg.Emby.AdminID)
+	mediaServer, err := newMediaServer(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -34,18 +63,190 @@ func NewWarezDaemon(logger log.Logger, requestLogPath string, config string) (*W
 	if err != nil {
 		return nil, err
 	}
-	slackClient, err := slack.NewClient(cfg.Slack.BotToken, cfg.Slack.ChannelID, cfg.Slack.BotID)
+	var artists, albums warez.MediaManager
+	if cfg.Lidarr.Path != "" {
+		lidarrClient, err := lidarr.NewClient(cfg.Lidarr.Path, cfg.Lidarr.APIKey, cfg.Lidarr.QualityProfileID, cfg.Lidarr.MetadataProfileID, cfg.Lidarr.RootFolderPath)
+		if err != nil {
+			return nil, err
+		}
+		artists = lidarr.Artists{Client: lidarrClient}
+		albums = lidarr.Albums{Client: lidarrClient}
+	}
+	books, err := newReadarr(cfg.Readarr, media.Book)
+	if err != nil {
+		return nil, err
+	}
+	audiobooks, err := newReadarr(cfg.ReadarrAudio, media.Audiobook)
+	if err != nil {
+		return nil, err
+	}
+	torrentClient, err := newDownloader(cfg)
+	if err != nil {
+		return nil, err
+	}
+	usenetClient, err := newUsenet(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var subtitles warez.Subtitles
+	if cfg.Bazarr.Path != "" {
+		subtitles, err = bazarr.NewClient(cfg.Bazarr.Path, cfg.Bazarr.APIKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var indexer warez.Indexer
+	if cfg.Torznab.Path != "" {
+		indexer, err = torznab.NewClient(cfg.Torznab.Path, cfg.Torznab.APIKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	slackClient, err := slack.NewClient(cfg.Slack.BotToken, cfg.Slack.ChannelID, cfg.Slack.AdminChannelID, cfg.Slack.BotID)
 	if err != nil {
 		return nil, err
 	}
+	var discordClient *discord.Client
+	if cfg.Discord.ApplicationID != "" {
+		discordClient, err = discord.NewClient(cfg.Discord.ApplicationID, cfg.Discord.BotToken)
+		if err != nil {
+			return nil, err
+		}
+		// Commands registered by an earlier run keep working, so this is
+		// not fatal.
+		if err := discordClient.RegisterCommands(context.Background(), cfg.Discord.GuildID); err != nil {
+			level.Warn(logger).Log("msg", "failed to register discord commands", "error", err)
+		}
+	}
+	var matrixClient *matrix.Client
+	if cfg.Matrix.Homeserver != "" {
+		matrixClient, err = matrix.NewClient(cfg.Matrix.Homeserver, cfg.Matrix.Token)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var telegramClient *telegram.Client
+	if cfg.Telegram.BotToken != "" {
+		telegramClient, err = telegram.NewClient(cfg.Telegram.BotToken)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Telegram.URL != "" {
+			if cfg.Telegram.Secret == "" {
+				return nil, fmt.Errorf("the telegram webhook needs a secret")
+			}
+			webhook := fmt.Sprintf("%s%s", strings.TrimSuffix(cfg.Telegram.URL, "/"), strings.Replace(telegramPath, "{secret}", cfg.Telegram.Secret, 1))
+			if err := telegramClient.SetWebhook(context.Background(), webhook); err != nil {
+				level.Warn(logger).Log("msg", "failed to set the telegram webhook", "error", err)
+			}
+		}
+	}
+	var mattermostClient *mattermost.Client
+	if cfg.Mattermost.Path != "" {
+		if cfg.Mattermost.CommandToken == "" {
+			return nil, fmt.Errorf("mattermost needs the token of the slash command")
+		}
+		actionURL := strings.TrimSuffix(cfg.Mattermost.URL, "/") + mattermostActionPath
+		mattermostClient, err = mattermost.NewClient(cfg.Mattermost.Path, cfg.Mattermost.BotToken, actionURL, cfg.Mattermost.CommandToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	auditLog, err := os.OpenFile(auditLogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+	auditLogger := log.With(log.NewLogfmtLogger(log.NewSyncWriter(auditLog)), "ts", log.DefaultTimestampUTC)
+
+	var weeklyReport warez.ReportSchedule
+	if cfg.WeeklyReport.Weekday != "" {
+		day, err := weekday(cfg.WeeklyReport.Weekday)
+		if err != nil {
+			return nil, err
+		}
+		weeklyReport = warez.ReportSchedule{
+			Enabled: true,
+			Weekday: day,
+			Hour:    cfg.WeeklyReport.Hour,
+		}
+	}
+
+	var sinks []warez.Sink
+	for _, n := range cfg.Notifications {
+		notifier, err := newNotifier(n)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, warez.Sink{Notifier: notifier, Events: n.Events})
+	}
+
+	var apiUsers []warez.APIUser
+	for _, u := range cfg.APIUsers {
+		if u.APIKey == "" || u.SlackUser == "" {
+			return nil, fmt.Errorf("api user %q needs an apikey and a slackuser", u.Name)
+		}
+		apiUsers = append(apiUsers, warez.APIUser{Key: u.APIKey, SlackUser: u.SlackUser, Name: u.Name})
+	}
 
-	svc, err := warez.NewService(embyClient, radarrClient, slackClient, logger)
+	svc, err := warez.NewService(warez.Config{
+		Media:             mediaServer,
+		Movies:            radarrClient,
+		Artists:           artists,
+		Albums:            albums,
+		Books:             books,
+		Audiobooks:        audiobooks,
+		Downloader:        torrentClient,
+		Usenet:            usenetClient,
+		UsenetAlerts:      cfg.Usenet.FailureAlerts,
+		Indexer:           indexer,
+		Subtitles:         subtitles,
+		SubtitleLanguages: cfg.Bazarr.Languages,
+		Slack:             slackClient,
+		Discord:           discordClient,
+		Matrix:            matrixClient,
+		MatrixRooms:       cfg.Matrix.Rooms,
+		Telegram:          telegramClient,
+		TelegramUsers:     cfg.Telegram.Users,
+		TelegramPolling:   cfg.Telegram.URL == "",
+		Mattermost:        mattermostClient,
+		MattermostToken:   cfg.Mattermost.CommandToken,
+		Logger:            logger,
+		Admins:            cfg.Slack.Admins,
+		AuditLogger:       auditLogger,
+		DataDir:           cfg.DataDir,
+		RequestQuota:      cfg.RequestQuota,
+		VoteThreshold:     cfg.Voting.Threshold,
+		VoteWindow:        time.Duration(cfg.Voting.Hours) * time.Hour,
+		WatchlistRequests: cfg.Watchlist.AutoRequest,
+		InvitePolicy: emby.PolicyTemplate{
+			EnabledFolders:           cfg.Emby.InvitePolicy.Libraries,
+			SimultaneousStreamLimit:  cfg.Emby.InvitePolicy.SimultaneousStreamLimit,
+			RemoteClientBitrateLimit: cfg.Emby.InvitePolicy.RemoteClientBitrateLimit,
+		},
+		WeeklyReport: weeklyReport,
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	d := &WarezDaemon{
-		logger: logger,
+		discordPublicKey: cfg.Discord.PublicKey,
+		mediaServer:      cfg.MediaServer,
+		slackSecret:      cfg.Slack.SigningSecret,
+		telegramSecret:   cfg.Telegram.Secret,
+		logger:           logger,
+	}
+
+	if cfg.Slack.AppToken != "" {
+		socketMode, err := slack.NewSocketMode(cfg.Slack.AppToken, logger)
+		if err != nil {
+			return nil, err
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		d.stopSocketMode = cancel
+		d.slackSocketMode = true
+		go socketMode.Run(ctx, slackEnvelopeHandler(svc))
 	}
 
 	d.HTTPSDaemon, err = NewHTTPDaemon(HTTPSConfig{
@@ -53,6 +254,9 @@ func NewWarezDaemon(logger log.Logger, requestLogPath string, config string) (*W
 		Logger:  logger,
 		LogPath: requestLogPath,
 		TLSCfg:  cfg.TLSConfig,
+		// Slack doesn't need to reach the bot over Socket Mode, so TLS is
+		// only required if a certificate is given.
+		Plain: cfg.Slack.AppToken != "" && cfg.TLSConfig.TLSCert == "",
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize HTTPS Daemon: %v", err)
@@ -61,6 +265,112 @@ func NewWarezDaemon(logger log.Logger, requestLogPath string, config string) (*W
 	return d, nil
 }
 
+// Run serves HTTP until the daemon is told to stop, and then stops receiving
+// from Slack as well.
+func (wd *WarezDaemon) Run(httpListenAddr string) error {
+	err := wd.HTTPSDaemon.Run(httpListenAddr)
+	if wd.stopSocketMode != nil {
+		wd.stopSocketMode()
+	}
+	return err
+}
+
+// slackEnvelopeHandler feeds what Slack sends over Socket Mode to the code
+// behind the HTTP endpoints.
+func slackEnvelopeHandler(svc warez.Service) slack.EnvelopeHandler {
+	return func(ctx context.Context, e slack.Envelope) (interface{}, error) {
+		switch e.Type {
+		case slack.EnvelopeEvents:
+			var event warez.SlackEvent
+			if err := json.Unmarshal(e.Payload, &event); err != nil {
+				return nil, fmt.Errorf("error unmarshaling event: %v", err)
+			}
+			_, err := svc.ProcessSlackEvents(ctx, event)
+			return nil, err
+		case slack.EnvelopeInteractive:
+			var action warez.SlackAction
+			if err := json.Unmarshal(e.Payload, &action); err != nil {
+				return nil, fmt.Errorf("error unmarshaling action request: %v", err)
+			}
+			resp, err := svc.ProcessSlackActions(ctx, action)
+			return resp.Payload, err
+		}
+		return nil, nil
+	}
+}
+
+// newMediaServer creates the client of the media server picked in the config,
+// Emby unless told otherwise.
+func newMediaServer(cfg *config) (warez.MediaServer, error) {
+	switch cfg.MediaServer {
+	case "", "emby":
+		return emby.NewClient(cfg.Emby.Path, cfg.Emby.Token, cfg.Emby.AdminID)
+	case "jellyfin":
+		return jellyfin.NewClient(cfg.Jellyfin.Path, cfg.Jellyfin.Token, cfg.Jellyfin.UserID)
+	case "plex":
+		return plex.NewClient(cfg.Plex.Path, cfg.Plex.Token, cfg.Plex.Posters)
+	default:
+		return nil, fmt.Errorf("unknown media server %q", cfg.MediaServer)
+	}
+}
+
+// newReadarr creates a Readarr client for items of the kind if it is
+// configured.
+func newReadarr(cfg readarrConfig, kind string) (warez.MediaManager, error) {
+	if cfg.Path == "" {
+		return nil, nil
+	}
+	return readarr.NewClient(cfg.Path, cfg.APIKey, kind, cfg.QualityProfileID, cfg.MetadataProfileID, cfg.RootFolderPath)
+}
+
+// newDownloader creates the client of the torrent client picked in the
+// config, if any.
+func newDownloader(cfg *config) (warez.Downloader, error) {
+	d := cfg.Downloader
+	switch d.Type {
+	case "":
+		return nil, nil
+	case "qbittorrent":
+		return downloader.NewQBittorrent(d.Path, d.Username, d.Password)
+	case "transmission":
+		return downloader.NewTransmission(d.Path, d.Username, d.Password)
+	default:
+		return nil, fmt.Errorf("unknown downloader %q", d.Type)
+	}
+}
+
+// newUsenet creates the client of the usenet client picked in the config, if
+// any.
+func newUsenet(cfg *config) (warez.Usenet, error) {
+	u := cfg.Usenet
+	switch u.Type {
+	case "":
+		return nil, nil
+	case "sabnzbd":
+		return downloader.NewSABnzbd(u.Path, u.APIKey)
+	case "nzbget":
+		return downloader.NewNZBGet(u.Path, u.Username, u.Password)
+	default:
+		return nil, fmt.Errorf("unknown usenet client %q", u.Type)
+	}
+}
+
+// newNotifier creates the notification sink of the type given.
+func newNotifier(cfg notificationConfig) (warez.Notifier, error) {
+	switch cfg.Type {
+	case "webhook":
+		return notify.NewWebhook(cfg.URL)
+	case "ntfy":
+		return notify.NewNtfy(cfg.URL, cfg.Topic, cfg.Token)
+	case "gotify":
+		return notify.NewGotify(cfg.URL, cfg.Token, cfg.Priority)
+	case "smtp":
+		return notify.NewSMTP(cfg.Address, cfg.Username, cfg.Password, cfg.From, cfg.To)
+	default:
+		return nil, fmt.Errorf("unknown notification type %q", cfg.Type)
+	}
+}
+
 func SetLoggerLevel(logger log.Logger, levelName string) log.Logger {
 	switch levelName {
 	case "debug":
@@ -2,6 +2,7 @@ package daemon
 
 import (
 	"context"
+	"crypto/subtle"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
@@ -15,6 +16,7 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -25,13 +27,31 @@ import (
 	httptransport "github.com/go-kit/kit/transport/http"
 	"github.com/gorilla/mux"
 
+	"warezbot/discord"
+	"warezbot/jellyfin"
+	"warezbot/plex"
+	"warezbot/slack"
+	"warezbot/telegram"
 	"warezbot/warez"
 )
 
 const (
-	slackProcessPath = "/slack/events"
-	slackInteractive = "/slack/actions"
-	embyEventPath    = "/emby/events"
+	slackProcessPath  = "/slack/events"
+	slackInteractive  = "/slack/actions"
+	embyEventPath     = "/emby/events"
+	jellyfinEventPath = "/jellyfin/events"
+	plexEventPath     = "/plex/events"
+	discordPath       = "/discord/interactions"
+	telegramPath      = "/telegram/{secret}"
+
+	mattermostCommandPath = "/mattermost/command"
+	mattermostActionPath  = "/mattermost/actions"
+
+	// The request API mimics these Overseerr endpoints.
+	apiSearchPath  = "/api/v1/search"
+	apiRequestPath = "/api/v1/request"
+	apiStatusPath  = "/api/v1/request/{id:[0-9]+}"
+	apiKeyHeader   = "X-Api-Key"
 
 	DefaultHTTPIdleTimeout       = 30 * time.Second // The timeout before unused open connections are close
 	DefaultHTTPReadHeaderTimeout = 5 * time.Second  // The max time to read the request header
@@ -54,6 +74,8 @@ type HTTPSConfig struct {
 	Logger             log.Logger
 	LogPath            string
 	TLSCfg             TLSConfig
+	// Plain serves plain HTTP, without loading TLSCfg.
+	Plain bool
 }
 
 type HTTPSDaemon struct {
@@ -62,6 +84,7 @@ type HTTPSDaemon struct {
 	handler           http.Handler
 	idleTimeout       time.Duration
 	logger            log.Logger
+	plain             bool
 	quit              chan bool
 	readHeaderTimeout time.Duration
 	writeTimeout      time.Duration
@@ -70,31 +93,36 @@ type HTTPSDaemon struct {
 func (wd *WarezDaemon) setupHTTP(svc warez.Service) http.Handler {
 	router := mux.NewRouter()
 
-	var slackEventEndpoint endpoint.Endpoint
-	{
-		slackEventEndpoint = slackProcessEndpoint(svc.ProcessSlackEvents)
-	}
-	var slackEventHandler http.Handler
-	{
-		slackEventHandler = httptransport.NewServer(
-			slackEventEndpoint,
-			wd.decodeSlackEvent,
-			wd.encodeWarezResponse)
-	}
-	router.Methods("POST").Path(slackProcessPath).Handler(slackEventHandler)
+	// Over Socket Mode Slack never posts to the bot, so nobody else gets to.
+	if !wd.slackSocketMode {
+		var slackEventEndpoint endpoint.Endpoint
+		{
+			slackEventEndpoint = slackProcessEndpoint(svc.ProcessSlackEvents)
+		}
+		var slackEventHandler http.Handler
+		{
+			slackEventHandler = httptransport.NewServer(
+				slackEventEndpoint,
+				wd.decodeSlackEvent,
+				wd.encodeWarezResponse,
+				httptransport.ServerErrorEncoder(encodeAPIError))
+		}
+		router.Methods("POST").Path(slackProcessPath).Handler(slackEventHandler)
 
-	var slackActionEndpoint endpoint.Endpoint
-	{
-		slackActionEndpoint = slackProcessActionEndpoint(svc.ProcessSlackActions)
-	}
-	var slackActionHandler http.Handler
-	{
-		slackActionHandler = httptransport.NewServer(
-			slackActionEndpoint,
-			wd.decodeSlackAction,
-			wd.encodeWarezNilResponse)
+		var slackActionEndpoint endpoint.Endpoint
+		{
+			slackActionEndpoint = slackProcessActionEndpoint(svc.ProcessSlackActions)
+		}
+		var slackActionHandler http.Handler
+		{
+			slackActionHandler = httptransport.NewServer(
+				slackActionEndpoint,
+				wd.decodeSlackAction,
+				wd.encodeWarezNilResponse,
+				httptransport.ServerErrorEncoder(encodeAPIError))
+		}
+		router.Methods("POST").Path(slackInteractive).Handler(slackActionHandler)
 	}
-	router.Methods("POST").Path(slackInteractive).Handler(slackActionHandler)
 
 	var embyEventEndpoint endpoint.Endpoint
 	{
@@ -107,11 +135,93 @@ func (wd *WarezDaemon) setupHTTP(svc warez.Service) http.Handler {
 			wd.decodeEmbyEvent,
 			wd.encodeWarezResponse)
 	}
-	router.Methods("POST").Path(embyEventPath).Handler(embyEventHandler)
+
+	var jellyfinEventHandler http.Handler
+	{
+		jellyfinEventHandler = httptransport.NewServer(
+			embyEventEndpoint,
+			wd.decodeJellyfinEvent,
+			wd.encodeWarezResponse)
+	}
+
+	var plexEventHandler http.Handler
+	{
+		plexEventHandler = httptransport.NewServer(
+			embyEventEndpoint,
+			wd.decodePlexEvent,
+			wd.encodeWarezResponse)
+	}
+
+	// Only the media server in use may post playback events.
+	switch wd.mediaServer {
+	case "", "emby":
+		router.Methods("POST").Path(embyEventPath).Handler(embyEventHandler)
+	case "jellyfin":
+		router.Methods("POST").Path(jellyfinEventPath).Handler(jellyfinEventHandler)
+	case "plex":
+		router.Methods("POST").Path(plexEventPath).Handler(plexEventHandler)
+	}
+
+	router.Methods("POST").Path(discordPath).Handler(httptransport.NewServer(
+		discordInteractionEndpoint(svc.ProcessDiscordInteractions),
+		wd.decodeDiscordInteraction,
+		wd.encodeAPIResponse,
+		httptransport.ServerErrorEncoder(encodeAPIError)))
+
+	router.Methods("POST").Path(telegramPath).Handler(httptransport.NewServer(
+		telegramUpdateEndpoint(svc.ProcessTelegramUpdates),
+		wd.decodeTelegramUpdate,
+		wd.encodeAPIResponse,
+		httptransport.ServerErrorEncoder(encodeAPIError)))
+
+	router.Methods("POST").Path(mattermostCommandPath).Handler(httptransport.NewServer(
+		mattermostCommandEndpoint(svc.ProcessMattermostCommands),
+		decodeMattermostCommand,
+		wd.encodeAPIResponse,
+		httptransport.ServerErrorEncoder(encodeAPIError)))
+	router.Methods("POST").Path(mattermostActionPath).Handler(httptransport.NewServer(
+		mattermostActionEndpoint(svc.ProcessMattermostActions),
+		decodeMattermostAction,
+		wd.encodeAPIResponse,
+		httptransport.ServerErrorEncoder(encodeAPIError)))
+
+	apiOptions := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(encodeAPIError),
+	}
+	router.Methods("GET").Path(apiSearchPath).Handler(httptransport.NewServer(
+		apiSearchEndpoint(svc.SearchMedia),
+		decodeAPISearch,
+		wd.encodeAPIResponse,
+		apiOptions...))
+	router.Methods("POST").Path(apiRequestPath).Handler(httptransport.NewServer(
+		apiMediaRequestEndpoint(svc.RequestMedia),
+		decodeAPIMediaRequest,
+		wd.encodeAPIResponse,
+		apiOptions...))
+	router.Methods("GET").Path(apiRequestPath).Handler(httptransport.NewServer(
+		apiRequestListEndpoint(svc.ListRequests),
+		decodeAPIRequestList,
+		wd.encodeAPIResponse,
+		apiOptions...))
+	router.Methods("GET").Path(apiStatusPath).Handler(httptransport.NewServer(
+		apiRequestStatusEndpoint(svc.RequestStatus),
+		decodeAPIRequestStatus,
+		wd.encodeAPIResponse,
+		apiOptions...))
 
 	return router
 }
 
+// verifySlackRequest checks that a request was signed by Slack with the
+// signing secret of the app, as the user IDs in it decide who is an admin.
+func (wd *WarezDaemon) verifySlackRequest(r *http.Request, body []byte) error {
+	if !slack.VerifyRequest(wd.slackSecret, r.Header.Get("X-Slack-Signature"), r.Header.Get("X-Slack-Request-Timestamp"), body, time.Now()) {
+		level.Warn(wd.logger).Log("event", "rejected unsigned slack request", "path", r.URL.Path, "remote", r.RemoteAddr)
+		return apiError{http.StatusUnauthorized, "invalid request signature"}
+	}
+	return nil
+}
+
 func (wd *WarezDaemon) decodeSlackAction(ctx context.Context, r *http.Request) (interface{}, error) {
 	var s warez.SlackAction
 	body, err := ioutil.ReadAll(r.Body)
@@ -119,6 +229,9 @@ func (wd *WarezDaemon) decodeSlackAction(ctx context.Context, r *http.Request) (
 		fmt.Print(err)
 		return nil, fmt.Errorf("error reading request body: %v", err)
 	}
+	if err := wd.verifySlackRequest(r, body); err != nil {
+		return nil, err
+	}
 	level.Debug(wd.logger).Log("endpoint", "decodeSlackAction", "body", string(body))
 
 	b, err := url.QueryUnescape(string(body))
@@ -146,6 +259,9 @@ func (wd *WarezDaemon) decodeSlackEvent(ctx context.Context, r *http.Request) (i
 		level.Error(wd.logger).Log("error", e)
 		return nil, e
 	}
+	if err := wd.verifySlackRequest(r, body); err != nil {
+		return nil, err
+	}
 	level.Debug(wd.logger).Log("endpoint", "decodeSlackEvent", "body", string(body))
 
 	if err := json.Unmarshal(body, &s); err != nil {
@@ -201,15 +317,307 @@ func (wd *WarezDaemon) decodeEmbyEvent(ctx context.Context, r *http.Request) (in
 	return e, nil
 }
 
+// decodeJellyfinEvent turns a Jellyfin webhook into the Emby event it
+// corresponds to, so both servers are handled alike.
+func (wd *WarezDaemon) decodeJellyfinEvent(ctx context.Context, r *http.Request) (interface{}, error) {
+	var j jellyfin.WebhookEvent
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		e := fmt.Errorf("error reading request body: %v", err)
+		level.Error(wd.logger).Log("error", e)
+		return nil, e
+	}
+	level.Debug(wd.logger).Log("endpoint", "decodeJellyfinEvent", "body", string(body))
+
+	if err := json.Unmarshal(body, &j); err != nil {
+		e := fmt.Errorf("error unmarshaling jellyfin event: %v", err)
+		level.Error(wd.logger).Log("error", e)
+		return nil, e
+	}
+
+	var e warez.EmbyEvent
+	e.Event = j.EmbyEvent()
+	e.User.ID = j.UserID
+	e.User.Name = j.NotificationUsername
+	e.Item.ID = j.ItemID
+	e.Item.Name = j.Name
+	e.Item.Type = j.ItemType
+	e.Item.SeriesName = j.SeriesName
+	e.Item.Overview = j.Overview
+	e.Item.ProductionYear = j.Year
+	e.Item.IndexNumber = j.EpisodeNumber
+	e.Item.ParentIndexNumber = j.SeasonNumber
+	e.Item.RunTimeTicks = j.RunTimeTicks
+	e.Item.ProviderIds.Imdb = j.ProviderImdb
+	e.Item.ProviderIds.Tvdb = j.ProviderTvdb
+	if j.ProviderImdb != "" {
+		e.Item.ExternalUrls = append(e.Item.ExternalUrls, struct {
+			Name string `json:"Name"`
+			URL  string `json:"Url"`
+		}{Name: "IMDb", URL: "https://www.imdb.com/title/" + j.ProviderImdb})
+	}
+	e.Server.ID = j.ServerID
+	e.Server.Name = j.ServerName
+	// The plugin sends no session ID, the device identifies the session
+	// well enough.
+	e.Session.ID = j.DeviceID
+	e.Session.DeviceID = j.DeviceID
+	e.Session.DeviceName = j.DeviceName
+	e.Session.Client = j.ClientName
+	e.Session.RemoteEndPoint = j.RemoteEndPoint
+	e.PlaybackInfo.PositionTicks = j.PlaybackPositionTicks
+	e.PlaybackInfo.PlayedToCompletion = j.PlayedToCompletion
+
+	return e, nil
+}
+
+// decodePlexEvent turns a Plex webhook into the Emby event it corresponds to.
+// Plex sends the event as the payload part of a multipart form, next to a
+// thumbnail that is ignored.
+func (wd *WarezDaemon) decodePlexEvent(ctx context.Context, r *http.Request) (interface{}, error) {
+	var p plex.WebhookEvent
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		e := fmt.Errorf("not a valid plex event: %v", err)
+		level.Error(wd.logger).Log("error", e)
+		return nil, e
+	}
+
+	found := false
+	mr := multipart.NewReader(r.Body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			level.Error(wd.logger).Log("error", err)
+			return nil, err
+		}
+		if part.FormName() != "payload" {
+			continue
+		}
+		data, err := ioutil.ReadAll(part)
+		if err != nil {
+			level.Error(wd.logger).Log("error", err)
+			return nil, err
+		}
+		level.Debug(wd.logger).Log("endpoint", "decodePlexEvent", "body", string(data))
+
+		if err := json.Unmarshal(data, &p); err != nil {
+			level.Error(wd.logger).Log("error", err)
+			return nil, err
+		}
+		found = true
+	}
+	if !found {
+		e := errors.New("plex event has no payload")
+		level.Error(wd.logger).Log("error", e)
+		return nil, e
+	}
+
+	var e warez.EmbyEvent
+	e.Event = p.EmbyEvent()
+	e.User.ID = strconv.Itoa(p.Account.ID)
+	e.User.Name = p.Account.Title
+	e.Item.ID = p.Metadata.RatingKey
+	e.Item.Name = p.Metadata.Title
+	e.Item.Type = p.Metadata.EmbyType()
+	e.Item.SeriesName = p.Metadata.GrandparentTitle
+	e.Item.Overview = p.Metadata.Summary
+	e.Item.ProductionYear = p.Metadata.Year
+	e.Item.IndexNumber = p.Metadata.Index
+	e.Item.ParentIndexNumber = p.Metadata.ParentIndex
+	e.Item.ProviderIds.Imdb = p.Metadata.ProviderID("imdb")
+	e.Item.ProviderIds.Tvdb = p.Metadata.ProviderID("tvdb")
+	if imdb := e.Item.ProviderIds.Imdb; imdb != "" {
+		e.Item.ExternalUrls = append(e.Item.ExternalUrls, struct {
+			Name string `json:"Name"`
+			URL  string `json:"Url"`
+		}{Name: "IMDb", URL: "https://www.imdb.com/title/" + imdb})
+	}
+	e.Server.ID = p.Server.UUID
+	e.Server.Name = p.Server.Title
+	// Plex sends no session ID, the player identifies the session well
+	// enough.
+	e.Session.ID = p.Player.UUID
+	e.Session.DeviceID = p.Player.UUID
+	e.Session.DeviceName = p.Player.Title
+	e.Session.RemoteEndPoint = p.Player.PublicAddress
+
+	return e, nil
+}
+
+// decodeDiscordInteraction checks that the interaction was signed by Discord,
+// which it tests by sending badly signed ones before accepting the URL.
+func (wd *WarezDaemon) decodeDiscordInteraction(ctx context.Context, r *http.Request) (interface{}, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, apiError{http.StatusBadRequest, fmt.Sprintf("error reading request body: %v", err)}
+	}
+	if !discord.VerifyRequest(wd.discordPublicKey, r.Header.Get("X-Signature-Ed25519"), r.Header.Get("X-Signature-Timestamp"), body) {
+		return nil, apiError{http.StatusUnauthorized, "invalid request signature"}
+	}
+	level.Debug(wd.logger).Log("endpoint", "decodeDiscordInteraction", "body", string(body))
+
+	var interaction warez.DiscordInteraction
+	if err := json.Unmarshal(body, &interaction); err != nil {
+		return nil, apiError{http.StatusBadRequest, fmt.Sprintf("invalid interaction: %v", err)}
+	}
+
+	return interaction, nil
+}
+
+// decodeTelegramUpdate only accepts updates posted to the secret path set as
+// the webhook.
+func (wd *WarezDaemon) decodeTelegramUpdate(ctx context.Context, r *http.Request) (interface{}, error) {
+	secret := mux.Vars(r)["secret"]
+	if wd.telegramSecret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(wd.telegramSecret)) != 1 {
+		return nil, apiError{http.StatusNotFound, "not found"}
+	}
+
+	var update telegram.Update
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		return nil, apiError{http.StatusBadRequest, fmt.Sprintf("invalid update: %v", err)}
+	}
+
+	return update, nil
+}
+
+func decodeMattermostCommand(ctx context.Context, r *http.Request) (interface{}, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, apiError{http.StatusBadRequest, fmt.Sprintf("invalid command: %v", err)}
+	}
+
+	return warez.MattermostCommand{
+		Token:     r.PostForm.Get("token"),
+		ChannelID: r.PostForm.Get("channel_id"),
+		UserID:    r.PostForm.Get("user_id"),
+		UserName:  r.PostForm.Get("user_name"),
+		Command:   r.PostForm.Get("command"),
+		Text:      r.PostForm.Get("text"),
+	}, nil
+}
+
+func decodeMattermostAction(ctx context.Context, r *http.Request) (interface{}, error) {
+	var action warez.MattermostAction
+	if err := json.NewDecoder(r.Body).Decode(&action); err != nil {
+		return nil, apiError{http.StatusBadRequest, fmt.Sprintf("invalid action: %v", err)}
+	}
+
+	return action, nil
+}
+
+// apiError is a bad request to the request API, reported the way Overseerr
+// reports errors.
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e apiError) Error() string {
+	return e.message
+}
+
+func encodeAPIError(ctx context.Context, err error, w http.ResponseWriter) {
+	status := http.StatusInternalServerError
+	if e, ok := err.(apiError); ok {
+		status = e.status
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Message string `json:"message"`
+	}{err.Error()})
+}
+
+func decodeAPISearch(ctx context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+	page, err := optionalInt(query.Get("page"))
+	if err != nil {
+		return nil, apiError{http.StatusBadRequest, "page must be a number"}
+	}
+
+	return warez.APISearch{
+		APIKey: r.Header.Get(apiKeyHeader),
+		Query:  query.Get("query"),
+		Page:   page,
+	}, nil
+}
+
+func decodeAPIMediaRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var request warez.APIMediaRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, apiError{http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err)}
+	}
+	request.APIKey = r.Header.Get(apiKeyHeader)
+
+	return request, nil
+}
+
+func decodeAPIRequestList(ctx context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+	take, err := optionalInt(query.Get("take"))
+	if err != nil {
+		return nil, apiError{http.StatusBadRequest, "take must be a number"}
+	}
+	skip, err := optionalInt(query.Get("skip"))
+	if err != nil {
+		return nil, apiError{http.StatusBadRequest, "skip must be a number"}
+	}
+
+	return warez.APIRequestList{
+		APIKey: r.Header.Get(apiKeyHeader),
+		Take:   take,
+		Skip:   skip,
+	}, nil
+}
+
+func decodeAPIRequestStatus(ctx context.Context, r *http.Request) (interface{}, error) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		return nil, apiError{http.StatusBadRequest, "invalid request id"}
+	}
+
+	return warez.APIRequestStatus{
+		APIKey:    r.Header.Get(apiKeyHeader),
+		RequestID: id,
+	}, nil
+}
+
+// optionalInt parses a query parameter that may be left out.
+func optionalInt(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(s)
+}
+
+func (wd *WarezDaemon) encodeAPIResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	resp, ok := response.(warez.Response)
+	if !ok {
+		return errors.New("endpoint response error")
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(resp.StatusCode)
+	return json.NewEncoder(w).Encode(resp.Payload)
+}
+
 func (wd *WarezDaemon) encodeWarezNilResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
 	resp, ok := response.(warez.Response)
 	if !ok {
 		return errors.New("endpoint response error")
 	}
-	if resp.StatusCode != http.StatusAccepted {
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
 		w.WriteHeader(resp.StatusCode)
 		return errors.New("something went wrong")
 	}
+	if resp.Payload != nil {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(resp.StatusCode)
+		return json.NewEncoder(w).Encode(resp.Payload)
+	}
 	w.WriteHeader(resp.StatusCode)
 	return nil
 }
@@ -258,14 +666,107 @@ func embyProcessEndpoint(searchFunc warez.EmbyEventFunc) endpoint.Endpoint {
 	}
 }
 
+func apiSearchEndpoint(searchFunc warez.APISearchFunc) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(warez.APISearch)
+		if !ok {
+			return nil, fmt.Errorf("unknown request data")
+		}
+
+		return searchFunc(ctx, req)
+	}
+}
+
+func apiMediaRequestEndpoint(requestFunc warez.APIMediaRequestFunc) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(warez.APIMediaRequest)
+		if !ok {
+			return nil, fmt.Errorf("unknown request data")
+		}
+
+		return requestFunc(ctx, req)
+	}
+}
+
+func apiRequestListEndpoint(listFunc warez.APIRequestListFunc) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(warez.APIRequestList)
+		if !ok {
+			return nil, fmt.Errorf("unknown request data")
+		}
+
+		return listFunc(ctx, req)
+	}
+}
+
+func apiRequestStatusEndpoint(statusFunc warez.APIRequestStatusFunc) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(warez.APIRequestStatus)
+		if !ok {
+			return nil, fmt.Errorf("unknown request data")
+		}
+
+		return statusFunc(ctx, req)
+	}
+}
+
+func discordInteractionEndpoint(interactionFunc warez.DiscordInteractionFunc) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(warez.DiscordInteraction)
+		if !ok {
+			return nil, fmt.Errorf("unknown request data")
+		}
+
+		return interactionFunc(ctx, req)
+	}
+}
+
+func telegramUpdateEndpoint(updateFunc warez.TelegramUpdateFunc) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(telegram.Update)
+		if !ok {
+			return nil, fmt.Errorf("unknown request data")
+		}
+
+		return updateFunc(ctx, req)
+	}
+}
+
+func mattermostCommandEndpoint(commandFunc warez.MattermostCommandFunc) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(warez.MattermostCommand)
+		if !ok {
+			return nil, fmt.Errorf("unknown request data")
+		}
+
+		return commandFunc(ctx, req)
+	}
+}
+
+func mattermostActionEndpoint(actionFunc warez.MattermostActionFunc) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(warez.MattermostAction)
+		if !ok {
+			return nil, fmt.Errorf("unknown request data")
+		}
+
+		return actionFunc(ctx, req)
+	}
+}
+
 // http related functions below
 
 func NewHTTPDaemon(cfg HTTPSConfig) (*HTTPSDaemon, error) {
 	handler := cfg.Handler
 
-	cert, caPool, err := loadTLSCertificates(cfg.TLSCfg.TLSCert, cfg.TLSCfg.TLSKey, cfg.TLSCfg.TLSCA)
-	if err != nil {
-		return nil, err
+	var cert tls.Certificate
+	var caPool *x509.CertPool
+	if !cfg.Plain {
+		var err error
+		cert, caPool, err = loadTLSCertificates(cfg.TLSCfg.TLSCert, cfg.TLSCfg.TLSKey, cfg.TLSCfg.TLSCA)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	d := &HTTPSDaemon{
@@ -273,6 +774,7 @@ func NewHTTPDaemon(cfg HTTPSConfig) (*HTTPSDaemon, error) {
 		cert:              cert,
 		handler:           handler,
 		logger:            log.With(cfg.Logger, "source", "HTTPSDaemon"),
+		plain:             cfg.Plain,
 		quit:              make(chan bool),
 		idleTimeout:       cfg.IdleTimeout,
 		readHeaderTimeout: cfg.ReadHeaderTimeout,
@@ -309,7 +811,11 @@ func (d *HTTPSDaemon) Run(httpListenAddr string) error {
 			WriteTimeout: d.writeTimeout,
 		}
 		go func() {
-			if err := srv.ListenAndServeTLS("", ""); err != nil {
+			serve := func() error { return srv.ListenAndServeTLS("", "") }
+			if d.plain {
+				serve = srv.ListenAndServe
+			}
+			if err := serve(); err != nil {
 				level.Error(d.logger).Log("event", "failed to start HTTPS server", "error", err)
 				close(d.quit)
 			}
@@ -0,0 +1,276 @@
+// Package discord answers Discord interactions: slash commands and buttons.
+// Interactions are answered through their webhook, so posting needs no bot
+// token, only registering the commands does.
+package discord
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	httpTimeout = 10 * time.Second
+	apiURL      = "https://discord.com/api/v10"
+
+	// flagEphemeral makes a message visible to the user who ran the command
+	// only.
+	flagEphemeral = 1 << 6
+
+	downloadPrefix = "download:"
+)
+
+// Interaction response types.
+const (
+	ResponsePong                  = 1
+	ResponseDeferredMessage       = 5
+	ResponseDeferredUpdateMessage = 6
+)
+
+// Interaction types.
+const (
+	InteractionPing      = 1
+	InteractionCommand   = 2
+	InteractionComponent = 3
+)
+
+type Embed struct {
+	Title       string       `json:"title,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Color       int          `json:"color,omitempty"`
+	Author      *EmbedAuthor `json:"author,omitempty"`
+	Thumbnail   *EmbedImage  `json:"thumbnail,omitempty"`
+	Footer      *EmbedFooter `json:"footer,omitempty"`
+	Fields      []EmbedField `json:"fields,omitempty"`
+}
+
+type EmbedAuthor struct {
+	Name string `json:"name"`
+}
+
+type EmbedImage struct {
+	URL string `json:"url"`
+}
+
+type EmbedFooter struct {
+	Text string `json:"text"`
+}
+
+type EmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+// Component is an action row or a button in it.
+type Component struct {
+	Type       int         `json:"type"`
+	Style      int         `json:"style,omitempty"`
+	Label      string      `json:"label,omitempty"`
+	CustomID   string      `json:"custom_id,omitempty"`
+	Components []Component `json:"components,omitempty"`
+}
+
+type Message struct {
+	Content    string       `json:"content"`
+	Embeds     []Embed      `json:"embeds,omitempty"`
+	Components *[]Component `json:"components,omitempty"`
+	Flags      int          `json:"flags,omitempty"`
+}
+
+// command is a slash command as registered with Discord.
+type command struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Options     []commandOption `json:"options,omitempty"`
+}
+
+type commandOption struct {
+	Type        int            `json:"type"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Required    bool           `json:"required"`
+	Choices     []optionChoice `json:"choices,omitempty"`
+}
+
+type optionChoice struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// optionString is the type of string options.
+const optionString = 3
+
+// commands are the slash commands the bot answers, named like the Slack
+// commands they match.
+var commands = []command{
+	{
+		Name:        "search",
+		Description: "Search the media server",
+		Options: []commandOption{
+			{Type: optionString, Name: "term", Description: "What to search for", Required: true},
+		},
+	},
+	{
+		Name:        "nowplaying",
+		Description: "Show what is playing",
+	},
+	{
+		Name:        "add",
+		Description: "Search for something to download",
+		Options: []commandOption{
+			{
+				Type:        optionString,
+				Name:        "kind",
+				Description: "What kind of title",
+				Required:    true,
+				Choices: []optionChoice{
+					{Name: "movie", Value: "movie"},
+					{Name: "artist", Value: "artist"},
+					{Name: "album", Value: "album"},
+					{Name: "book", Value: "book"},
+					{Name: "audiobook", Value: "audiobook"},
+				},
+			},
+			{Type: optionString, Name: "title", Description: "The title to search for", Required: true},
+		},
+	},
+	{
+		Name:        "queue",
+		Description: "Show what is being downloaded",
+	},
+	{
+		Name:        "requests",
+		Description: "Show the latest requests",
+	},
+	{
+		Name:        "subs",
+		Description: "List missing subtitles, or search subtitles for a title",
+		Options: []commandOption{
+			{Type: optionString, Name: "title", Description: "A movie, or an episode as <series> S01E02"},
+			{Type: optionString, Name: "language", Description: "Two letter language code"},
+		},
+	},
+}
+
+type Client struct {
+	applicationID string
+	botToken      string
+	http          http.Client
+}
+
+// NewClient creates a client of the Discord application. The bot token is
+// only needed to register the commands.
+func NewClient(applicationID string, botToken string) (*Client, error) {
+	if applicationID == "" {
+		return nil, fmt.Errorf("discord needs an application id")
+	}
+	return &Client{
+		applicationID: applicationID,
+		botToken:      botToken,
+		http: http.Client{
+			Timeout: httpTimeout,
+		},
+	}, nil
+}
+
+// VerifyRequest checks the signature Discord puts on every interaction with
+// the public key of the application, given in hex.
+func VerifyRequest(publicKey string, signature string, timestamp string, body []byte) bool {
+	key, err := hex.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return false
+	}
+	sig, err := hex.DecodeString(signature)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+
+	return ed25519.Verify(ed25519.PublicKey(key), append([]byte(timestamp), body...), sig)
+}
+
+// DownloadCustomID is the custom ID of the button to download the item of
+// the kind with the ID.
+func DownloadCustomID(kind string, id string) string {
+	return fmt.Sprintf("%s%s:%s", downloadPrefix, kind, id)
+}
+
+// ParseDownloadCustomID returns the kind and ID of the item of a download
+// button.
+func ParseDownloadCustomID(customID string) (string, string, bool) {
+	if !strings.HasPrefix(customID, downloadPrefix) {
+		return "", "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(customID, downloadPrefix), ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// RegisterCommands registers the slash commands in the guild, or globally if
+// guildID is empty. Global commands take up to an hour to show up.
+func (c *Client) RegisterCommands(ctx context.Context, guildID string) error {
+	if c.botToken == "" {
+		return fmt.Errorf("registering discord commands needs a bot token")
+	}
+
+	path := fmt.Sprintf("applications/%s/commands", c.applicationID)
+	if guildID != "" {
+		path = fmt.Sprintf("applications/%s/guilds/%s/commands", c.applicationID, guildID)
+	}
+	return c.do(ctx, "PUT", path, commands, true)
+}
+
+// FollowUp posts a message in answer to the interaction with the token.
+func (c *Client) FollowUp(ctx context.Context, token string, m Message) error {
+	return c.do(ctx, "POST", fmt.Sprintf("webhooks/%s/%s", c.applicationID, token), m, false)
+}
+
+// EditOriginal replaces the message the interaction with the token belongs
+// to, e.g. to remove the buttons that were clicked.
+func (c *Client) EditOriginal(ctx context.Context, token string, m Message) error {
+	return c.do(ctx, "PATCH", fmt.Sprintf("webhooks/%s/%s/messages/@original", c.applicationID, token), m, false)
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, in interface{}, auth bool) error {
+	input, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/%s", apiURL, path), bytes.NewReader(input))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if auth {
+		req.Header.Set("Authorization", "Bot "+c.botToken)
+	}
+	req = req.WithContext(ctx)
+	response, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode >= http.StatusBadRequest {
+		// Leave the path out, it holds the interaction token.
+		return fmt.Errorf("discord returned %s for %s: %s", response.Status, method, body)
+	}
+
+	return nil
+}
@@ -0,0 +1,199 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"warezbot/emby"
+	"warezbot/media"
+)
+
+const (
+	embedColor = 0x5865f2
+	// maxResults is how many search results are shown, one button each in
+	// a single action row.
+	maxResults = 5
+	// maxContent is how long a message may be.
+	maxContent   = 2000
+	maxCellWidth = 40
+)
+
+// Reply answers the user who ran the command, visible to them only.
+func (c *Client) Reply(ctx context.Context, token string, text string) error {
+	return c.FollowUp(ctx, token, Message{Content: text, Flags: flagEphemeral})
+}
+
+// PostSearch shows the items found with a button to download each.
+func (c *Client) PostSearch(ctx context.Context, token string, kind string, items media.Items) error {
+	if len(items) == 0 {
+		return c.FollowUp(ctx, token, Message{Content: fmt.Sprintf("No %s found.", kind)})
+	}
+	if len(items) > maxResults {
+		items = items[:maxResults]
+	}
+
+	m := Message{Content: fmt.Sprintf("Select %s to download", kind)}
+	row := Component{Type: 1}
+	for i, item := range items {
+		name := item.Title
+		if item.Creator != "" {
+			name = fmt.Sprintf("%s by %s", item.Title, item.Creator)
+		}
+
+		embed := Embed{
+			Title:       fmt.Sprintf("%d.) %s", i+1, name),
+			Description: truncate(item.Overview, 300),
+			Color:       embedColor,
+		}
+		if item.Year > 0 {
+			embed.Footer = &EmbedFooter{Text: strconv.Itoa(item.Year)}
+		}
+		if item.ImageURL != "" {
+			embed.Thumbnail = &EmbedImage{URL: item.ImageURL}
+		}
+		m.Embeds = append(m.Embeds, embed)
+
+		row.Components = append(row.Components, Component{
+			Type:     2,
+			Style:    1,
+			Label:    truncate(fmt.Sprintf("%d.) %s", i+1, item.Title), 80),
+			CustomID: DownloadCustomID(kind, item.ID),
+		})
+	}
+	m.Components = &[]Component{row}
+
+	return c.FollowUp(ctx, token, m)
+}
+
+// PostLibrarySearch shows the movies and shows found on the media server.
+func (c *Client) PostLibrarySearch(ctx context.Context, token string, results emby.SearchResults) error {
+	m := Message{}
+	var count int
+	for _, result := range results.SearchHints {
+		if result.Type != "Movie" && result.Type != "Episode" && result.Type != "Series" {
+			continue
+		}
+		count++
+		// A message holds 10 embeds at most.
+		if len(m.Embeds) == 10 {
+			continue
+		}
+
+		embed := Embed{
+			Title:       fmt.Sprintf("%s - %s", result.Name, result.Type),
+			Description: truncate(result.ItemDetail.Overview, 300),
+			Color:       embedColor,
+		}
+		if result.ProductionYear > 0 {
+			embed.Footer = &EmbedFooter{Text: strconv.Itoa(result.ProductionYear)}
+		}
+		if result.ItemImages.TotalRecordCount > 0 {
+			embed.Thumbnail = &EmbedImage{URL: result.ItemImages.Images[0].URL}
+		}
+		m.Embeds = append(m.Embeds, embed)
+	}
+	m.Content = fmt.Sprintf("Total results found: %d", count)
+
+	return c.FollowUp(ctx, token, m)
+}
+
+// NowPlaying shows a card for every session playing something.
+func (c *Client) NowPlaying(ctx context.Context, token string, sessions emby.Sessions) error {
+	m := Message{}
+	for _, ses := range sessions {
+		item := ses.NowPlayingItem
+		if item.Name == "" || len(m.Embeds) == 10 {
+			continue
+		}
+
+		title := item.Name
+		if item.Type == "Episode" {
+			title = fmt.Sprintf("%s - %s (Season %d - %d)", item.SeriesName, item.Name, item.ParentIndexNumber, item.IndexNumber)
+		}
+		status := "Playing"
+		if ses.PlayState.IsPaused {
+			status = "Paused"
+		}
+		var progress float64
+		if item.RunTimeTicks > 0 {
+			progress = math.Round(float64(ses.PlayState.PositionTicks) * 100 / float64(item.RunTimeTicks))
+		}
+
+		embed := Embed{
+			Title:       title,
+			Description: fmt.Sprintf("%s is %s - %g%%", ses.UserName, strings.ToLower(status), progress),
+			Color:       embedColor,
+			Author:      &EmbedAuthor{Name: fmt.Sprintf("%s - %s", ses.DeviceName, ses.Client)},
+		}
+		if ses.ItemDetail.Overview != "" {
+			embed.Footer = &EmbedFooter{Text: truncate(ses.ItemDetail.Overview, 300)}
+		}
+		if ses.ItemImages.TotalRecordCount > 0 {
+			embed.Thumbnail = &EmbedImage{URL: ses.ItemImages.Images[0].URL}
+		}
+		m.Embeds = append(m.Embeds, embed)
+	}
+	if len(m.Embeds) == 0 {
+		m.Content = "Nothing is playing."
+	}
+
+	return c.FollowUp(ctx, token, m)
+}
+
+// PostTable posts rows as a fixed width table in a code block.
+func (c *Client) PostTable(ctx context.Context, token string, title string, header []string, rows [][]string) error {
+	if len(rows) == 0 {
+		return c.FollowUp(ctx, token, Message{Content: fmt.Sprintf("**%s**\n_Nothing to show_", title)})
+	}
+
+	widths := make([]int, len(header))
+	for i, h := range header {
+		widths[i] = utf8.RuneCountInString(h)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if n := utf8.RuneCountInString(truncate(cell, maxCellWidth)); i < len(widths) && n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		for i, cell := range cells {
+			if i >= len(widths) {
+				break
+			}
+			cell = truncate(cell, maxCellWidth)
+			b.WriteString(cell)
+			if i < len(cells)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+2))
+			}
+		}
+		b.WriteString("\n")
+	}
+	writeRow(header)
+	for _, row := range rows {
+		writeRow(row)
+	}
+
+	table := b.String()
+	// Leave room for the title and the code fence.
+	if limit := maxContent - len(title) - 20; len(table) > limit {
+		table = table[:strings.LastIndex(table[:limit], "\n")+1]
+	}
+
+	return c.FollowUp(ctx, token, Message{Content: fmt.Sprintf("**%s**\n```\n%s```", title, table)})
+}
+
+// truncate cuts s to n runes, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
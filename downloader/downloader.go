@@ -0,0 +1,58 @@
+// Package downloader talks to the torrent clients that Radarr and friends
+// hand their downloads to.
+package downloader
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	httpTimeout = 10 * time.Second
+
+	Downloading = "downloading"
+	Stalled     = "stalled"
+	Seeding     = "seeding"
+	Paused      = "paused"
+	Queued      = "queued"
+	Checking    = "checking"
+	Error       = "error"
+)
+
+// Torrent is a torrent as seen by any of the clients. Speeds are in bytes per
+// second and Progress goes from 0 to 1.
+type Torrent struct {
+	Hash          string
+	Name          string
+	State         string
+	Progress      float64
+	DownloadSpeed int64
+	UploadSpeed   int64
+	// ETA is zero when the client can't tell.
+	ETA   time.Duration
+	Ratio float64
+	Size  int64
+	Peers int
+	Seeds int
+	// Message is why the torrent is in the error state.
+	Message string
+}
+
+// Done tells if the torrent finished downloading.
+func (t Torrent) Done() bool {
+	return t.Progress >= 1
+}
+
+// FormatBytes formats a size in bytes, e.g. 1.5 GB.
+func FormatBytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
+}
@@ -0,0 +1,197 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type nzbgetStatus struct {
+	DownloadRate    int64 `json:"DownloadRate"`
+	RemainingSizeMB int64 `json:"RemainingSizeMB"`
+	DownloadPaused  bool  `json:"DownloadPaused"`
+}
+
+type nzbgetGroup struct {
+	NZBID           int    `json:"NZBID"`
+	NZBName         string `json:"NZBName"`
+	Status          string `json:"Status"`
+	Category        string `json:"Category"`
+	FileSizeMB      int64  `json:"FileSizeMB"`
+	RemainingSizeMB int64  `json:"RemainingSizeMB"`
+}
+
+type nzbgetHistory struct {
+	NZBID       int    `json:"NZBID"`
+	Name        string `json:"Name"`
+	Status      string `json:"Status"`
+	Category    string `json:"Category"`
+	FileSizeMB  int64  `json:"FileSizeMB"`
+	HistoryTime int64  `json:"HistoryTime"`
+}
+
+// NZBGet is a client of the NZBGet JSON-RPC API.
+type NZBGet struct {
+	username string
+	password string
+	baseURL  *url.URL
+	http     http.Client
+}
+
+func NewNZBGet(host, username, password string) (*NZBGet, error) {
+	base, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse host %q: %v", host, err)
+	}
+	return &NZBGet{
+		username: username,
+		password: password,
+		baseURL:  base,
+		http: http.Client{
+			Timeout: httpTimeout,
+		},
+	}, nil
+}
+
+func (n *NZBGet) Queue(ctx context.Context) (UsenetQueue, error) {
+	var status nzbgetStatus
+	if err := n.call(ctx, "status", nil, &status); err != nil {
+		return UsenetQueue{}, err
+	}
+	var groups []nzbgetGroup
+	if err := n.call(ctx, "listgroups", []interface{}{0}, &groups); err != nil {
+		return UsenetQueue{}, err
+	}
+
+	queue := UsenetQueue{
+		Paused:   status.DownloadPaused,
+		Speed:    status.DownloadRate,
+		SizeLeft: status.RemainingSizeMB * bytesPerMB,
+	}
+	if queue.Speed > 0 {
+		queue.TimeLeft = time.Duration(queue.SizeLeft/queue.Speed) * time.Second
+	}
+	for _, group := range groups {
+		nzb := NZB{
+			ID:       strconv.Itoa(group.NZBID),
+			Name:     group.NZBName,
+			Status:   strings.Title(strings.ToLower(group.Status)),
+			Category: group.Category,
+			Size:     group.FileSizeMB * bytesPerMB,
+			SizeLeft: group.RemainingSizeMB * bytesPerMB,
+		}
+		queue.Items = append(queue.Items, nzb)
+	}
+
+	return queue, nil
+}
+
+// History returns the last limit finished downloads, most recent first.
+// Downloads that failed, were deleted or finished with a warning have a status
+// like FAILURE/PAR, DELETED/HEALTH or WARNING/SCRIPT, which is also given as
+// the reason.
+func (n *NZBGet) History(ctx context.Context, limit int) ([]HistoryItem, error) {
+	var history []nzbgetHistory
+	if err := n.call(ctx, "history", []interface{}{false}, &history); err != nil {
+		return nil, err
+	}
+	if len(history) > limit {
+		history = history[:limit]
+	}
+
+	var items []HistoryItem
+	for _, h := range history {
+		item := HistoryItem{
+			ID:        strconv.Itoa(h.NZBID),
+			Name:      h.Name,
+			Status:    h.Status,
+			Category:  h.Category,
+			Size:      h.FileSizeMB * bytesPerMB,
+			Completed: time.Unix(h.HistoryTime, 0),
+		}
+		if message, ok := nzbgetFailure(h.Status); ok {
+			item.failed = true
+			item.FailMessage = message
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// nzbgetFailure returns the reason a download with the status did not
+// succeed, if it didn't.
+func nzbgetFailure(status string) (string, bool) {
+	parts := strings.SplitN(status, "/", 2)
+	reason := ""
+	if len(parts) == 2 {
+		reason = parts[1]
+	}
+	switch parts[0] {
+	case "FAILURE":
+		return reason, true
+	case "DELETED", "WARNING":
+		return strings.ToLower(parts[0]) + ": " + reason, true
+	}
+	return "", false
+}
+
+// call runs the RPC method and decodes its result into v.
+func (n *NZBGet) call(ctx context.Context, method string, params []interface{}, v interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	input, err := json.Marshal(struct {
+		Method string        `json:"method"`
+		Params []interface{} `json:"params"`
+	}{method, params})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/jsonrpc", n.baseURL), bytes.NewBuffer(input))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if n.username != "" {
+		req.SetBasicAuth(n.username, n.password)
+	}
+	req = req.WithContext(ctx)
+	response, err := n.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("nzbget returned %s for %s", response.Status, method)
+	}
+
+	var result struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return err
+	}
+	if result.Error != nil {
+		return fmt.Errorf("nzbget %s failed: %s", method, result.Error.Message)
+	}
+
+	return json.Unmarshal(result.Result, v)
+}
@@ -0,0 +1,197 @@
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// qbittorrentNoETA is the ETA qBittorrent reports when it has none.
+const qbittorrentNoETA = 8640000
+
+var qbittorrentStates = map[string]string{
+	"downloading":        Downloading,
+	"forcedDL":           Downloading,
+	"metaDL":             Downloading,
+	"stalledDL":          Stalled,
+	"uploading":          Seeding,
+	"forcedUP":           Seeding,
+	"stalledUP":          Seeding,
+	"pausedDL":           Paused,
+	"pausedUP":           Paused,
+	"queuedDL":           Queued,
+	"queuedUP":           Queued,
+	"checkingDL":         Checking,
+	"checkingUP":         Checking,
+	"checkingResumeData": Checking,
+	"error":              Error,
+	"missingFiles":       Error,
+}
+
+type qbittorrentTorrent struct {
+	Hash      string  `json:"hash"`
+	Name      string  `json:"name"`
+	State     string  `json:"state"`
+	Progress  float64 `json:"progress"`
+	DLSpeed   int64   `json:"dlspeed"`
+	UPSpeed   int64   `json:"upspeed"`
+	ETA       int64   `json:"eta"`
+	Ratio     float64 `json:"ratio"`
+	Size      int64   `json:"size"`
+	NumSeeds  int     `json:"num_seeds"`
+	NumLeechs int     `json:"num_leechs"`
+}
+
+// QBittorrent is a client of the qBittorrent WebUI API. It logs in with a
+// username and password and keeps the session cookie it gets back.
+type QBittorrent struct {
+	username string
+	password string
+	baseURL  *url.URL
+	http     http.Client
+}
+
+func NewQBittorrent(host, username, password string) (*QBittorrent, error) {
+	base, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse host %q: %v", host, err)
+	}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &QBittorrent{
+		username: username,
+		password: password,
+		baseURL:  base,
+		http: http.Client{
+			Timeout: httpTimeout,
+			Jar:     jar,
+		},
+	}, nil
+}
+
+func (q *QBittorrent) Torrents(ctx context.Context) ([]Torrent, error) {
+	body, err := q.do(ctx, "GET", "torrents/info", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var torrents []qbittorrentTorrent
+	if err := json.Unmarshal(body, &torrents); err != nil {
+		return nil, err
+	}
+
+	result := make([]Torrent, len(torrents))
+	for i, t := range torrents {
+		state, ok := qbittorrentStates[t.State]
+		if !ok {
+			state = t.State
+		}
+		result[i] = Torrent{
+			Hash:          t.Hash,
+			Name:          t.Name,
+			State:         state,
+			Progress:      t.Progress,
+			DownloadSpeed: t.DLSpeed,
+			UploadSpeed:   t.UPSpeed,
+			Ratio:         t.Ratio,
+			Size:          t.Size,
+			Peers:         t.NumLeechs,
+			Seeds:         t.NumSeeds,
+		}
+		if t.ETA > 0 && t.ETA < qbittorrentNoETA {
+			result[i].ETA = time.Duration(t.ETA) * time.Second
+		}
+	}
+
+	return result, nil
+}
+
+func (q *QBittorrent) Pause(ctx context.Context, hash string) error {
+	_, err := q.do(ctx, "POST", "torrents/pause", url.Values{"hashes": {hash}})
+	return err
+}
+
+func (q *QBittorrent) Resume(ctx context.Context, hash string) error {
+	_, err := q.do(ctx, "POST", "torrents/resume", url.Values{"hashes": {hash}})
+	return err
+}
+
+// Remove removes the torrent, and the files it downloaded if deleteData is
+// set.
+func (q *QBittorrent) Remove(ctx context.Context, hash string, deleteData bool) error {
+	form := url.Values{
+		"hashes":      {hash},
+		"deleteFiles": {strconv.FormatBool(deleteData)},
+	}
+	_, err := q.do(ctx, "POST", "torrents/delete", form)
+	return err
+}
+
+func (q *QBittorrent) login(ctx context.Context) error {
+	form := url.Values{
+		"username": {q.username},
+		"password": {q.password},
+	}
+	body, status, err := q.request(ctx, "POST", "auth/login", form)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK || strings.TrimSpace(string(body)) != "Ok." {
+		return fmt.Errorf("qbittorrent login failed: %d %s", status, body)
+	}
+	return nil
+}
+
+// do sends a request, logging in first if the session expired.
+func (q *QBittorrent) do(ctx context.Context, method string, path string, form url.Values) ([]byte, error) {
+	body, status, err := q.request(ctx, method, path, form)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusForbidden {
+		if err := q.login(ctx); err != nil {
+			return nil, err
+		}
+		body, status, err = q.request(ctx, method, path, form)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if status >= http.StatusBadRequest {
+		return nil, fmt.Errorf("qbittorrent returned %d for %s %s: %s", status, method, path, body)
+	}
+	return body, nil
+}
+
+func (q *QBittorrent) request(ctx context.Context, method string, path string, form url.Values) ([]byte, int, error) {
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/api/v2/%s", q.baseURL, path), strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	req = req.WithContext(ctx)
+	response, err := q.http.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return body, response.StatusCode, nil
+}
@@ -0,0 +1,125 @@
+package downloader
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeQBittorrent hands out a new session cookie on every login and rejects
+// requests without the current one, like qBittorrent does once a session
+// expires.
+type fakeQBittorrent struct {
+	t       *testing.T
+	logins  int
+	session string
+}
+
+func (f *fakeQBittorrent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/v2/auth/login" {
+		if r.FormValue("username") != "admin" || r.FormValue("password") != "hunter2" {
+			w.Write([]byte("Fails."))
+			return
+		}
+		f.logins++
+		f.session = time.Now().Format(time.RFC3339Nano)
+		http.SetCookie(w, &http.Cookie{Name: "SID", Value: f.session, Path: "/"})
+		w.Write([]byte("Ok."))
+		return
+	}
+
+	cookie, err := r.Cookie("SID")
+	if err != nil || f.session == "" || cookie.Value != f.session {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("Forbidden"))
+		return
+	}
+
+	switch r.URL.Path {
+	case "/api/v2/torrents/info":
+		w.Write([]byte(`[
+			{"hash": "abc", "name": "Movie.2019.1080p", "state": "stalledDL", "progress": 0.5, "dlspeed": 0, "eta": 8640000, "size": 1000, "num_seeds": 0, "num_leechs": 2},
+			{"hash": "def", "name": "Other.2020.720p", "state": "uploading", "progress": 1, "upspeed": 512, "eta": 0, "ratio": 1.5, "size": 500}
+		]`))
+	case "/api/v2/torrents/pause":
+		if got := r.FormValue("hashes"); got != "abc" {
+			f.t.Errorf("hashes = %q, want abc", got)
+		}
+	default:
+		f.t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestQBittorrent(t *testing.T, password string) (*QBittorrent, *fakeQBittorrent) {
+	t.Helper()
+	fake := &fakeQBittorrent{t: t}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	q, err := NewQBittorrent(server.URL, "admin", password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q, fake
+}
+
+func TestQBittorrentLogin(t *testing.T) {
+	q, fake := newTestQBittorrent(t, "hunter2")
+	ctx := context.Background()
+
+	torrents, err := q.Torrents(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fake.logins != 1 {
+		t.Errorf("logged in %d times, want 1", fake.logins)
+	}
+	if len(torrents) != 2 {
+		t.Fatalf("got %d torrents, want 2", len(torrents))
+	}
+	if torrents[0].State != Stalled || torrents[0].ETA != 0 || torrents[0].Peers != 2 {
+		t.Errorf("torrent = %+v, want stalled with no ETA and 2 peers", torrents[0])
+	}
+	if torrents[1].State != Seeding || torrents[1].Ratio != 1.5 {
+		t.Errorf("torrent = %+v, want seeding with a ratio of 1.5", torrents[1])
+	}
+
+	// The session cookie is kept.
+	if err := q.Pause(ctx, "abc"); err != nil {
+		t.Fatal(err)
+	}
+	if fake.logins != 1 {
+		t.Errorf("logged in %d times, want 1", fake.logins)
+	}
+}
+
+func TestQBittorrentRelogin(t *testing.T) {
+	q, fake := newTestQBittorrent(t, "hunter2")
+	ctx := context.Background()
+
+	if _, err := q.Torrents(ctx); err != nil {
+		t.Fatal(err)
+	}
+	fake.session = "expired"
+
+	if _, err := q.Torrents(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if fake.logins != 2 {
+		t.Errorf("logged in %d times, want 2", fake.logins)
+	}
+}
+
+func TestQBittorrentLoginFailure(t *testing.T) {
+	q, fake := newTestQBittorrent(t, "wrong")
+
+	if _, err := q.Torrents(context.Background()); err == nil {
+		t.Error("Torrents succeeded with a wrong password")
+	}
+	if fake.logins != 0 {
+		t.Errorf("logged in %d times, want 0", fake.logins)
+	}
+}
@@ -0,0 +1,150 @@
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type sabnzbdQueue struct {
+	Queue struct {
+		Paused   bool   `json:"paused"`
+		KBPerSec string `json:"kbpersec"`
+		MBLeft   string `json:"mbleft"`
+		TimeLeft string `json:"timeleft"`
+		Slots    []struct {
+			NzoID    string `json:"nzo_id"`
+			Filename string `json:"filename"`
+			Status   string `json:"status"`
+			Cat      string `json:"cat"`
+			MB       string `json:"mb"`
+			MBLeft   string `json:"mbleft"`
+			TimeLeft string `json:"timeleft"`
+		} `json:"slots"`
+	} `json:"queue"`
+}
+
+type sabnzbdHistory struct {
+	History struct {
+		Slots []struct {
+			NzoID       string `json:"nzo_id"`
+			Name        string `json:"name"`
+			Status      string `json:"status"`
+			Category    string `json:"category"`
+			Bytes       int64  `json:"bytes"`
+			Completed   int64  `json:"completed"`
+			FailMessage string `json:"fail_message"`
+		} `json:"slots"`
+	} `json:"history"`
+}
+
+// SABnzbd is a client of the SABnzbd API, authenticated with its API key.
+type SABnzbd struct {
+	apiKey  string
+	baseURL *url.URL
+	http    http.Client
+}
+
+func NewSABnzbd(host, apiKey string) (*SABnzbd, error) {
+	base, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse host %q: %v", host, err)
+	}
+	return &SABnzbd{
+		apiKey:  apiKey,
+		baseURL: base,
+		http: http.Client{
+			Timeout: httpTimeout,
+		},
+	}, nil
+}
+
+func (s *SABnzbd) Queue(ctx context.Context) (UsenetQueue, error) {
+	var q sabnzbdQueue
+	if err := s.get(ctx, url.Values{"mode": {"queue"}}, &q); err != nil {
+		return UsenetQueue{}, err
+	}
+
+	queue := UsenetQueue{
+		Paused:   q.Queue.Paused,
+		Speed:    int64(parseFloat(q.Queue.KBPerSec) * 1024),
+		SizeLeft: int64(parseFloat(q.Queue.MBLeft) * bytesPerMB),
+		TimeLeft: parseClock(q.Queue.TimeLeft),
+	}
+	for _, slot := range q.Queue.Slots {
+		queue.Items = append(queue.Items, NZB{
+			ID:       slot.NzoID,
+			Name:     slot.Filename,
+			Status:   slot.Status,
+			Category: slot.Cat,
+			Size:     int64(parseFloat(slot.MB) * bytesPerMB),
+			SizeLeft: int64(parseFloat(slot.MBLeft) * bytesPerMB),
+			TimeLeft: parseClock(slot.TimeLeft),
+		})
+	}
+
+	return queue, nil
+}
+
+// History returns the last limit finished downloads, most recent first.
+func (s *SABnzbd) History(ctx context.Context, limit int) ([]HistoryItem, error) {
+	var h sabnzbdHistory
+	if err := s.get(ctx, url.Values{"mode": {"history"}, "limit": {strconv.Itoa(limit)}}, &h); err != nil {
+		return nil, err
+	}
+
+	var items []HistoryItem
+	for _, slot := range h.History.Slots {
+		items = append(items, HistoryItem{
+			ID:          slot.NzoID,
+			Name:        slot.Name,
+			Status:      slot.Status,
+			Category:    slot.Category,
+			Size:        slot.Bytes,
+			Completed:   time.Unix(slot.Completed, 0),
+			FailMessage: slot.FailMessage,
+			failed:      slot.Status == "Failed",
+		})
+	}
+
+	return items, nil
+}
+
+func (s *SABnzbd) get(ctx context.Context, query url.Values, v interface{}) error {
+	query.Set("output", "json")
+	query.Set("apikey", s.apiKey)
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api?%s", s.baseURL, query.Encode()), nil)
+	if err != nil {
+		return err
+	}
+
+	req = req.WithContext(ctx)
+	response, err := s.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("sabnzbd returned %s for mode %s", response.Status, query.Get("mode"))
+	}
+
+	// Errors, such as a wrong API key, come back with a 200.
+	var failure struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &failure); err == nil && failure.Error != "" {
+		return fmt.Errorf("sabnzbd: %s", failure.Error)
+	}
+
+	return json.Unmarshal(body, v)
+}
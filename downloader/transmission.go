@@ -0,0 +1,214 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const transmissionSessionHeader = "X-Transmission-Session-Id"
+
+var transmissionFields = []string{
+	"hashString", "name", "status", "percentDone", "rateDownload", "rateUpload",
+	"eta", "uploadRatio", "totalSize", "peersGettingFromUs", "peersSendingToUs",
+	"isStalled", "error", "errorString",
+}
+
+type transmissionTorrent struct {
+	HashString         string  `json:"hashString"`
+	Name               string  `json:"name"`
+	Status             int     `json:"status"`
+	PercentDone        float64 `json:"percentDone"`
+	RateDownload       int64   `json:"rateDownload"`
+	RateUpload         int64   `json:"rateUpload"`
+	ETA                int64   `json:"eta"`
+	UploadRatio        float64 `json:"uploadRatio"`
+	TotalSize          int64   `json:"totalSize"`
+	PeersGettingFromUs int     `json:"peersGettingFromUs"`
+	PeersSendingToUs   int     `json:"peersSendingToUs"`
+	IsStalled          bool    `json:"isStalled"`
+	Error              int     `json:"error"`
+	ErrorString        string  `json:"errorString"`
+}
+
+// state returns the state of the torrent from its status, which goes from
+// stopped (0) to seeding (6).
+func (t transmissionTorrent) state() string {
+	if t.Error != 0 {
+		return Error
+	}
+	switch t.Status {
+	case 0:
+		return Paused
+	case 1, 2:
+		return Checking
+	case 3, 5:
+		return Queued
+	case 4:
+		if t.IsStalled {
+			return Stalled
+		}
+		return Downloading
+	default:
+		return Seeding
+	}
+}
+
+// Transmission is a client of the Transmission RPC API. Transmission rejects
+// requests without its current session ID, and hands out a new one in the
+// rejection, so the client retries once whenever that happens.
+type Transmission struct {
+	username string
+	password string
+	baseURL  *url.URL
+	http     http.Client
+
+	mu        sync.Mutex
+	sessionID string
+}
+
+func NewTransmission(host, username, password string) (*Transmission, error) {
+	base, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse host %q: %v", host, err)
+	}
+	return &Transmission{
+		username: username,
+		password: password,
+		baseURL:  base,
+		http: http.Client{
+			Timeout: httpTimeout,
+		},
+	}, nil
+}
+
+func (t *Transmission) Torrents(ctx context.Context) ([]Torrent, error) {
+	var result struct {
+		Torrents []transmissionTorrent `json:"torrents"`
+	}
+	if err := t.call(ctx, "torrent-get", map[string]interface{}{"fields": transmissionFields}, &result); err != nil {
+		return nil, err
+	}
+
+	torrents := make([]Torrent, len(result.Torrents))
+	for i, tt := range result.Torrents {
+		torrents[i] = Torrent{
+			Hash:          tt.HashString,
+			Name:          tt.Name,
+			State:         tt.state(),
+			Progress:      tt.PercentDone,
+			DownloadSpeed: tt.RateDownload,
+			UploadSpeed:   tt.RateUpload,
+			Ratio:         tt.UploadRatio,
+			Size:          tt.TotalSize,
+			Peers:         tt.PeersGettingFromUs,
+			Seeds:         tt.PeersSendingToUs,
+			Message:       tt.ErrorString,
+		}
+		// Negative ETAs mean unknown or not available.
+		if tt.ETA > 0 {
+			torrents[i].ETA = time.Duration(tt.ETA) * time.Second
+		}
+	}
+
+	return torrents, nil
+}
+
+func (t *Transmission) Pause(ctx context.Context, hash string) error {
+	return t.call(ctx, "torrent-stop", map[string]interface{}{"ids": []string{hash}}, nil)
+}
+
+func (t *Transmission) Resume(ctx context.Context, hash string) error {
+	return t.call(ctx, "torrent-start", map[string]interface{}{"ids": []string{hash}}, nil)
+}
+
+// Remove removes the torrent, and the files it downloaded if deleteData is
+// set.
+func (t *Transmission) Remove(ctx context.Context, hash string, deleteData bool) error {
+	return t.call(ctx, "torrent-remove", map[string]interface{}{
+		"ids":               []string{hash},
+		"delete-local-data": deleteData,
+	}, nil)
+}
+
+// call runs the RPC method and decodes its arguments into v.
+func (t *Transmission) call(ctx context.Context, method string, arguments interface{}, v interface{}) error {
+	input, err := json.Marshal(struct {
+		Method    string      `json:"method"`
+		Arguments interface{} `json:"arguments"`
+	}{method, arguments})
+	if err != nil {
+		return err
+	}
+
+	body, status, err := t.request(ctx, input)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusConflict {
+		body, status, err = t.request(ctx, input)
+		if err != nil {
+			return err
+		}
+	}
+	if status >= http.StatusBadRequest {
+		return fmt.Errorf("transmission returned %d for %s: %s", status, method, body)
+	}
+
+	var response struct {
+		Result    string          `json:"result"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return err
+	}
+	if response.Result != "success" {
+		return fmt.Errorf("transmission %s failed: %s", method, response.Result)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.Unmarshal(response.Arguments, v)
+}
+
+// request posts to the RPC endpoint, remembering the session ID handed out
+// when it is rejected with a conflict.
+func (t *Transmission) request(ctx context.Context, input []byte) ([]byte, int, error) {
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/transmission/rpc", t.baseURL), bytes.NewBuffer(input))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	t.mu.Lock()
+	req.Header.Set(transmissionSessionHeader, t.sessionID)
+	t.mu.Unlock()
+	if t.username != "" {
+		req.SetBasicAuth(t.username, t.password)
+	}
+	req = req.WithContext(ctx)
+	response, err := t.http.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusConflict {
+		t.mu.Lock()
+		t.sessionID = response.Header.Get(transmissionSessionHeader)
+		t.mu.Unlock()
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return body, response.StatusCode, nil
+}
@@ -0,0 +1,131 @@
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeTransmission rejects requests without the current session ID with a
+// conflict that hands out the ID, like Transmission does.
+type fakeTransmission struct {
+	t         *testing.T
+	sessionID string
+	conflicts int
+	calls     []string
+}
+
+func (f *fakeTransmission) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/transmission/rpc" {
+		f.t.Errorf("path = %s, want /transmission/rpc", r.URL.Path)
+	}
+	if user, password, ok := r.BasicAuth(); !ok || user != "admin" || password != "hunter2" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if r.Header.Get(transmissionSessionHeader) != f.sessionID {
+		f.conflicts++
+		w.Header().Set(transmissionSessionHeader, f.sessionID)
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+
+	var call struct {
+		Method    string          `json:"method"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&call); err != nil {
+		f.t.Fatal(err)
+	}
+	f.calls = append(f.calls, call.Method)
+
+	switch call.Method {
+	case "torrent-get":
+		w.Write([]byte(`{"result": "success", "arguments": {"torrents": [
+			{"hashString": "abc", "name": "Movie.2019.1080p", "status": 4, "percentDone": 0.25, "rateDownload": 1024, "eta": 60, "totalSize": 1000, "peersSendingToUs": 3},
+			{"hashString": "def", "name": "Other.2020.720p", "status": 4, "isStalled": true, "eta": -1},
+			{"hashString": "ghi", "name": "Broken", "status": 0, "error": 3, "errorString": "No data found"}
+		]}}`))
+	case "torrent-remove":
+		w.Write([]byte(`{"result": "torrent not found", "arguments": {}}`))
+	default:
+		w.Write([]byte(`{"result": "success", "arguments": {}}`))
+	}
+}
+
+func newTestTransmission(t *testing.T) (*Transmission, *fakeTransmission) {
+	t.Helper()
+	fake := &fakeTransmission{t: t, sessionID: "first"}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	c, err := NewTransmission(server.URL, "admin", "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, fake
+}
+
+func TestTransmissionSessionHandshake(t *testing.T) {
+	c, fake := newTestTransmission(t)
+	ctx := context.Background()
+
+	torrents, err := c.Torrents(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fake.conflicts != 1 {
+		t.Errorf("got %d conflicts, want 1", fake.conflicts)
+	}
+	if len(torrents) != 3 {
+		t.Fatalf("got %d torrents, want 3", len(torrents))
+	}
+	if torrents[0].State != Downloading || torrents[0].ETA != time.Minute || torrents[0].Seeds != 3 {
+		t.Errorf("torrent = %+v, want downloading with a minute left and 3 seeds", torrents[0])
+	}
+	if torrents[1].State != Stalled || torrents[1].ETA != 0 {
+		t.Errorf("torrent = %+v, want stalled with no ETA", torrents[1])
+	}
+	if torrents[2].State != Error || torrents[2].Message != "No data found" {
+		t.Errorf("torrent = %+v, want an error", torrents[2])
+	}
+
+	// The session ID is reused.
+	if err := c.Pause(ctx, "abc"); err != nil {
+		t.Fatal(err)
+	}
+	if fake.conflicts != 1 {
+		t.Errorf("got %d conflicts, want 1", fake.conflicts)
+	}
+}
+
+func TestTransmissionSessionRenewed(t *testing.T) {
+	c, fake := newTestTransmission(t)
+	ctx := context.Background()
+
+	if err := c.Resume(ctx, "abc"); err != nil {
+		t.Fatal(err)
+	}
+	fake.sessionID = "second"
+	if err := c.Resume(ctx, "abc"); err != nil {
+		t.Fatal(err)
+	}
+
+	if fake.conflicts != 2 {
+		t.Errorf("got %d conflicts, want 2", fake.conflicts)
+	}
+	if len(fake.calls) != 2 {
+		t.Errorf("calls = %v, want torrent-start twice", fake.calls)
+	}
+}
+
+func TestTransmissionFailedResult(t *testing.T) {
+	c, _ := newTestTransmission(t)
+
+	if err := c.Remove(context.Background(), "abc", true); err == nil {
+		t.Error("Remove succeeded with a failed result")
+	}
+}
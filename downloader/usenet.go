@@ -0,0 +1,78 @@
+package downloader
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const bytesPerMB = 1024 * 1024
+
+// UsenetQueue is the state of a usenet client and what it is downloading.
+// Sizes are in bytes and the speed in bytes per second.
+type UsenetQueue struct {
+	Paused   bool
+	Speed    int64
+	SizeLeft int64
+	TimeLeft time.Duration
+	Items    []NZB
+}
+
+type NZB struct {
+	ID       string
+	Name     string
+	Status   string
+	Category string
+	Size     int64
+	SizeLeft int64
+	TimeLeft time.Duration
+}
+
+// Progress returns how much of the NZB was downloaded, from 0 to 1.
+func (n NZB) Progress() float64 {
+	if n.Size == 0 {
+		return 0
+	}
+	return float64(n.Size-n.SizeLeft) / float64(n.Size)
+}
+
+// HistoryItem is a finished download, successful or not.
+type HistoryItem struct {
+	ID          string
+	Name        string
+	Status      string
+	Category    string
+	Size        int64
+	Completed   time.Time
+	FailMessage string
+	failed      bool
+}
+
+func (h HistoryItem) Failed() bool {
+	return h.failed
+}
+
+// parseClock parses the h:mm:ss durations SABnzbd reports. Days are counted
+// in the hours.
+func parseClock(s string) time.Duration {
+	parts := strings.Split(s, ":")
+	var d time.Duration
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0
+		}
+		d = d*60 + time.Duration(n)
+	}
+	return d * time.Second
+}
+
+// parseFloat parses the numbers SABnzbd sends as strings, treating anything
+// unparsable as zero.
+func parseFloat(s string) float64 {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
@@ -1,6 +1,7 @@
 package emby
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -402,46 +403,50 @@ type ItemDetail struct {
 }
 
 type ItemImages struct {
-	Images []struct {
-		ProviderName    string  `json:"ProviderName"`
-		URL             string  `json:"Url"`
-		Height          int     `json:"Height"`
-		Width           int     `json:"Width"`
-		Type            string  `json:"Type"`
-		RatingType      string  `json:"RatingType"`
-		CommunityRating float64 `json:"CommunityRating,omitempty"`
-		VoteCount       int     `json:"VoteCount,omitempty"`
-	} `json:"Images"`
+	Images           []Image  `json:"Images"`
 	TotalRecordCount int      `json:"TotalRecordCount"`
 	Providers        []string `json:"Providers"`
 }
 
+type Image struct {
+	ProviderName    string  `json:"ProviderName"`
+	URL             string  `json:"Url"`
+	Height          int     `json:"Height"`
+	Width           int     `json:"Width"`
+	Type            string  `json:"Type"`
+	RatingType      string  `json:"RatingType"`
+	CommunityRating float64 `json:"CommunityRating,omitempty"`
+	VoteCount       int     `json:"VoteCount,omitempty"`
+}
+
 type SearchResults struct {
-	SearchHints []struct {
-		ItemImages              ItemImages
-		ItemDetail              ItemDetail
-		ItemID                  int      `json:"ItemId"`
-		ID                      int      `json:"Id"`
-		Name                    string   `json:"Name"`
-		IndexNumber             int      `json:"IndexNumber,omitempty"`
-		ProductionYear          int      `json:"ProductionYear,omitempty"`
-		PrimaryImageTag         string   `json:"PrimaryImageTag,omitempty"`
-		Type                    string   `json:"Type"`
-		RunTimeTicks            int64    `json:"RunTimeTicks,omitempty"`
-		MediaType               string   `json:"MediaType,omitempty"`
-		Album                   string   `json:"Album,omitempty"`
-		AlbumID                 int      `json:"AlbumId"`
-		AlbumArtist             string   `json:"AlbumArtist,omitempty"`
-		Artists                 []string `json:"Artists,omitempty"`
-		PrimaryImageAspectRatio float64  `json:"PrimaryImageAspectRatio,omitempty"`
-		ParentIndexNumber       int      `json:"ParentIndexNumber,omitempty"`
-		ThumbImageTag           string   `json:"ThumbImageTag,omitempty"`
-		ThumbImageItemID        string   `json:"ThumbImageItemId,omitempty"`
-		BackdropImageTag        string   `json:"BackdropImageTag,omitempty"`
-		BackdropImageItemID     string   `json:"BackdropImageItemId,omitempty"`
-		Series                  string   `json:"Series,omitempty"`
-	} `json:"SearchHints"`
-	TotalRecordCount int `json:"TotalRecordCount"`
+	SearchHints      []SearchHint `json:"SearchHints"`
+	TotalRecordCount int          `json:"TotalRecordCount"`
+}
+
+type SearchHint struct {
+	ItemImages              ItemImages
+	ItemDetail              ItemDetail
+	ItemID                  int      `json:"ItemId"`
+	ID                      int      `json:"Id"`
+	Name                    string   `json:"Name"`
+	IndexNumber             int      `json:"IndexNumber,omitempty"`
+	ProductionYear          int      `json:"ProductionYear,omitempty"`
+	PrimaryImageTag         string   `json:"PrimaryImageTag,omitempty"`
+	Type                    string   `json:"Type"`
+	RunTimeTicks            int64    `json:"RunTimeTicks,omitempty"`
+	MediaType               string   `json:"MediaType,omitempty"`
+	Album                   string   `json:"Album,omitempty"`
+	AlbumID                 int      `json:"AlbumId"`
+	AlbumArtist             string   `json:"AlbumArtist,omitempty"`
+	Artists                 []string `json:"Artists,omitempty"`
+	PrimaryImageAspectRatio float64  `json:"PrimaryImageAspectRatio,omitempty"`
+	ParentIndexNumber       int      `json:"ParentIndexNumber,omitempty"`
+	ThumbImageTag           string   `json:"ThumbImageTag,omitempty"`
+	ThumbImageItemID        string   `json:"ThumbImageItemId,omitempty"`
+	BackdropImageTag        string   `json:"BackdropImageTag,omitempty"`
+	BackdropImageItemID     string   `json:"BackdropImageItemId,omitempty"`
+	Series                  string   `json:"Series,omitempty"`
 }
 
 type Client struct {
@@ -452,11 +457,14 @@ type Client struct {
 }
 
 func NewClient(host, token string, adminID string) (*Client, error) {
+	// Hosts without a scheme are reached over HTTPS.
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
 	base, err := url.Parse(host)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse host %q: %v", host, err)
 	}
-	base.Scheme = "https"
 	httpClient := http.Client{
 		Timeout: httpTimeout,
 	}
@@ -468,17 +476,17 @@ func NewClient(host, token string, adminID string) (*Client, error) {
 	}, nil
 }
 
+// URL returns the address of the Emby server.
+func (c *Client) URL() string {
+	return c.baseURL.String()
+}
+
 func (c *Client) Sessions(ctx context.Context) (Sessions, error) {
-	body, err := c.do(ctx, "GET", fmt.Sprintf("Sessions"))
+	sessions, err := c.sessions(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	var sessions Sessions
-	if err := json.Unmarshal(body, &sessions); err != nil {
-		return nil, err
-	}
-
 	for i, session := range sessions {
 		if session.NowPlayingItem.ID != "" {
 			details, err := c.itemDetails(ctx, session.NowPlayingItem.ID)
@@ -498,9 +506,42 @@ func (c *Client) Sessions(ctx context.Context) (Sessions, error) {
 	return sessions, nil
 }
 
+// PlayMethod returns how the session plays its current item: DirectPlay,
+// DirectStream or Transcode.
+func (c *Client) PlayMethod(ctx context.Context, sessionID string) (string, error) {
+	sessions, err := c.sessions(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	for _, session := range sessions {
+		if session.ID == sessionID {
+			return session.PlayState.PlayMethod, nil
+		}
+	}
+
+	return "", fmt.Errorf("emby session %q not found", sessionID)
+}
+
+// sessions returns the sessions without looking up the details of the items
+// they play.
+func (c *Client) sessions(ctx context.Context) (Sessions, error) {
+	body, err := c.do(ctx, "GET", fmt.Sprintf("Sessions"), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions Sessions
+	if err := json.Unmarshal(body, &sessions); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
 func (c *Client) Search(ctx context.Context, searchTerm []string) (SearchResults, error) {
 	s := strings.Join(searchTerm, " ")
-	body, err := c.do(ctx, "GET", fmt.Sprintf("Search/Hints?searchTerm=%s", s))
+	body, err := c.do(ctx, "GET", fmt.Sprintf("Search/Hints?searchTerm=%s", s), nil)
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -529,7 +570,7 @@ func (c *Client) Search(ctx context.Context, searchTerm []string) (SearchResults
 }
 
 func (c *Client) itemDetails(ctx context.Context, id string) (ItemDetail, error) {
-	body, err := c.do(ctx, "GET", fmt.Sprintf("Users/%s/Items/%s", c.adminID, id))
+	body, err := c.do(ctx, "GET", fmt.Sprintf("Users/%s/Items/%s", c.adminID, id), nil)
 	if err != nil {
 		return ItemDetail{}, err
 	}
@@ -543,7 +584,7 @@ func (c *Client) itemDetails(ctx context.Context, id string) (ItemDetail, error)
 }
 
 func (c *Client) itemImages(ctx context.Context, id string) (ItemImages, error) {
-	body, err := c.do(ctx, "GET", fmt.Sprintf("Items/%s/RemoteImages/", id))
+	body, err := c.do(ctx, "GET", fmt.Sprintf("Items/%s/RemoteImages/", id), nil)
 	if err != nil {
 		return ItemImages{}, err
 	}
@@ -556,23 +597,31 @@ func (c *Client) itemImages(ctx context.Context, id string) (ItemImages, error)
 	return images, nil
 }
 
-func (c *Client) do(ctx context.Context, method string, path string) ([]byte, error) {
-	req, err := http.NewRequest(method, fmt.Sprintf("%s/%s", c.baseURL, path), nil)
+func (c *Client) do(ctx context.Context, method string, path string, input []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/%s", c.baseURL, path), bytes.NewBuffer(input))
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("X-MediaBrowser-Token", c.token)
+	if input != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 	//req = req.WithContext(ctx)
 	response, err := c.http.Do(req)
 	if err != nil {
 		return nil, err
 	}
+	defer response.Body.Close()
 
 	body, err := ioutil.ReadAll(response.Body)
 	if err != nil {
 		return nil, err
 	}
 
+	if response.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("emby returned %s for %s %s: %s", response.Status, method, path, body)
+	}
+
 	return body, nil
 }
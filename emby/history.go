@@ -0,0 +1,135 @@
+package emby
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// playbackReportingTime is the layout of the dates stored by the Playback
+// Reporting plugin.
+const playbackReportingTime = "2006-01-02 15:04:05"
+
+// Play is a single playback recorded by the Playback Reporting plugin.
+type Play struct {
+	Date       time.Time
+	UserID     string
+	ItemID     string
+	ItemType   string
+	ItemName   string
+	PlayMethod string
+	Client     string
+	Device     string
+	Duration   time.Duration
+}
+
+type Plays []Play
+
+type PlayedItems struct {
+	Items []struct {
+		Name              string `json:"Name"`
+		ID                string `json:"Id"`
+		Type              string `json:"Type"`
+		SeriesName        string `json:"SeriesName"`
+		IndexNumber       int    `json:"IndexNumber"`
+		ParentIndexNumber int    `json:"ParentIndexNumber"`
+		ProductionYear    int    `json:"ProductionYear"`
+		UserData          struct {
+			PlayCount      int       `json:"PlayCount"`
+			LastPlayedDate time.Time `json:"LastPlayedDate"`
+		} `json:"UserData"`
+	} `json:"Items"`
+	TotalRecordCount int `json:"TotalRecordCount"`
+}
+
+// PlayedItems returns the movies and episodes the user has watched, most
+// recently played first.
+func (c *Client) PlayedItems(ctx context.Context, userID string, limit int) (PlayedItems, error) {
+	query := url.Values{
+		"Filters":          {"IsPlayed"},
+		"Recursive":        {"true"},
+		"IncludeItemTypes": {"Movie,Episode"},
+		"SortBy":           {"DatePlayed"},
+		"SortOrder":        {"Descending"},
+		"Limit":            {strconv.Itoa(limit)},
+	}
+	body, err := c.do(ctx, "GET", fmt.Sprintf("Users/%s/Items?%s", userID, query.Encode()), nil)
+	if err != nil {
+		return PlayedItems{}, err
+	}
+
+	var items PlayedItems
+	if err := json.Unmarshal(body, &items); err != nil {
+		return PlayedItems{}, err
+	}
+
+	return items, nil
+}
+
+// PlaybackActivity returns the plays recorded since the given time, most
+// recent first. It requires the Playback Reporting plugin.
+func (c *Client) PlaybackActivity(ctx context.Context, since time.Time) (Plays, error) {
+	input, err := json.Marshal(struct {
+		CustomQueryString string
+		ReplaceUserID     bool `json:"ReplaceUserId"`
+	}{
+		CustomQueryString: fmt.Sprintf("SELECT DateCreated, UserId, ItemId, ItemType, ItemName, PlaybackMethod, ClientName, DeviceName, PlayDuration "+
+			"FROM PlaybackActivity WHERE DateCreated >= '%s' ORDER BY DateCreated DESC", since.Format(playbackReportingTime)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.do(ctx, "POST", "user_usage_stats/submit_custom_query", input)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Results [][]string `json:"results"`
+		Message string     `json:"message"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+	if result.Message != "" {
+		return nil, fmt.Errorf("playback reporting query failed: %s", result.Message)
+	}
+
+	var plays Plays
+	for _, row := range result.Results {
+		if len(row) < 9 {
+			continue
+		}
+		date, err := time.ParseInLocation(playbackReportingTime, truncateFraction(row[0]), time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse playback date %q: %v", row[0], err)
+		}
+		seconds, _ := strconv.Atoi(row[8])
+
+		plays = append(plays, Play{
+			Date:       date,
+			UserID:     row[1],
+			ItemID:     row[2],
+			ItemType:   row[3],
+			ItemName:   row[4],
+			PlayMethod: row[5],
+			Client:     row[6],
+			Device:     row[7],
+			Duration:   time.Duration(seconds) * time.Second,
+		})
+	}
+
+	return plays, nil
+}
+
+// truncateFraction drops the fractional seconds SQLite may add to a date.
+func truncateFraction(date string) string {
+	if len(date) > len(playbackReportingTime) {
+		return date[:len(playbackReportingTime)]
+	}
+	return date
+}
@@ -0,0 +1,157 @@
+package emby
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type Library struct {
+	Name           string   `json:"Name"`
+	Locations      []string `json:"Locations"`
+	CollectionType string   `json:"CollectionType"`
+	ItemID         string   `json:"ItemId"`
+}
+
+type Libraries []Library
+
+// Find looks up a library by name, ignoring case.
+func (l Libraries) Find(name string) (Library, bool) {
+	for _, library := range l {
+		if strings.EqualFold(library.Name, name) {
+			return library, true
+		}
+	}
+
+	return Library{}, false
+}
+
+func (c *Client) Libraries(ctx context.Context) (Libraries, error) {
+	body, err := c.do(ctx, "GET", "Library/VirtualFolders", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var libraries Libraries
+	if err := json.Unmarshal(body, &libraries); err != nil {
+		return nil, err
+	}
+
+	return libraries, nil
+}
+
+// LibraryStat is the number of titles in a library: movies, series, artists
+// or books, not the files they are made of.
+type LibraryStat struct {
+	Name           string
+	CollectionType string
+	Titles         int
+}
+
+// LibraryStats counts the titles in every library.
+func (c *Client) LibraryStats(ctx context.Context) ([]LibraryStat, error) {
+	libraries, err := c.Libraries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats []LibraryStat
+	for _, library := range libraries {
+		query := url.Values{
+			"ParentId":         {library.ItemID},
+			"Recursive":        {"true"},
+			"IncludeItemTypes": {"Movie,Series,MusicArtist,Book,AudioBook"},
+			"Limit":            {"0"},
+		}
+		body, err := c.do(ctx, "GET", fmt.Sprintf("Users/%s/Items?%s", c.adminID, query.Encode()), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var count struct {
+			TotalRecordCount int `json:"TotalRecordCount"`
+		}
+		if err := json.Unmarshal(body, &count); err != nil {
+			return nil, err
+		}
+
+		stats = append(stats, LibraryStat{
+			Name:           library.Name,
+			CollectionType: library.CollectionType,
+			Titles:         count.TotalRecordCount,
+		})
+	}
+
+	return stats, nil
+}
+
+type ScheduledTask struct {
+	Name                      string  `json:"Name"`
+	State                     string  `json:"State"`
+	CurrentProgressPercentage float64 `json:"CurrentProgressPercentage"`
+	ID                        string  `json:"Id"`
+	Key                       string  `json:"Key"`
+	Category                  string  `json:"Category"`
+	Description               string  `json:"Description"`
+	LastExecutionResult       struct {
+		StartTimeUtc time.Time `json:"StartTimeUtc"`
+		EndTimeUtc   time.Time `json:"EndTimeUtc"`
+		Status       string    `json:"Status"`
+		ErrorMessage string    `json:"ErrorMessage"`
+	} `json:"LastExecutionResult"`
+}
+
+type ScheduledTasks []ScheduledTask
+
+// RefreshLibrary scans all libraries for new and removed files.
+func (c *Client) RefreshLibrary(ctx context.Context) error {
+	_, err := c.do(ctx, "POST", "Library/Refresh", nil)
+	return err
+}
+
+// RefreshItem scans an item, e.g. a single library, and everything below it.
+func (c *Client) RefreshItem(ctx context.Context, id string) error {
+	query := url.Values{
+		"Recursive":           {"true"},
+		"MetadataRefreshMode": {"Default"},
+		"ImageRefreshMode":    {"Default"},
+	}
+	_, err := c.do(ctx, "POST", fmt.Sprintf("Items/%s/Refresh?%s", id, query.Encode()), nil)
+	return err
+}
+
+func (c *Client) ScheduledTasks(ctx context.Context) (ScheduledTasks, error) {
+	body, err := c.do(ctx, "GET", "ScheduledTasks?IsHidden=false", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks ScheduledTasks
+	if err := json.Unmarshal(body, &tasks); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func (c *Client) ScheduledTask(ctx context.Context, id string) (ScheduledTask, error) {
+	body, err := c.do(ctx, "GET", fmt.Sprintf("ScheduledTasks/%s", id), nil)
+	if err != nil {
+		return ScheduledTask{}, err
+	}
+
+	var task ScheduledTask
+	if err := json.Unmarshal(body, &task); err != nil {
+		return ScheduledTask{}, err
+	}
+
+	return task, nil
+}
+
+func (c *Client) RunScheduledTask(ctx context.Context, id string) error {
+	_, err := c.do(ctx, "POST", fmt.Sprintf("ScheduledTasks/Running/%s", id), nil)
+	return err
+}
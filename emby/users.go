@@ -0,0 +1,189 @@
+package emby
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type User struct {
+	Name             string    `json:"Name"`
+	ServerID         string    `json:"ServerId"`
+	ID               string    `json:"Id"`
+	HasPassword      bool      `json:"HasPassword"`
+	LastLoginDate    time.Time `json:"LastLoginDate"`
+	LastActivityDate time.Time `json:"LastActivityDate"`
+	Policy           struct {
+		IsAdministrator bool `json:"IsAdministrator"`
+		IsDisabled      bool `json:"IsDisabled"`
+	} `json:"Policy"`
+}
+
+type Users []User
+
+func (c *Client) Users(ctx context.Context) (Users, error) {
+	body, err := c.do(ctx, "GET", "Users", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var users Users
+	if err := json.Unmarshal(body, &users); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// UserByName looks up a user by name, ignoring case.
+func (c *Client) UserByName(ctx context.Context, name string) (User, error) {
+	users, err := c.Users(ctx)
+	if err != nil {
+		return User{}, err
+	}
+
+	for _, user := range users {
+		if strings.EqualFold(user.Name, name) {
+			return user, nil
+		}
+	}
+
+	return User{}, fmt.Errorf("emby user %q not found", name)
+}
+
+func (c *Client) CreateUser(ctx context.Context, name string) (User, error) {
+	input, err := json.Marshal(struct {
+		Name string
+	}{
+		Name: name,
+	})
+	if err != nil {
+		return User{}, err
+	}
+
+	body, err := c.do(ctx, "POST", "Users/New", input)
+	if err != nil {
+		return User{}, err
+	}
+
+	var user User
+	if err := json.Unmarshal(body, &user); err != nil {
+		return User{}, err
+	}
+
+	return user, nil
+}
+
+// DeleteUser deletes the user.
+func (c *Client) DeleteUser(ctx context.Context, id string) error {
+	_, err := c.do(ctx, "DELETE", fmt.Sprintf("Users/%s", id), nil)
+	return err
+}
+
+// SetPassword clears the current password of the user and sets the new one.
+func (c *Client) SetPassword(ctx context.Context, id string, password string) error {
+	reset, err := json.Marshal(struct {
+		ID            string `json:"Id"`
+		ResetPassword bool
+	}{
+		ID:            id,
+		ResetPassword: true,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := c.do(ctx, "POST", fmt.Sprintf("Users/%s/Password", id), reset); err != nil {
+		return err
+	}
+
+	input, err := json.Marshal(struct {
+		ID        string `json:"Id"`
+		CurrentPw string
+		NewPw     string
+	}{
+		ID:    id,
+		NewPw: password,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.do(ctx, "POST", fmt.Sprintf("Users/%s/Password", id), input)
+
+	return err
+}
+
+func (c *Client) SetUserDisabled(ctx context.Context, id string, disabled bool) error {
+	return c.updatePolicy(ctx, id, map[string]interface{}{
+		"IsDisabled": disabled,
+	})
+}
+
+// updatePolicy fetches the current policy of the user and posts it back with
+// the given fields overwritten, so that settings this client does not know
+// about are left untouched.
+func (c *Client) updatePolicy(ctx context.Context, id string, fields map[string]interface{}) error {
+	body, err := c.do(ctx, "GET", fmt.Sprintf("Users/%s", id), nil)
+	if err != nil {
+		return err
+	}
+
+	var user struct {
+		Policy map[string]interface{} `json:"Policy"`
+	}
+	if err := json.Unmarshal(body, &user); err != nil {
+		return err
+	}
+	if user.Policy == nil {
+		user.Policy = map[string]interface{}{}
+	}
+
+	for k, v := range fields {
+		user.Policy[k] = v
+	}
+
+	input, err := json.Marshal(user.Policy)
+	if err != nil {
+		return err
+	}
+	_, err = c.do(ctx, "POST", fmt.Sprintf("Users/%s/Policy", id), input)
+
+	return err
+}
+
+// PolicyTemplate holds the policy settings applied to invited users.
+type PolicyTemplate struct {
+	// EnabledFolders are the names of the libraries the user can access. All
+	// libraries are accessible when empty.
+	EnabledFolders           []string
+	SimultaneousStreamLimit  int
+	RemoteClientBitrateLimit int
+}
+
+func (c *Client) ApplyPolicy(ctx context.Context, id string, template PolicyTemplate) error {
+	fields := map[string]interface{}{
+		"SimultaneousStreamLimit":  template.SimultaneousStreamLimit,
+		"RemoteClientBitrateLimit": template.RemoteClientBitrateLimit,
+	}
+
+	if len(template.EnabledFolders) > 0 {
+		libraries, err := c.Libraries(ctx)
+		if err != nil {
+			return err
+		}
+
+		var folders []string
+		for _, name := range template.EnabledFolders {
+			library, ok := libraries.Find(name)
+			if !ok {
+				return fmt.Errorf("emby library %q not found", name)
+			}
+			folders = append(folders, library.ItemID)
+		}
+		fields["EnableAllFolders"] = false
+		fields["EnabledFolders"] = folders
+	}
+
+	return c.updatePolicy(ctx, id, fields)
+}
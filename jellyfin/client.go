@@ -0,0 +1,275 @@
+package jellyfin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"warezbot/emby"
+)
+
+const (
+	httpTimeout = 20 * time.Second
+)
+
+// Jellyfin forked from Emby and still speaks mostly the same API, so results
+// are returned as the emby types. Its IDs are GUIDs where Emby has numbers in
+// a few places, which is why sessions and search hints are decoded into the
+// types below first.
+
+type session struct {
+	ID             string `json:"Id"`
+	UserID         string `json:"UserId"`
+	UserName       string `json:"UserName"`
+	Client         string `json:"Client"`
+	DeviceName     string `json:"DeviceName"`
+	DeviceID       string `json:"DeviceId"`
+	RemoteEndPoint string `json:"RemoteEndPoint"`
+	PlayState      struct {
+		PositionTicks int64  `json:"PositionTicks"`
+		IsPaused      bool   `json:"IsPaused"`
+		PlayMethod    string `json:"PlayMethod"`
+	} `json:"PlayState"`
+	NowPlayingItem struct {
+		Name              string `json:"Name"`
+		ID                string `json:"Id"`
+		Type              string `json:"Type"`
+		SeriesName        string `json:"SeriesName"`
+		IndexNumber       int    `json:"IndexNumber"`
+		ParentIndexNumber int    `json:"ParentIndexNumber"`
+		RunTimeTicks      int64  `json:"RunTimeTicks"`
+		ProductionYear    int    `json:"ProductionYear"`
+		Overview          string `json:"Overview"`
+	} `json:"NowPlayingItem"`
+}
+
+type searchHints struct {
+	SearchHints []struct {
+		ID             string `json:"Id"`
+		Name           string `json:"Name"`
+		Type           string `json:"Type"`
+		ProductionYear int    `json:"ProductionYear"`
+		IndexNumber    int    `json:"IndexNumber"`
+		RunTimeTicks   int64  `json:"RunTimeTicks"`
+		MediaType      string `json:"MediaType"`
+		Series         string `json:"Series"`
+	} `json:"SearchHints"`
+	TotalRecordCount int `json:"TotalRecordCount"`
+}
+
+type Client struct {
+	userID  string
+	token   string
+	baseURL *url.URL
+	http    http.Client
+}
+
+// NewClient creates a Jellyfin client. Items are looked up as seen by the
+// user with userID.
+func NewClient(host, token string, userID string) (*Client, error) {
+	// Hosts without a scheme are reached over HTTPS.
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	base, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse host %q: %v", host, err)
+	}
+	httpClient := http.Client{
+		Timeout: httpTimeout,
+	}
+	return &Client{
+		userID:  userID,
+		baseURL: base,
+		token:   token,
+		http:    httpClient,
+	}, nil
+}
+
+// URL returns the address of the Jellyfin server.
+func (c *Client) URL() string {
+	return c.baseURL.String()
+}
+
+func (c *Client) Sessions(ctx context.Context) (emby.Sessions, error) {
+	body, err := c.do(ctx, "GET", "Sessions", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []session
+	if err := json.Unmarshal(body, &sessions); err != nil {
+		return nil, err
+	}
+
+	result := make(emby.Sessions, len(sessions))
+	for i, ses := range sessions {
+		result[i].ID = ses.ID
+		result[i].UserID = ses.UserID
+		result[i].UserName = ses.UserName
+		result[i].Client = ses.Client
+		result[i].DeviceName = ses.DeviceName
+		result[i].DeviceID = ses.DeviceID
+		result[i].RemoteEndPoint = ses.RemoteEndPoint
+		result[i].PlayState.PositionTicks = ses.PlayState.PositionTicks
+		result[i].PlayState.IsPaused = ses.PlayState.IsPaused
+		result[i].PlayState.PlayMethod = ses.PlayState.PlayMethod
+
+		item := ses.NowPlayingItem
+		result[i].NowPlayingItem.Name = item.Name
+		result[i].NowPlayingItem.ID = item.ID
+		result[i].NowPlayingItem.Type = item.Type
+		result[i].NowPlayingItem.SeriesName = item.SeriesName
+		result[i].NowPlayingItem.IndexNumber = item.IndexNumber
+		result[i].NowPlayingItem.ParentIndexNumber = item.ParentIndexNumber
+		result[i].NowPlayingItem.RunTimeTicks = item.RunTimeTicks
+		result[i].NowPlayingItem.ProductionYear = item.ProductionYear
+		result[i].NowPlayingItem.Overview = item.Overview
+
+		if item.ID != "" {
+			details, err := c.itemDetails(ctx, item.ID)
+			if err != nil {
+				return nil, err
+			}
+			result[i].ItemDetail = details
+
+			images, err := c.itemImages(ctx, item.ID)
+			if err != nil {
+				return nil, err
+			}
+			result[i].ItemImages = images
+		}
+	}
+
+	return result, nil
+}
+
+func (c *Client) Search(ctx context.Context, searchTerm []string) (emby.SearchResults, error) {
+	query := url.Values{
+		"searchTerm": {strings.Join(searchTerm, " ")},
+		"userId":     {c.userID},
+	}
+	body, err := c.do(ctx, "GET", fmt.Sprintf("Search/Hints?%s", query.Encode()), nil)
+	if err != nil {
+		return emby.SearchResults{}, err
+	}
+
+	var hints searchHints
+	if err := json.Unmarshal(body, &hints); err != nil {
+		return emby.SearchResults{}, err
+	}
+
+	sr := emby.SearchResults{
+		TotalRecordCount: hints.TotalRecordCount,
+	}
+	for _, hint := range hints.SearchHints {
+		result := emby.SearchHint{
+			Name:           hint.Name,
+			Type:           hint.Type,
+			ProductionYear: hint.ProductionYear,
+			IndexNumber:    hint.IndexNumber,
+			RunTimeTicks:   hint.RunTimeTicks,
+			MediaType:      hint.MediaType,
+			Series:         hint.Series,
+		}
+
+		if hint.ID != "" {
+			result.ItemImages, err = c.itemImages(ctx, hint.ID)
+			if err != nil {
+				return emby.SearchResults{}, err
+			}
+			result.ItemDetail, err = c.itemDetails(ctx, hint.ID)
+			if err != nil {
+				return emby.SearchResults{}, err
+			}
+		}
+		sr.SearchHints = append(sr.SearchHints, result)
+	}
+
+	return sr, nil
+}
+
+func (c *Client) itemDetails(ctx context.Context, id string) (emby.ItemDetail, error) {
+	body, err := c.do(ctx, "GET", fmt.Sprintf("Users/%s/Items/%s", c.userID, id), nil)
+	if err != nil {
+		return emby.ItemDetail{}, err
+	}
+
+	var itemDetail emby.ItemDetail
+	if err := json.Unmarshal(body, &itemDetail); err != nil {
+		return emby.ItemDetail{}, err
+	}
+
+	return itemDetail, nil
+}
+
+func (c *Client) itemImages(ctx context.Context, id string) (emby.ItemImages, error) {
+	body, err := c.do(ctx, "GET", fmt.Sprintf("Items/%s/RemoteImages", id), nil)
+	if err != nil {
+		return emby.ItemImages{}, err
+	}
+
+	var images emby.ItemImages
+	if err := json.Unmarshal(body, &images); err != nil {
+		return emby.ItemImages{}, err
+	}
+
+	return images, nil
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, input []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/%s", c.baseURL, path), bytes.NewBuffer(input))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("MediaBrowser Token=%q", c.token))
+	if input != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req = req.WithContext(ctx)
+	response, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("jellyfin returned %s for %s %s: %s", response.Status, method, path, body)
+	}
+
+	return body, nil
+}
+
+// PlayMethod returns how the session plays its item. The webhook plugin does
+// not send session IDs, so a device ID is accepted as well.
+func (c *Client) PlayMethod(ctx context.Context, sessionID string) (string, error) {
+	body, err := c.do(ctx, "GET", "Sessions", nil)
+	if err != nil {
+		return "", err
+	}
+
+	var sessions []session
+	if err := json.Unmarshal(body, &sessions); err != nil {
+		return "", err
+	}
+
+	for _, ses := range sessions {
+		if ses.ID == sessionID || ses.DeviceID == sessionID {
+			return ses.PlayState.PlayMethod, nil
+		}
+	}
+
+	return "", fmt.Errorf("jellyfin session %s not found", sessionID)
+}
@@ -0,0 +1,44 @@
+package jellyfin
+
+// WebhookEvent is the body sent by the Jellyfin webhook plugin with its
+// default template.
+type WebhookEvent struct {
+	NotificationType      string `json:"NotificationType"`
+	ServerID              string `json:"ServerId"`
+	ServerName            string `json:"ServerName"`
+	ItemID                string `json:"ItemId"`
+	ItemType              string `json:"ItemType"`
+	Name                  string `json:"Name"`
+	SeriesName            string `json:"SeriesName"`
+	Overview              string `json:"Overview"`
+	Year                  int    `json:"Year"`
+	SeasonNumber          int    `json:"SeasonNumber"`
+	EpisodeNumber         int    `json:"EpisodeNumber"`
+	RunTimeTicks          int64  `json:"RunTimeTicks"`
+	ProviderImdb          string `json:"Provider_imdb"`
+	ProviderTmdb          string `json:"Provider_tmdb"`
+	ProviderTvdb          string `json:"Provider_tvdb"`
+	NotificationUsername  string `json:"NotificationUsername"`
+	UserID                string `json:"UserId"`
+	DeviceID              string `json:"DeviceId"`
+	DeviceName            string `json:"DeviceName"`
+	ClientName            string `json:"ClientName"`
+	RemoteEndPoint        string `json:"RemoteEndPoint"`
+	PlaybackPositionTicks int64  `json:"PlaybackPositionTicks"`
+	PlayedToCompletion    bool   `json:"PlayedToCompletion"`
+}
+
+// EmbyEvent returns the name Emby uses for the notification, or the Jellyfin
+// notification type if Emby has no equivalent.
+func (e WebhookEvent) EmbyEvent() string {
+	switch e.NotificationType {
+	case "PlaybackStart":
+		return "playback.start"
+	case "PlaybackStop":
+		return "playback.stop"
+	case "ItemAdded":
+		return "library.new"
+	default:
+		return e.NotificationType
+	}
+}
@@ -0,0 +1,374 @@
+package lidarr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"warezbot/media"
+)
+
+const (
+	httpTimeout = 10 * time.Second
+	queueSize   = 50
+)
+
+type Image struct {
+	CoverType string `json:"coverType"`
+	URL       string `json:"url"`
+	RemoteURL string `json:"remoteUrl"`
+}
+
+type Artist struct {
+	ID                int     `json:"id,omitempty"`
+	ArtistName        string  `json:"artistName"`
+	ForeignArtistID   string  `json:"foreignArtistId"`
+	Overview          string  `json:"overview,omitempty"`
+	Disambiguation    string  `json:"disambiguation,omitempty"`
+	Images            []Image `json:"images,omitempty"`
+	QualityProfileID  int     `json:"qualityProfileId,omitempty"`
+	MetadataProfileID int     `json:"metadataProfileId,omitempty"`
+	RootFolderPath    string  `json:"rootFolderPath,omitempty"`
+	Monitored         bool    `json:"monitored"`
+	AddOptions        *struct {
+		Monitor                string `json:"monitor"`
+		SearchForMissingAlbums bool   `json:"searchForMissingAlbums"`
+	} `json:"addOptions,omitempty"`
+}
+
+type Album struct {
+	ID             int       `json:"id,omitempty"`
+	Title          string    `json:"title"`
+	ForeignAlbumID string    `json:"foreignAlbumId"`
+	Overview       string    `json:"overview,omitempty"`
+	AlbumType      string    `json:"albumType,omitempty"`
+	ReleaseDate    time.Time `json:"releaseDate"`
+	Images         []Image   `json:"images,omitempty"`
+	Monitored      bool      `json:"monitored"`
+	AnyReleaseOk   bool      `json:"anyReleaseOk"`
+	Artist         Artist    `json:"artist"`
+	AddOptions     *struct {
+		SearchForNewAlbum bool `json:"searchForNewAlbum"`
+	} `json:"addOptions,omitempty"`
+}
+
+type queue struct {
+	Records []struct {
+		Artist struct {
+			ArtistName string `json:"artistName"`
+		} `json:"artist"`
+		Album struct {
+			Title string `json:"title"`
+		} `json:"album"`
+		Title                 string  `json:"title"`
+		Size                  float64 `json:"size"`
+		Sizeleft              float64 `json:"sizeleft"`
+		Timeleft              string  `json:"timeleft"`
+		Status                string  `json:"status"`
+		TrackedDownloadStatus string  `json:"trackedDownloadStatus"`
+		Protocol              string  `json:"protocol"`
+	} `json:"records"`
+}
+
+type Client struct {
+	token             string
+	qualityProfileID  int
+	metadataProfileID int
+	rootFolderPath    string
+	baseURL           *url.URL
+	http              http.Client
+}
+
+// NewClient creates a Lidarr client. Artists are added with the quality and
+// metadata profiles to the root folder given.
+func NewClient(host, token string, qualityProfileID int, metadataProfileID int, rootFolderPath string) (*Client, error) {
+	// Hosts without a scheme are reached over HTTPS.
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	base, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse host %q: %v", host, err)
+	}
+	httpClient := http.Client{
+		Timeout: httpTimeout,
+	}
+	return &Client{
+		token:             token,
+		qualityProfileID:  qualityProfileID,
+		metadataProfileID: metadataProfileID,
+		rootFolderPath:    rootFolderPath,
+		baseURL:           base,
+		http:              httpClient,
+	}, nil
+}
+
+// Artists searches and adds whole artists.
+type Artists struct {
+	*Client
+}
+
+func (a Artists) Search(ctx context.Context, searchTerm []string) (media.Items, error) {
+	artists, err := a.SearchArtists(ctx, strings.Join(searchTerm, " "))
+	if err != nil {
+		return nil, err
+	}
+
+	var items media.Items
+	for _, artist := range artists {
+		items = append(items, artist.item())
+	}
+	return items, nil
+}
+
+func (a Artists) Add(ctx context.Context, id string) (media.Item, error) {
+	artist, err := a.AddArtist(ctx, id)
+	if err != nil {
+		return media.Item{}, err
+	}
+	return artist.item(), nil
+}
+
+// Albums searches and adds single albums, along with their artist if it is
+// not there yet.
+type Albums struct {
+	*Client
+}
+
+func (a Albums) Search(ctx context.Context, searchTerm []string) (media.Items, error) {
+	albums, err := a.SearchAlbums(ctx, strings.Join(searchTerm, " "))
+	if err != nil {
+		return nil, err
+	}
+
+	var items media.Items
+	for _, album := range albums {
+		items = append(items, album.item())
+	}
+	return items, nil
+}
+
+func (a Albums) Add(ctx context.Context, id string) (media.Item, error) {
+	album, err := a.AddAlbum(ctx, id)
+	if err != nil {
+		return media.Item{}, err
+	}
+	return album.item(), nil
+}
+
+func (a Artist) item() media.Item {
+	return media.Item{
+		ID:       a.ForeignArtistID,
+		Kind:     media.Artist,
+		Title:    a.ArtistName,
+		Overview: a.Overview,
+		ImageURL: image(a.Images, "poster"),
+	}
+}
+
+func (a Album) item() media.Item {
+	item := media.Item{
+		ID:       a.ForeignAlbumID,
+		Kind:     media.Album,
+		Title:    a.Title,
+		Overview: a.Overview,
+		ImageURL: image(a.Images, "cover"),
+		Creator:  a.Artist.ArtistName,
+	}
+	if !a.ReleaseDate.IsZero() {
+		item.Year = a.ReleaseDate.Year()
+	}
+	return item
+}
+
+// image returns the URL of the image of the cover type, or of the first image
+// if there is none of that type.
+func image(images []Image, coverType string) string {
+	for _, i := range images {
+		if i.CoverType == coverType {
+			if i.RemoteURL != "" {
+				return i.RemoteURL
+			}
+			return i.URL
+		}
+	}
+	if len(images) > 0 {
+		return images[0].URL
+	}
+	return ""
+}
+
+func (c *Client) SearchArtists(ctx context.Context, term string) ([]Artist, error) {
+	var artists []Artist
+	if err := c.lookup(ctx, "artist", term, &artists); err != nil {
+		return nil, err
+	}
+	return artists, nil
+}
+
+func (c *Client) SearchAlbums(ctx context.Context, term string) ([]Album, error) {
+	var albums []Album
+	if err := c.lookup(ctx, "album", term, &albums); err != nil {
+		return nil, err
+	}
+	return albums, nil
+}
+
+// AddArtist adds the artist with the MusicBrainz ID, monitors all of their
+// albums and starts searching for them.
+func (c *Client) AddArtist(ctx context.Context, id string) (Artist, error) {
+	var artists []Artist
+	if err := c.lookup(ctx, "artist", "lidarr:"+id, &artists); err != nil {
+		return Artist{}, err
+	}
+	if len(artists) == 0 {
+		return Artist{}, fmt.Errorf("no artist with ID %s", id)
+	}
+
+	artist := c.newArtist(artists[0], "all")
+	artist.AddOptions.SearchForMissingAlbums = true
+
+	var added Artist
+	if err := c.post(ctx, "artist", artist, &added); err != nil {
+		return Artist{}, err
+	}
+	return added, nil
+}
+
+// AddAlbum adds the album with the MusicBrainz ID and starts searching for it.
+// Its artist is added too, without monitoring their other albums.
+func (c *Client) AddAlbum(ctx context.Context, id string) (Album, error) {
+	var albums []Album
+	if err := c.lookup(ctx, "album", "lidarr:"+id, &albums); err != nil {
+		return Album{}, err
+	}
+	if len(albums) == 0 {
+		return Album{}, fmt.Errorf("no album with ID %s", id)
+	}
+
+	album := albums[0]
+	album.Monitored = true
+	album.AnyReleaseOk = true
+	album.Artist = c.newArtist(album.Artist, "none")
+	album.AddOptions = &struct {
+		SearchForNewAlbum bool `json:"searchForNewAlbum"`
+	}{SearchForNewAlbum: true}
+
+	var added Album
+	if err := c.post(ctx, "album", album, &added); err != nil {
+		return Album{}, err
+	}
+	return added, nil
+}
+
+// newArtist prepares an artist found by a lookup to be added.
+func (c *Client) newArtist(artist Artist, monitor string) Artist {
+	artist.QualityProfileID = c.qualityProfileID
+	artist.MetadataProfileID = c.metadataProfileID
+	artist.RootFolderPath = c.rootFolderPath
+	artist.Monitored = true
+	artist.AddOptions = &struct {
+		Monitor                string `json:"monitor"`
+		SearchForMissingAlbums bool   `json:"searchForMissingAlbums"`
+	}{Monitor: monitor}
+	return artist
+}
+
+// Queue returns the albums being downloaded.
+func (c *Client) Queue(ctx context.Context) ([]media.QueueItem, error) {
+	query := url.Values{
+		"page":          {"1"},
+		"pageSize":      {strconv.Itoa(queueSize)},
+		"includeArtist": {"true"},
+		"includeAlbum":  {"true"},
+		"apikey":        {c.token},
+	}
+	body, err := c.do(ctx, "GET", fmt.Sprintf("queue?%s", query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var q queue
+	if err := json.Unmarshal(body, &q); err != nil {
+		return nil, err
+	}
+
+	var items []media.QueueItem
+	for _, record := range q.Records {
+		status := record.Status
+		if record.TrackedDownloadStatus != "" && record.TrackedDownloadStatus != "ok" {
+			status = fmt.Sprintf("%s (%s)", status, record.TrackedDownloadStatus)
+		}
+		items = append(items, media.QueueItem{
+			Title:    fmt.Sprintf("%s - %s", record.Artist.ArtistName, record.Album.Title),
+			Release:  record.Title,
+			Status:   status,
+			Protocol: record.Protocol,
+			Size:     record.Size,
+			SizeLeft: record.Sizeleft,
+			TimeLeft: media.ParseTimeLeft(record.Timeleft),
+		})
+	}
+
+	return items, nil
+}
+
+func (c *Client) lookup(ctx context.Context, resource string, term string, v interface{}) error {
+	query := url.Values{
+		"term":   {term},
+		"apikey": {c.token},
+	}
+	body, err := c.do(ctx, "GET", fmt.Sprintf("%s/lookup?%s", resource, query.Encode()), nil)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+func (c *Client) post(ctx context.Context, resource string, in interface{}, out interface{}) error {
+	input, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	body, err := c.do(ctx, "POST", fmt.Sprintf("%s?apikey=%s", resource, c.token), input)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, out)
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, input []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/%s", c.baseURL, path), bytes.NewBuffer(input))
+	if err != nil {
+		return nil, err
+	}
+
+	if input != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req = req.WithContext(ctx)
+	response, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("lidarr returned %s for %s %s", response.Status, method, strings.SplitN(path, "?", 2)[0])
+	}
+
+	return body, nil
+}
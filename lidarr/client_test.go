@@ -0,0 +1,267 @@
+package lidarr
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"warezbot/media"
+)
+
+const artistLookupResponse = `[{
+	"artistName": "Radiohead",
+	"foreignArtistId": "a74b1b7f-71a5-4011-9441-d0b5e4122711",
+	"overview": "English rock band.",
+	"images": [
+		{"coverType": "banner", "url": "/MediaCover/1/banner.jpg"},
+		{"coverType": "poster", "url": "/MediaCover/1/poster.jpg", "remoteUrl": "https://images.example/radiohead.jpg"}
+	]
+}]`
+
+const albumLookupResponse = `[{
+	"title": "OK Computer",
+	"foreignAlbumId": "b1392450-e666-3926-a536-22c65f834433",
+	"overview": "Third studio album.",
+	"releaseDate": "1997-05-21T00:00:00Z",
+	"images": [{"coverType": "cover", "url": "/MediaCover/2/cover.jpg"}],
+	"artist": {"artistName": "Radiohead", "foreignArtistId": "a74b1b7f-71a5-4011-9441-d0b5e4122711"}
+}]`
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c, err := NewClient(server.URL, "secret", 2, 3, "/music/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestSearchArtists(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/artist/lookup" {
+			t.Errorf("path = %s, want /artist/lookup", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("term"); got != "radio head" {
+			t.Errorf("term = %q, want %q", got, "radio head")
+		}
+		if got := r.URL.Query().Get("apikey"); got != "secret" {
+			t.Errorf("apikey = %q, want secret", got)
+		}
+		w.Write([]byte(artistLookupResponse))
+	})
+
+	items, err := Artists{c}.Search(context.Background(), []string{"radio", "head"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("got %d items, want 1", len(items))
+	}
+	want := media.Item{
+		ID:       "a74b1b7f-71a5-4011-9441-d0b5e4122711",
+		Kind:     media.Artist,
+		Title:    "Radiohead",
+		Overview: "English rock band.",
+		ImageURL: "https://images.example/radiohead.jpg",
+	}
+	if items[0] != want {
+		t.Errorf("item = %+v, want %+v", items[0], want)
+	}
+}
+
+func TestSearchAlbums(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/album/lookup" {
+			t.Errorf("path = %s, want /album/lookup", r.URL.Path)
+		}
+		w.Write([]byte(albumLookupResponse))
+	})
+
+	items, err := Albums{c}.Search(context.Background(), []string{"ok", "computer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("got %d items, want 1", len(items))
+	}
+	want := media.Item{
+		ID:       "b1392450-e666-3926-a536-22c65f834433",
+		Kind:     media.Album,
+		Title:    "OK Computer",
+		Year:     1997,
+		Overview: "Third studio album.",
+		ImageURL: "/MediaCover/2/cover.jpg",
+		Creator:  "Radiohead",
+	}
+	if items[0] != want {
+		t.Errorf("item = %+v, want %+v", items[0], want)
+	}
+}
+
+func TestAddArtist(t *testing.T) {
+	var added Artist
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/artist/lookup":
+			if got := r.URL.Query().Get("term"); got != "lidarr:a74b1b7f-71a5-4011-9441-d0b5e4122711" {
+				t.Errorf("term = %q, want the lidarr ID", got)
+			}
+			w.Write([]byte(artistLookupResponse))
+		case r.Method == "POST" && r.URL.Path == "/artist":
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(body, &added); err != nil {
+				t.Fatal(err)
+			}
+			w.Write([]byte(`{"id": 4, "artistName": "Radiohead", "foreignArtistId": "a74b1b7f-71a5-4011-9441-d0b5e4122711"}`))
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	artist, err := c.AddArtist(context.Background(), "a74b1b7f-71a5-4011-9441-d0b5e4122711")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if artist.ID != 4 {
+		t.Errorf("ID = %d, want 4", artist.ID)
+	}
+
+	if added.QualityProfileID != 2 || added.MetadataProfileID != 3 || added.RootFolderPath != "/music/" {
+		t.Errorf("artist profiles = %d, %d, folder = %q, want 2, 3, /music/", added.QualityProfileID, added.MetadataProfileID, added.RootFolderPath)
+	}
+	if !added.Monitored {
+		t.Error("artist is not monitored")
+	}
+	if added.AddOptions == nil || added.AddOptions.Monitor != "all" || !added.AddOptions.SearchForMissingAlbums {
+		t.Errorf("artist add options = %+v, want all albums monitored and searched for", added.AddOptions)
+	}
+}
+
+func TestAddAlbum(t *testing.T) {
+	var added Album
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/album/lookup":
+			if got := r.URL.Query().Get("term"); got != "lidarr:b1392450-e666-3926-a536-22c65f834433" {
+				t.Errorf("term = %q, want the lidarr ID", got)
+			}
+			w.Write([]byte(albumLookupResponse))
+		case r.Method == "POST" && r.URL.Path == "/album":
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(body, &added); err != nil {
+				t.Fatal(err)
+			}
+			w.Write([]byte(`{"id": 9, "title": "OK Computer", "foreignAlbumId": "b1392450-e666-3926-a536-22c65f834433", "artist": {"artistName": "Radiohead"}}`))
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	album, err := c.AddAlbum(context.Background(), "b1392450-e666-3926-a536-22c65f834433")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if album.ID != 9 {
+		t.Errorf("ID = %d, want 9", album.ID)
+	}
+
+	if !added.Monitored || !added.AnyReleaseOk {
+		t.Errorf("album monitored = %v, any release = %v, want both set", added.Monitored, added.AnyReleaseOk)
+	}
+	if added.AddOptions == nil || !added.AddOptions.SearchForNewAlbum {
+		t.Errorf("album add options = %+v, want a search for the album", added.AddOptions)
+	}
+
+	artist := added.Artist
+	if artist.QualityProfileID != 2 || artist.MetadataProfileID != 3 || artist.RootFolderPath != "/music/" {
+		t.Errorf("artist profiles = %d, %d, folder = %q, want 2, 3, /music/", artist.QualityProfileID, artist.MetadataProfileID, artist.RootFolderPath)
+	}
+	if artist.AddOptions == nil || artist.AddOptions.Monitor != "none" || artist.AddOptions.SearchForMissingAlbums {
+		t.Errorf("artist add options = %+v, want only this album monitored", artist.AddOptions)
+	}
+}
+
+func TestAddAlbumNotFound(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`[]`))
+	})
+
+	if _, err := c.AddAlbum(context.Background(), "1"); err == nil {
+		t.Error("AddAlbum of an unknown album succeeded")
+	}
+}
+
+func TestQueue(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/queue" {
+			t.Errorf("path = %s, want /queue", r.URL.Path)
+		}
+		if r.URL.Query().Get("includeAlbum") != "true" || r.URL.Query().Get("includeArtist") != "true" {
+			t.Errorf("query = %s, want the album and artist included", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"records": [
+			{"artist": {"artistName": "Radiohead"}, "album": {"title": "OK Computer"}, "title": "Radiohead - OK Computer (1997) [FLAC]", "size": 400, "sizeleft": 100, "timeleft": "00:05:30", "status": "downloading", "trackedDownloadStatus": "ok", "protocol": "torrent"},
+			{"artist": {"artistName": "Björk"}, "album": {"title": "Homogenic"}, "title": "Bjork-Homogenic-1997", "size": 120, "sizeleft": 120, "timeleft": "", "status": "queued", "trackedDownloadStatus": "warning", "protocol": "usenet"}
+		]}`))
+	})
+
+	items, err := c.Queue(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []media.QueueItem{
+		{
+			Title:    "Radiohead - OK Computer",
+			Release:  "Radiohead - OK Computer (1997) [FLAC]",
+			Status:   "downloading",
+			Protocol: "torrent",
+			Size:     400,
+			SizeLeft: 100,
+			TimeLeft: 5*time.Minute + 30*time.Second,
+		},
+		{
+			Title:    "Björk - Homogenic",
+			Release:  "Bjork-Homogenic-1997",
+			Status:   "queued (warning)",
+			Protocol: "usenet",
+			Size:     120,
+			SizeLeft: 120,
+		},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d", len(items), len(want))
+	}
+	for i := range want {
+		if items[i] != want[i] {
+			t.Errorf("item %d = %+v, want %+v", i, items[i], want[i])
+		}
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	if _, err := c.Queue(context.Background()); err == nil {
+		t.Error("Queue succeeded on a 401")
+	}
+}
@@ -13,6 +13,7 @@ import (
 var (
 	listenAddr     = flag.String("httpListen", ":3000", "Listen address for the http service")
 	requestLogPath = flag.String("requestLog", "/var/log/request.log", "Path to the request log file")
+	auditLogPath   = flag.String("auditLog", "/var/log/audit.log", "Path to the audit log of admin commands")
 	configFile     = flag.String("configFile", "./secrets.json", "Path to the config file")
 )
 
@@ -20,7 +21,7 @@ func main() {
 	var logger log.Logger
 	logger = log.NewLogfmtLogger(log.NewSyncWriter(os.Stderr))
 
-	api, err := daemon.NewWarezDaemon(logger, *requestLogPath, *configFile)
+	api, err := daemon.NewWarezDaemon(logger, *requestLogPath, *auditLogPath, *configFile)
 	if err != nil {
 		level.Error(logger).Log("error", err)
 		os.Exit(1)
@@ -0,0 +1,190 @@
+// Package matrix talks to a Matrix homeserver through the client-server API
+// as a bot user.
+package matrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// httpTimeout leaves room for the long polling of Sync.
+	httpTimeout = SyncTimeout + 30*time.Second
+	// SyncTimeout is how long the homeserver holds a sync open waiting for
+	// events.
+	SyncTimeout = 30 * time.Second
+	apiPrefix   = "_matrix/client/v3"
+
+	// syncFilter keeps the sync down to the messages of joined rooms.
+	syncFilter = `{"presence":{"types":[]},"account_data":{"types":[]},"room":{"ephemeral":{"types":[]},"state":{"types":[]},"timeline":{"types":["m.room.message"],"limit":50}}}`
+)
+
+// Event is a room event. Only messages are synced.
+type Event struct {
+	Type    string `json:"type"`
+	EventID string `json:"event_id"`
+	Sender  string `json:"sender"`
+	Content struct {
+		MsgType string `json:"msgtype"`
+		Body    string `json:"body"`
+	} `json:"content"`
+}
+
+// Sync holds the events since the last sync.
+type Sync struct {
+	NextBatch string `json:"next_batch"`
+	Rooms     struct {
+		Join map[string]struct {
+			Timeline struct {
+				Events []Event `json:"events"`
+			} `json:"timeline"`
+		} `json:"join"`
+	} `json:"rooms"`
+}
+
+// Message is an m.room.message. FormattedBody is HTML, Body the plain text
+// shown by clients that don't render it.
+type Message struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"`
+	Format        string `json:"format,omitempty"`
+	FormattedBody string `json:"formatted_body,omitempty"`
+}
+
+type Client struct {
+	token   string
+	baseURL *url.URL
+	http    http.Client
+	// txnID makes every message sent by this client unique, so retried
+	// sends aren't posted twice.
+	txnID int64
+	start int64
+}
+
+func NewClient(host, token string) (*Client, error) {
+	// Hosts without a scheme are reached over HTTPS.
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	base, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse host %q: %v", host, err)
+	}
+	httpClient := http.Client{
+		Timeout: httpTimeout,
+	}
+	return &Client{
+		baseURL: base,
+		token:   token,
+		http:    httpClient,
+		start:   time.Now().UnixNano(),
+	}, nil
+}
+
+// WhoAmI returns the user ID of the bot.
+func (c *Client) WhoAmI(ctx context.Context) (string, error) {
+	body, err := c.do(ctx, "GET", "account/whoami", nil)
+	if err != nil {
+		return "", err
+	}
+
+	var r struct {
+		UserID string `json:"user_id"`
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return "", err
+	}
+	return r.UserID, nil
+}
+
+// JoinRoom joins the room with the ID or alias, which does nothing if the bot
+// already joined it, and returns its ID.
+func (c *Client) JoinRoom(ctx context.Context, room string) (string, error) {
+	body, err := c.do(ctx, "POST", "join/"+url.PathEscape(room), struct{}{})
+	if err != nil {
+		return "", err
+	}
+
+	var r struct {
+		RoomID string `json:"room_id"`
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return "", err
+	}
+	return r.RoomID, nil
+}
+
+// Sync returns the messages since the batch given, waiting up to timeout for
+// new ones. The first sync, with no batch, returns the latest messages at
+// once.
+func (c *Client) Sync(ctx context.Context, since string, timeout time.Duration) (Sync, error) {
+	query := url.Values{}
+	query.Set("filter", syncFilter)
+	query.Set("timeout", fmt.Sprint(timeout.Milliseconds()))
+	if since != "" {
+		query.Set("since", since)
+	}
+
+	body, err := c.do(ctx, "GET", "sync?"+query.Encode(), nil)
+	if err != nil {
+		return Sync{}, err
+	}
+
+	var s Sync
+	if err := json.Unmarshal(body, &s); err != nil {
+		return Sync{}, err
+	}
+	return s, nil
+}
+
+// Send posts the message to the room.
+func (c *Client) Send(ctx context.Context, roomID string, m Message) error {
+	txnID := fmt.Sprintf("warezbot.%d.%d", c.start, atomic.AddInt64(&c.txnID, 1))
+	_, err := c.do(ctx, "PUT", fmt.Sprintf("rooms/%s/send/m.room.message/%s", url.PathEscape(roomID), txnID), m)
+	return err
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, in interface{}) ([]byte, error) {
+	var input []byte
+	if in != nil {
+		var err error
+		if input, err = json.Marshal(in); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(c.baseURL.String(), "/"), apiPrefix, path), bytes.NewBuffer(input))
+	if err != nil {
+		return nil, err
+	}
+
+	if input != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req = req.WithContext(ctx)
+	response, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("matrix returned %s for %s %s: %s", response.Status, method, strings.SplitN(path, "?", 2)[0], body)
+	}
+
+	return body, nil
+}
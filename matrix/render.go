@@ -0,0 +1,175 @@
+package matrix
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"math"
+	"strings"
+
+	"warezbot/emby"
+	"warezbot/media"
+)
+
+// MaxChoices is how many search results are offered to pick from.
+const MaxChoices = 5
+
+// StripReply removes the quote of the message replied to, which clients put
+// ahead of the reply.
+func StripReply(body string) string {
+	lines := strings.Split(body, "\n")
+	for len(lines) > 0 && strings.HasPrefix(lines[0], ">") {
+		lines = lines[1:]
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// notice is a message from the bot, which other bots ignore.
+func notice(text string, formatted string) Message {
+	return Message{
+		MsgType:       "m.notice",
+		Body:          text,
+		Format:        "org.matrix.custom.html",
+		FormattedBody: formatted,
+	}
+}
+
+// Reply answers the user. Rooms have no private replies, so the user is named.
+func (c *Client) Reply(ctx context.Context, roomID string, user string, text string) error {
+	return c.Send(ctx, roomID, notice(
+		fmt.Sprintf("%s: %s", user, text),
+		fmt.Sprintf(`<a href="https://matrix.to/#/%s">%s</a>: %s`, html.EscapeString(user), html.EscapeString(user), html.EscapeString(text)),
+	))
+}
+
+// PostSearch lists the items found, numbered so the user can reply with the
+// number of the one to download. Matrix has no buttons.
+func (c *Client) PostSearch(ctx context.Context, roomID string, kind string, items media.Items) error {
+	if len(items) == 0 {
+		return c.Send(ctx, roomID, notice(fmt.Sprintf("No %s found.", kind), html.EscapeString(fmt.Sprintf("No %s found.", kind))))
+	}
+	if len(items) > MaxChoices {
+		items = items[:MaxChoices]
+	}
+
+	var text, formatted strings.Builder
+	fmt.Fprintf(&text, "Select %s to download, reply with its number:\n", kind)
+	fmt.Fprintf(&formatted, "<p>Select %s to download, reply with its number:</p><ol>", html.EscapeString(kind))
+	for i, item := range items {
+		name := item.Title
+		if item.Creator != "" {
+			name = fmt.Sprintf("%s by %s", item.Title, item.Creator)
+		}
+		if item.Year > 0 {
+			name = fmt.Sprintf("%s (%d)", name, item.Year)
+		}
+
+		fmt.Fprintf(&text, "%d. %s\n", i+1, name)
+		fmt.Fprintf(&formatted, "<li><b>%s</b>", html.EscapeString(name))
+		if item.Overview != "" {
+			fmt.Fprintf(&formatted, "<br/>%s", html.EscapeString(truncate(item.Overview, 200)))
+		}
+		formatted.WriteString("</li>")
+	}
+	formatted.WriteString("</ol>")
+
+	return c.Send(ctx, roomID, notice(text.String(), formatted.String()))
+}
+
+// PostLibrarySearch lists the movies and shows found on the media server.
+func (c *Client) PostLibrarySearch(ctx context.Context, roomID string, results emby.SearchResults) error {
+	var text, formatted strings.Builder
+	var count int
+	formatted.WriteString("<ul>")
+	for _, result := range results.SearchHints {
+		if result.Type != "Movie" && result.Type != "Episode" && result.Type != "Series" {
+			continue
+		}
+		count++
+
+		name := result.Name
+		if result.ProductionYear > 0 {
+			name = fmt.Sprintf("%s (%d)", name, result.ProductionYear)
+		}
+		fmt.Fprintf(&text, "%s - %s\n", name, result.Type)
+		fmt.Fprintf(&formatted, "<li><b>%s</b> - %s</li>", html.EscapeString(name), html.EscapeString(result.Type))
+	}
+	formatted.WriteString("</ul>")
+	fmt.Fprintf(&text, "Total results found: %d", count)
+	fmt.Fprintf(&formatted, "<p>Total results found: %d</p>", count)
+
+	return c.Send(ctx, roomID, notice(text.String(), formatted.String()))
+}
+
+// NowPlaying lists the sessions playing something.
+func (c *Client) NowPlaying(ctx context.Context, roomID string, sessions emby.Sessions) error {
+	var text, formatted strings.Builder
+	formatted.WriteString("<ul>")
+	for _, ses := range sessions {
+		item := ses.NowPlayingItem
+		if item.Name == "" {
+			continue
+		}
+
+		title := item.Name
+		if item.Type == "Episode" {
+			title = fmt.Sprintf("%s - %s (Season %d - %d)", item.SeriesName, item.Name, item.ParentIndexNumber, item.IndexNumber)
+		}
+		status := "playing"
+		if ses.PlayState.IsPaused {
+			status = "paused"
+		}
+		var progress float64
+		if item.RunTimeTicks > 0 {
+			progress = math.Round(float64(ses.PlayState.PositionTicks) * 100 / float64(item.RunTimeTicks))
+		}
+
+		fmt.Fprintf(&text, "%s is %s %s on %s - %g%%\n", ses.UserName, status, title, ses.DeviceName, progress)
+		fmt.Fprintf(&formatted, "<li><b>%s</b> is %s <i>%s</i> on %s - %g%%</li>",
+			html.EscapeString(ses.UserName), status, html.EscapeString(title), html.EscapeString(ses.DeviceName), progress)
+	}
+	formatted.WriteString("</ul>")
+	if text.Len() == 0 {
+		return c.Send(ctx, roomID, notice("Nothing is playing.", "Nothing is playing."))
+	}
+
+	return c.Send(ctx, roomID, notice(text.String(), formatted.String()))
+}
+
+// PostTable posts rows as an HTML table.
+func (c *Client) PostTable(ctx context.Context, roomID string, title string, header []string, rows [][]string) error {
+	if len(rows) == 0 {
+		return c.Send(ctx, roomID, notice(
+			fmt.Sprintf("%s\nNothing to show", title),
+			fmt.Sprintf("<b>%s</b><br/><i>Nothing to show</i>", html.EscapeString(title)),
+		))
+	}
+
+	var text, formatted strings.Builder
+	fmt.Fprintf(&text, "%s\n%s\n", title, strings.Join(header, " | "))
+	fmt.Fprintf(&formatted, "<b>%s</b><table><thead><tr>", html.EscapeString(title))
+	for _, h := range header {
+		fmt.Fprintf(&formatted, "<th>%s</th>", html.EscapeString(h))
+	}
+	formatted.WriteString("</tr></thead><tbody>")
+	for _, row := range rows {
+		text.WriteString(strings.Join(row, " | ") + "\n")
+		formatted.WriteString("<tr>")
+		for _, cell := range row {
+			fmt.Fprintf(&formatted, "<td>%s</td>", html.EscapeString(cell))
+		}
+		formatted.WriteString("</tr>")
+	}
+	formatted.WriteString("</tbody></table>")
+
+	return c.Send(ctx, roomID, notice(text.String(), formatted.String()))
+}
+
+// truncate cuts s to n runes, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
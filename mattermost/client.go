@@ -0,0 +1,256 @@
+// Package mattermost posts to Mattermost through its REST API. Mattermost
+// takes Slack attachments, so messages are rendered by the slack package and
+// only their buttons are converted.
+package mattermost
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	slackClient "github.com/nlopes/slack"
+
+	"warezbot/emby"
+	"warezbot/media"
+	"warezbot/slack"
+)
+
+const (
+	httpTimeout = 10 * time.Second
+
+	// ActionDownload is the action of the buttons that download an item.
+	ActionDownload = "download"
+)
+
+// Action is a button. Clicking it posts its context to the URL of its
+// integration.
+type Action struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	Style       string      `json:"style,omitempty"`
+	Integration Integration `json:"integration"`
+}
+
+type Integration struct {
+	URL     string        `json:"url"`
+	Context ActionContext `json:"context"`
+}
+
+// ActionContext is what a button passes back when clicked. Token proves the
+// click comes through a button the bot posted.
+type ActionContext struct {
+	Action string `json:"action"`
+	Kind   string `json:"kind,omitempty"`
+	ID     string `json:"id,omitempty"`
+	Title  string `json:"title,omitempty"`
+	Token  string `json:"token"`
+}
+
+// Attachment is a Slack attachment with Mattermost actions.
+type Attachment struct {
+	Color      string                        `json:"color,omitempty"`
+	AuthorName string                        `json:"author_name,omitempty"`
+	AuthorIcon string                        `json:"author_icon,omitempty"`
+	Title      string                        `json:"title,omitempty"`
+	Text       string                        `json:"text,omitempty"`
+	ImageURL   string                        `json:"image_url,omitempty"`
+	ThumbURL   string                        `json:"thumb_url,omitempty"`
+	Footer     string                        `json:"footer,omitempty"`
+	Fields     []slackClient.AttachmentField `json:"fields,omitempty"`
+	Actions    []Action                      `json:"actions,omitempty"`
+}
+
+type Client struct {
+	token   string
+	baseURL *url.URL
+	http    http.Client
+	// actionURL is where buttons post back to, with actionToken in their
+	// context.
+	actionURL   string
+	actionToken string
+}
+
+// NewClient creates a client of the Mattermost server at host, posting as
+// the bot with the token. Buttons post back to actionURL.
+func NewClient(host string, token string, actionURL string, actionToken string) (*Client, error) {
+	// Hosts without a scheme are reached over HTTPS.
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	base, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse host %q: %v", host, err)
+	}
+	httpClient := http.Client{
+		Timeout: httpTimeout,
+	}
+	return &Client{
+		baseURL:     base,
+		token:       token,
+		http:        httpClient,
+		actionURL:   actionURL,
+		actionToken: actionToken,
+	}, nil
+}
+
+// ValidAction reports whether the context comes from a button of the bot.
+func (c *Client) ValidAction(context ActionContext) bool {
+	return c.actionToken != "" && subtle.ConstantTimeCompare([]byte(context.Token), []byte(c.actionToken)) == 1
+}
+
+// convert turns a Slack attachment into a Mattermost one. Mattermost ignores
+// Slack buttons, they are replaced by the actions given.
+func convert(a slackClient.Attachment, actions []Action) Attachment {
+	return Attachment{
+		Color:      a.Color,
+		AuthorName: a.AuthorName,
+		AuthorIcon: a.AuthorIcon,
+		Title:      a.Title,
+		Text:       a.Text,
+		ImageURL:   a.ImageURL,
+		ThumbURL:   a.ThumbURL,
+		Footer:     a.Footer,
+		Fields:     a.Fields,
+		Actions:    actions,
+	}
+}
+
+// Post posts the message and attachments to the channel and returns the ID of
+// the post.
+func (c *Client) Post(ctx context.Context, channelID string, message string, attachments ...Attachment) (string, error) {
+	in := map[string]interface{}{
+		"channel_id": channelID,
+		"message":    message,
+	}
+	if len(attachments) > 0 {
+		in["props"] = map[string]interface{}{"attachments": attachments}
+	}
+
+	body, err := c.do(ctx, "POST", "posts", in)
+	if err != nil {
+		return "", err
+	}
+
+	var post struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(body, &post); err != nil {
+		return "", err
+	}
+	return post.ID, nil
+}
+
+// PostEphemeral posts a message in the channel that is only visible to the
+// given user.
+func (c *Client) PostEphemeral(ctx context.Context, channelID string, userID string, message string) error {
+	_, err := c.do(ctx, "POST", "posts/ephemeral", map[string]interface{}{
+		"user_id": userID,
+		"post": map[string]string{
+			"channel_id": channelID,
+			"message":    message,
+		},
+	})
+	return err
+}
+
+// PostSearch posts the items found and the buttons to pick one to download.
+func (c *Client) PostSearch(ctx context.Context, channelID string, kind string, items media.Items) error {
+	results, prompt := slack.SearchAttachments(kind, items)
+
+	var attachments []Attachment
+	for _, a := range results {
+		attachments = append(attachments, convert(a, nil))
+	}
+	var actions []Action
+	for i, a := range prompt.Actions {
+		actions = append(actions, Action{
+			ID:   fmt.Sprintf("download%d", i),
+			Name: a.Text,
+			Type: "button",
+			Integration: Integration{
+				URL: c.actionURL,
+				Context: ActionContext{
+					Action: ActionDownload,
+					Kind:   kind,
+					ID:     a.Name,
+					Title:  a.Value,
+					Token:  c.actionToken,
+				},
+			},
+		})
+	}
+	attachments = append(attachments, convert(prompt, actions))
+
+	_, err := c.Post(ctx, channelID, "", attachments...)
+	return err
+}
+
+// PostLibrarySearch posts the movies and shows found on the media server.
+func (c *Client) PostLibrarySearch(ctx context.Context, channelID string, results emby.SearchResults) error {
+	var attachments []Attachment
+	for _, a := range slack.EmbySearchAttachments(results) {
+		attachments = append(attachments, convert(a, nil))
+	}
+	_, err := c.Post(ctx, channelID, "", attachments...)
+	return err
+}
+
+// NowPlaying posts a card for every session playing something.
+func (c *Client) NowPlaying(ctx context.Context, channelID string, sessions emby.Sessions) error {
+	var attachments []Attachment
+	for _, a := range slack.NowPlayingAttachments(sessions) {
+		attachments = append(attachments, convert(a, nil))
+	}
+	if len(attachments) == 0 {
+		_, err := c.Post(ctx, channelID, "Nothing is playing.")
+		return err
+	}
+	_, err := c.Post(ctx, channelID, "", attachments...)
+	return err
+}
+
+// PostTable posts rows as a fixed width table.
+func (c *Client) PostTable(ctx context.Context, channelID string, title string, header []string, rows [][]string) error {
+	_, err := c.Post(ctx, channelID, "", convert(slack.TableAttachment(title, header, rows), nil))
+	return err
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, in interface{}) ([]byte, error) {
+	input, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/api/v4/%s", strings.TrimSuffix(c.baseURL.String(), "/"), path), bytes.NewReader(input))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req = req.WithContext(ctx)
+	response, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("mattermost returned %s for %s %s: %s", response.Status, method, path, body)
+	}
+
+	return body, nil
+}
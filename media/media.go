@@ -0,0 +1,67 @@
+// Package media holds the types shared by the media managers, such as Radarr
+// and Lidarr, that titles are requested from.
+package media
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	Movie  = "movie"
+	Artist = "artist"
+	Album  = "album"
+	// Book and Audiobook are both handled by Readarr, usually by two
+	// instances of it.
+	Book      = "book"
+	Audiobook = "audiobook"
+)
+
+// Item is a title found in, or added to, a media manager.
+type Item struct {
+	// ID is what the item is added by, e.g. the TMDB ID of a movie or the
+	// MusicBrainz ID of an artist.
+	ID       string
+	Kind     string
+	Title    string
+	Year     int
+	Overview string
+	ImageURL string
+	// Creator is who the item is by, e.g. the artist of an album.
+	Creator string
+}
+
+type Items []Item
+
+// QueueItem is a download a media manager is waiting for.
+type QueueItem struct {
+	Title    string
+	Release  string
+	Status   string
+	Protocol string
+	Size     float64
+	SizeLeft float64
+	TimeLeft time.Duration
+}
+
+// Progress returns how much of the item was downloaded, in percent.
+func (q QueueItem) Progress() float64 {
+	if q.Size == 0 {
+		return 0
+	}
+	return (q.Size - q.SizeLeft) * 100 / q.Size
+}
+
+// ParseTimeLeft parses the hh:mm:ss, or d.hh:mm:ss, time left reported by the
+// media managers.
+func ParseTimeLeft(s string) time.Duration {
+	var days, hours, minutes, seconds int
+	if n, _ := fmt.Sscanf(s, "%d.%d:%d:%d", &days, &hours, &minutes, &seconds); n != 4 {
+		days = 0
+		if n, _ := fmt.Sscanf(s, "%d:%d:%d", &hours, &minutes, &seconds); n != 3 {
+			return 0
+		}
+	}
+	return time.Duration(days)*24*time.Hour + time.Duration(hours)*time.Hour +
+		time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
+}
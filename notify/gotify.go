@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Gotify sends messages to a Gotify server as an application.
+type Gotify struct {
+	token    string
+	priority int
+	baseURL  string
+	http     http.Client
+}
+
+// NewGotify creates a client of the server that sends with the token of an
+// application, at the priority given.
+func NewGotify(server string, token string, priority int) (*Gotify, error) {
+	base, err := url.Parse(server)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse host %q: %v", server, err)
+	}
+	return &Gotify{
+		token:    token,
+		priority: priority,
+		baseURL:  strings.TrimSuffix(base.String(), "/"),
+		http: http.Client{
+			Timeout: httpTimeout,
+		},
+	}, nil
+}
+
+func (g *Gotify) Notify(ctx context.Context, m Message) error {
+	body, err := json.Marshal(struct {
+		Title    string `json:"title"`
+		Message  string `json:"message"`
+		Priority int    `json:"priority"`
+	}{m.Title, m.Message, g.priority})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/message", g.baseURL), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", g.token)
+
+	return do(ctx, &g.http, req)
+}
@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGotify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/message" {
+			t.Errorf("path = %s, want /message", r.URL.Path)
+		}
+		if key := r.Header.Get("X-Gotify-Key"); key != "app-token" {
+			t.Errorf("X-Gotify-Key = %q, want app-token", key)
+		}
+		var body struct {
+			Title    string `json:"title"`
+			Message  string `json:"message"`
+			Priority int    `json:"priority"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		if body.Title != testMessage.Title || body.Message != testMessage.Message || body.Priority != 7 {
+			t.Errorf("body = %+v, want the message at priority 7", body)
+		}
+	}))
+	defer server.Close()
+
+	g, err := NewGotify(server.URL+"/", "app-token", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Notify(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGotifyErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"Unauthorized"}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	g, err := NewGotify(server.URL, "wrong", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Notify(context.Background(), testMessage); err == nil {
+		t.Error("Notify succeeded on a 401")
+	}
+}
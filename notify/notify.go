@@ -0,0 +1,41 @@
+// Package notify sends notifications to services outside of Slack: generic
+// webhooks, ntfy, Gotify and email.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+const httpTimeout = 10 * time.Second
+
+// Message is a notification about something that happened.
+type Message struct {
+	Event   string    `json:"event"`
+	Title   string    `json:"title"`
+	Message string    `json:"message"`
+	User    string    `json:"user,omitempty"`
+	URL     string    `json:"url,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// do sends the request and fails on error statuses, reporting what the
+// service answered.
+func do(ctx context.Context, client *http.Client, req *http.Request) error {
+	req = req.WithContext(ctx)
+	response, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		body, _ := ioutil.ReadAll(response.Body)
+		return fmt.Errorf("%s returned %s: %s", req.URL.Host, response.Status, body)
+	}
+
+	return nil
+}
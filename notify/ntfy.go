@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Ntfy publishes messages to a topic of an ntfy server.
+type Ntfy struct {
+	token    string
+	topicURL string
+	http     http.Client
+}
+
+// NewNtfy creates a client of the topic on the server. The token is only
+// needed for protected topics.
+func NewNtfy(server string, topic string, token string) (*Ntfy, error) {
+	base, err := url.Parse(server)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse host %q: %v", server, err)
+	}
+	return &Ntfy{
+		token:    token,
+		topicURL: fmt.Sprintf("%s/%s", strings.TrimSuffix(base.String(), "/"), url.PathEscape(topic)),
+		http: http.Client{
+			Timeout: httpTimeout,
+		},
+	}, nil
+}
+
+func (n *Ntfy) Notify(ctx context.Context, m Message) error {
+	req, err := http.NewRequest("POST", n.topicURL, strings.NewReader(m.Message))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", m.Title)
+	req.Header.Set("Tags", strings.Replace(m.Event, ".", "_", -1))
+	if m.URL != "" {
+		req.Header.Set("Click", m.URL)
+	}
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+
+	return do(ctx, &n.http, req)
+}
@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNtfy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/warez%20bot" {
+			t.Errorf("path = %s, want the escaped topic", r.URL.EscapedPath())
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if string(body) != testMessage.Message {
+			t.Errorf("body = %q, want %q", body, testMessage.Message)
+		}
+		headers := map[string]string{
+			"Title":         testMessage.Title,
+			"Tags":          "request_created",
+			"Click":         testMessage.URL,
+			"Authorization": "Bearer tk_secret",
+		}
+		for name, want := range headers {
+			if got := r.Header.Get(name); got != want {
+				t.Errorf("%s = %q, want %q", name, got, want)
+			}
+		}
+	}))
+	defer server.Close()
+
+	n, err := NewNtfy(server.URL+"/", "warez bot", "tk_secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Notify(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNtfyWithoutToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("Authorization = %q, want none", auth)
+		}
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	n, err := NewNtfy(server.URL, "warez", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Notify(context.Background(), testMessage); err == nil {
+		t.Error("Notify succeeded on a 403")
+	}
+}
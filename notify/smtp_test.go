@@ -0,0 +1,163 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTP accepts one connection and answers just enough SMTP to take a
+// message. Recipients in reject are refused.
+type fakeSMTP struct {
+	listener net.Listener
+	reject   string
+	rcpts    []string
+	data     string
+	done     chan struct{}
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return &fakeSMTP{listener: l, done: make(chan struct{})}
+}
+
+func (f *fakeSMTP) serve() {
+	defer close(f.done)
+	conn, err := f.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) {
+		conn.Write([]byte(line + "\r\n"))
+	}
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250-fake")
+			reply("250 8BITMIME")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			rcpt := strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>")
+			if rcpt == f.reject {
+				reply("550 no such user")
+				continue
+			}
+			f.rcpts = append(f.rcpts, rcpt)
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			f.data = data.String()
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestSMTP(t *testing.T) {
+	fake := newFakeSMTP(t)
+	go fake.serve()
+
+	s, err := NewSMTP(fake.listener.Addr().String(), "", "", "bot@example.com", []string{"alice@example.com", "bob@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Notify(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+	<-fake.done
+
+	if strings.Join(fake.rcpts, ",") != "alice@example.com,bob@example.com" {
+		t.Errorf("recipients = %v, want alice and bob", fake.rcpts)
+	}
+	for _, want := range []string{
+		"From: bot@example.com\r\n",
+		"To: alice@example.com, bob@example.com\r\n",
+		"Subject: New request\r\n",
+		"Content-Type: text/plain; charset=utf-8\r\n",
+		testMessage.Message,
+		testMessage.URL,
+	} {
+		if !strings.Contains(fake.data, want) {
+			t.Errorf("message does not contain %q:\n%s", want, fake.data)
+		}
+	}
+}
+
+func TestSMTPRejectedRecipient(t *testing.T) {
+	fake := newFakeSMTP(t)
+	fake.reject = "bob@example.com"
+	go fake.serve()
+
+	s, err := NewSMTP(fake.listener.Addr().String(), "", "", "bot@example.com", []string{"alice@example.com", "bob@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Notify(context.Background(), testMessage); err == nil {
+		t.Error("Notify succeeded with a rejected recipient")
+	}
+}
+
+func TestSMTPStuckServer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	// Accept, then never greet.
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		time.Sleep(5 * time.Second)
+	}()
+
+	s, err := NewSMTP(l.Addr().String(), "", "", "bot@example.com", []string{"alice@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := s.Notify(ctx, testMessage); err == nil {
+		t.Error("Notify succeeded without a greeting")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Notify took %v, want it to give up at the deadline", elapsed)
+	}
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var testMessage = Message{
+	Event:   "request.created",
+	Title:   "New request",
+	Message: "Dune (2021) was requested",
+	User:    "alice",
+	URL:     "https://emby.example/item/1",
+	Time:    time.Date(2021, 10, 22, 12, 0, 0, 0, time.UTC),
+}
+
+func TestWebhook(t *testing.T) {
+	var got Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	w, err := NewWebhook(server.URL + "/hook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Notify(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Time.Equal(testMessage.Time) {
+		t.Errorf("time = %v, want %v", got.Time, testMessage.Time)
+	}
+	got.Time = testMessage.Time
+	if got != testMessage {
+		t.Errorf("posted %+v, want %+v", got, testMessage)
+	}
+}
+
+func TestWebhookErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	w, err := NewWebhook(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Notify(context.Background(), testMessage); err == nil {
+		t.Error("Notify succeeded on a 500")
+	}
+}
@@ -0,0 +1,373 @@
+package plex
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"warezbot/emby"
+)
+
+const (
+	httpTimeout = 20 * time.Second
+
+	// ticksPerMillisecond converts Plex durations to the ticks Emby uses.
+	ticksPerMillisecond = 10000
+)
+
+// itemTypes maps Plex item types to their Emby names.
+var itemTypes = map[string]string{
+	"movie":   "Movie",
+	"show":    "Series",
+	"season":  "Season",
+	"episode": "Episode",
+	"artist":  "MusicArtist",
+	"album":   "MusicAlbum",
+	"track":   "Audio",
+}
+
+// MediaContainer is the envelope of every Plex response. In JSON it is
+// wrapped in a MediaContainer object, in XML it is the root element and the
+// items are Video, Track or Directory elements.
+type MediaContainer struct {
+	Size      int        `json:"size" xml:"size,attr"`
+	TotalSize int        `json:"totalSize" xml:"totalSize,attr"`
+	Metadata  []Metadata `json:"Metadata" xml:",any"`
+}
+
+type Metadata struct {
+	RatingKey        string `json:"ratingKey" xml:"ratingKey,attr"`
+	Key              string `json:"key" xml:"key,attr"`
+	GUID             string `json:"guid" xml:"guid,attr"`
+	Type             string `json:"type" xml:"type,attr"`
+	Title            string `json:"title" xml:"title,attr"`
+	GrandparentTitle string `json:"grandparentTitle" xml:"grandparentTitle,attr"`
+	ParentTitle      string `json:"parentTitle" xml:"parentTitle,attr"`
+	Summary          string `json:"summary" xml:"summary,attr"`
+	Year             int    `json:"year" xml:"year,attr"`
+	Index            int    `json:"index" xml:"index,attr"`
+	ParentIndex      int    `json:"parentIndex" xml:"parentIndex,attr"`
+	Duration         int64  `json:"duration" xml:"duration,attr"`
+	ViewOffset       int64  `json:"viewOffset" xml:"viewOffset,attr"`
+	Thumb            string `json:"thumb" xml:"thumb,attr"`
+	GrandparentThumb string `json:"grandparentThumb" xml:"grandparentThumb,attr"`
+	Guids            []struct {
+		ID string `json:"id" xml:"id,attr"`
+	} `json:"Guid" xml:"Guid"`
+	User struct {
+		ID    string `json:"id" xml:"id,attr"`
+		Title string `json:"title" xml:"title,attr"`
+	} `json:"User" xml:"User"`
+	Player struct {
+		Title             string `json:"title" xml:"title,attr"`
+		Product           string `json:"product" xml:"product,attr"`
+		State             string `json:"state" xml:"state,attr"`
+		Address           string `json:"address" xml:"address,attr"`
+		MachineIdentifier string `json:"machineIdentifier" xml:"machineIdentifier,attr"`
+	} `json:"Player" xml:"Player"`
+	Session struct {
+		ID string `json:"id" xml:"id,attr"`
+	} `json:"Session" xml:"Session"`
+	TranscodeSession struct {
+		VideoDecision string `json:"videoDecision" xml:"videoDecision,attr"`
+	} `json:"TranscodeSession" xml:"TranscodeSession"`
+}
+
+// ProviderID returns the ID the item has with a metadata provider such as
+// imdb or tmdb.
+func (m Metadata) ProviderID(provider string) string {
+	for _, guid := range m.Guids {
+		if strings.HasPrefix(guid.ID, provider+"://") {
+			return strings.TrimPrefix(guid.ID, provider+"://")
+		}
+	}
+	return ""
+}
+
+// EmbyType returns the Emby name of the item type, e.g. Series for a show.
+func (m Metadata) EmbyType() string {
+	if t, ok := itemTypes[m.Type]; ok {
+		return t
+	}
+	return m.Type
+}
+
+// playMethod returns how the item is played, in Emby's terms.
+func (m Metadata) playMethod() string {
+	switch m.TranscodeSession.VideoDecision {
+	case "":
+		return "DirectPlay"
+	case "transcode":
+		return "Transcode"
+	default:
+		return "DirectStream"
+	}
+}
+
+type sections struct {
+	Directory []struct {
+		Key      string `json:"key" xml:"key,attr"`
+		Type     string `json:"type" xml:"type,attr"`
+		Title    string `json:"title" xml:"title,attr"`
+		Location []struct {
+			Path string `json:"path" xml:"path,attr"`
+		} `json:"Location" xml:"Location"`
+	} `json:"Directory" xml:"Directory"`
+}
+
+type Client struct {
+	token   string
+	posters bool
+	baseURL *url.URL
+	http    http.Client
+}
+
+// NewClient creates a Plex client. Poster URLs carry the token, so they are
+// only added to items when posters is set.
+func NewClient(host, token string, posters bool) (*Client, error) {
+	// Hosts without a scheme are reached over HTTPS.
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	base, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse host %q: %v", host, err)
+	}
+	httpClient := http.Client{
+		Timeout: httpTimeout,
+	}
+	return &Client{
+		token:   token,
+		posters: posters,
+		baseURL: base,
+		http:    httpClient,
+	}, nil
+}
+
+// URL returns the address of the Plex server.
+func (c *Client) URL() string {
+	return c.baseURL.String()
+}
+
+func (c *Client) Sessions(ctx context.Context) (emby.Sessions, error) {
+	var container MediaContainer
+	if err := c.get(ctx, "status/sessions", &container); err != nil {
+		return nil, err
+	}
+
+	result := make(emby.Sessions, len(container.Metadata))
+	for i, item := range container.Metadata {
+		result[i].ID = item.Session.ID
+		result[i].UserID = item.User.ID
+		result[i].UserName = item.User.Title
+		result[i].Client = item.Player.Product
+		result[i].DeviceName = item.Player.Title
+		result[i].DeviceID = item.Player.MachineIdentifier
+		result[i].RemoteEndPoint = item.Player.Address
+		result[i].PlayState.PositionTicks = item.ViewOffset * ticksPerMillisecond
+		result[i].PlayState.IsPaused = item.Player.State == "paused"
+		result[i].PlayState.PlayMethod = item.playMethod()
+
+		result[i].NowPlayingItem.Name = item.Title
+		result[i].NowPlayingItem.ID = item.RatingKey
+		result[i].NowPlayingItem.Type = item.EmbyType()
+		result[i].NowPlayingItem.SeriesName = item.GrandparentTitle
+		result[i].NowPlayingItem.IndexNumber = item.Index
+		result[i].NowPlayingItem.ParentIndexNumber = item.ParentIndex
+		result[i].NowPlayingItem.RunTimeTicks = item.Duration * ticksPerMillisecond
+		result[i].NowPlayingItem.ProductionYear = item.Year
+		result[i].NowPlayingItem.Overview = item.Summary
+
+		result[i].ItemDetail.Overview = item.Summary
+		result[i].ItemImages = c.itemImages(item)
+	}
+
+	return result, nil
+}
+
+// PlayMethod returns how the session plays its item. The webhook identifies
+// sessions by player, so a player ID is accepted as well.
+func (c *Client) PlayMethod(ctx context.Context, sessionID string) (string, error) {
+	var container MediaContainer
+	if err := c.get(ctx, "status/sessions", &container); err != nil {
+		return "", err
+	}
+
+	for _, item := range container.Metadata {
+		if item.Session.ID == sessionID || item.Player.MachineIdentifier == sessionID {
+			return item.playMethod(), nil
+		}
+	}
+
+	return "", fmt.Errorf("plex session %s not found", sessionID)
+}
+
+func (c *Client) Search(ctx context.Context, searchTerm []string) (emby.SearchResults, error) {
+	query := url.Values{
+		"query":        {strings.Join(searchTerm, " ")},
+		"includeGuids": {"1"},
+	}
+	var container MediaContainer
+	if err := c.get(ctx, fmt.Sprintf("search?%s", query.Encode()), &container); err != nil {
+		return emby.SearchResults{}, err
+	}
+
+	sr := emby.SearchResults{
+		TotalRecordCount: len(container.Metadata),
+	}
+	for _, item := range container.Metadata {
+		hint := emby.SearchHint{
+			Name:           item.Title,
+			Type:           item.EmbyType(),
+			ProductionYear: item.Year,
+			IndexNumber:    item.Index,
+			RunTimeTicks:   item.Duration * ticksPerMillisecond,
+			Series:         item.GrandparentTitle,
+			ItemImages:     c.itemImages(item),
+		}
+		hint.ItemDetail.Name = item.Title
+		hint.ItemDetail.Overview = item.Summary
+		sr.SearchHints = append(sr.SearchHints, hint)
+	}
+
+	return sr, nil
+}
+
+// itemImages returns the poster of the item, or of its series for episodes.
+func (c *Client) itemImages(item Metadata) emby.ItemImages {
+	thumb := item.Thumb
+	if item.GrandparentThumb != "" {
+		thumb = item.GrandparentThumb
+	}
+	if !c.posters || thumb == "" {
+		return emby.ItemImages{}
+	}
+
+	query := url.Values{
+		"url":          {thumb},
+		"width":        {"300"},
+		"height":       {"450"},
+		"X-Plex-Token": {c.token},
+	}
+	return emby.ItemImages{
+		Images: []emby.Image{
+			{
+				ProviderName: "Plex",
+				URL:          fmt.Sprintf("%s/photo/:/transcode?%s", c.baseURL, query.Encode()),
+				Type:         "Primary",
+			},
+		},
+		TotalRecordCount: 1,
+	}
+}
+
+// Libraries returns the library sections. Their keys are used as item IDs.
+func (c *Client) Libraries(ctx context.Context) (emby.Libraries, error) {
+	var s sections
+	if err := c.get(ctx, "library/sections", &s); err != nil {
+		return nil, err
+	}
+
+	var libraries emby.Libraries
+	for _, dir := range s.Directory {
+		library := emby.Library{
+			Name:           dir.Title,
+			CollectionType: dir.Type,
+			ItemID:         dir.Key,
+		}
+		for _, location := range dir.Location {
+			library.Locations = append(library.Locations, location.Path)
+		}
+		libraries = append(libraries, library)
+	}
+
+	return libraries, nil
+}
+
+// LibraryStats counts the titles in every library section.
+func (c *Client) LibraryStats(ctx context.Context) ([]emby.LibraryStat, error) {
+	libraries, err := c.Libraries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats []emby.LibraryStat
+	for _, library := range libraries {
+		query := url.Values{
+			"X-Plex-Container-Start": {"0"},
+			"X-Plex-Container-Size":  {"0"},
+		}
+		var container MediaContainer
+		if err := c.get(ctx, fmt.Sprintf("library/sections/%s/all?%s", library.ItemID, query.Encode()), &container); err != nil {
+			return nil, err
+		}
+
+		stats = append(stats, emby.LibraryStat{
+			Name:           library.Name,
+			CollectionType: library.CollectionType,
+			Titles:         container.TotalSize,
+		})
+	}
+
+	return stats, nil
+}
+
+// RefreshLibrary scans all library sections for new and removed files.
+func (c *Client) RefreshLibrary(ctx context.Context) error {
+	return c.get(ctx, "library/sections/all/refresh", nil)
+}
+
+// RefreshItem scans a single library section.
+func (c *Client) RefreshItem(ctx context.Context, id string) error {
+	return c.get(ctx, fmt.Sprintf("library/sections/%s/refresh", url.PathEscape(id)), nil)
+}
+
+// get decodes the response into v, which is the content of the
+// MediaContainer. Plex answers in JSON when asked to, but some endpoints and
+// older servers only speak XML.
+func (c *Client) get(ctx context.Context, path string, v interface{}) error {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/%s", c.baseURL, path), nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("X-Plex-Token", c.token)
+	req.Header.Set("Accept", "application/json")
+	req = req.WithContext(ctx)
+	response, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("plex returned %s for GET %s: %s", response.Status, path, body)
+	}
+
+	if v == nil || len(body) == 0 {
+		return nil
+	}
+
+	if strings.Contains(response.Header.Get("Content-Type"), "xml") {
+		return xml.Unmarshal(body, v)
+	}
+
+	var envelope struct {
+		MediaContainer json.RawMessage `json:"MediaContainer"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return err
+	}
+	return json.Unmarshal(envelope.MediaContainer, v)
+}
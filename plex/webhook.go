@@ -0,0 +1,36 @@
+package plex
+
+// WebhookEvent is the payload part of a Plex webhook.
+type WebhookEvent struct {
+	Event   string `json:"event"`
+	User    bool   `json:"user"`
+	Owner   bool   `json:"owner"`
+	Account struct {
+		ID    int    `json:"id"`
+		Title string `json:"title"`
+	} `json:"Account"`
+	Server struct {
+		Title string `json:"title"`
+		UUID  string `json:"uuid"`
+	} `json:"Server"`
+	Player struct {
+		Local         bool   `json:"local"`
+		PublicAddress string `json:"publicAddress"`
+		Title         string `json:"title"`
+		UUID          string `json:"uuid"`
+	} `json:"Player"`
+	Metadata Metadata `json:"Metadata"`
+}
+
+// EmbyEvent returns the name Emby uses for the event, or the Plex event if
+// Emby has no equivalent. Pausing and resuming are not starts and stops.
+func (e WebhookEvent) EmbyEvent() string {
+	switch e.Event {
+	case "media.play":
+		return "playback.start"
+	case "media.stop":
+		return "playback.stop"
+	default:
+		return e.Event
+	}
+}
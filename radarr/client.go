@@ -8,8 +8,11 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
+
+	"warezbot/media"
 )
 
 const (
@@ -20,6 +23,7 @@ const (
 )
 
 type Movies []struct {
+	ID                    int           `json:"id"`
 	Title                 string        `json:"title"`
 	AlternativeTitles     []interface{} `json:"alternativeTitles"`
 	SecondaryYearSourceID int           `json:"secondaryYearSourceId"`
@@ -28,6 +32,8 @@ type Movies []struct {
 	Status                string        `json:"status"`
 	Overview              string        `json:"overview"`
 	InCinemas             time.Time     `json:"inCinemas,omitempty"`
+	DigitalRelease        time.Time     `json:"digitalRelease,omitempty"`
+	PhysicalRelease       time.Time     `json:"physicalRelease,omitempty"`
 	Images                []struct {
 		CoverType string `json:"coverType"`
 		URL       string `json:"url"`
@@ -104,6 +110,20 @@ type AddMovieResponse struct {
 	ID                  int           `json:"id"`
 }
 
+type queue []struct {
+	Movie struct {
+		Title string `json:"title"`
+		Year  int    `json:"year"`
+	} `json:"movie"`
+	Title                 string  `json:"title"`
+	Size                  float64 `json:"size"`
+	Sizeleft              float64 `json:"sizeleft"`
+	Timeleft              string  `json:"timeleft"`
+	Status                string  `json:"status"`
+	TrackedDownloadStatus string  `json:"trackedDownloadStatus"`
+	Protocol              string  `json:"protocol"`
+}
+
 type Client struct {
 	token   string
 	baseURL *url.URL
@@ -111,11 +131,14 @@ type Client struct {
 }
 
 func NewClient(host, token string) (*Client, error) {
+	// Hosts without a scheme are reached over HTTPS.
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
 	base, err := url.Parse(host)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse host %q: %v", host, err)
 	}
-	base.Scheme = "https"
 	httpClient := http.Client{
 		Timeout: httpTimeout,
 	}
@@ -126,7 +149,7 @@ func NewClient(host, token string) (*Client, error) {
 	}, nil
 }
 
-func (c *Client) Search(ctx context.Context, searchTerm []string) (Movies, error) {
+func (c *Client) Search(ctx context.Context, searchTerm []string) (media.Items, error) {
 	s := strings.Join(searchTerm, "%20")
 	body, err := c.do(ctx, "GET", fmt.Sprintf("movie/lookup?term=%s&apikey=%s", s, c.token), nil)
 	if err != nil {
@@ -138,19 +161,130 @@ func (c *Client) Search(ctx context.Context, searchTerm []string) (Movies, error
 		return nil, err
 	}
 
-	return movies, nil
+	var items media.Items
+	for _, movie := range movies {
+		item := media.Item{
+			ID:       strconv.Itoa(movie.TmdbID),
+			Kind:     media.Movie,
+			Title:    movie.Title,
+			Year:     movie.Year,
+			Overview: movie.Overview,
+			ImageURL: movie.RemotePoster,
+		}
+		if len(movie.Images) > 0 {
+			item.ImageURL = movie.Images[0].URL
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// Add adds the movie with the TMDB ID and starts searching for it.
+func (c *Client) Add(ctx context.Context, id string) (media.Item, error) {
+	movie, err := c.Download(ctx, id)
+	if err != nil {
+		return media.Item{}, err
+	}
+	return addedItem(movie), nil
+}
+
+// AddWithOptions adds the movie with the TMDB ID the way the options say and
+// starts searching for it.
+func (c *Client) AddWithOptions(ctx context.Context, id string, opts AddOptions) (media.Item, error) {
+	movie, err := c.download(ctx, id, opts)
+	if err != nil {
+		return media.Item{}, err
+	}
+	return addedItem(movie), nil
+}
+
+func addedItem(movie AddMovieResponse) media.Item {
+	item := media.Item{
+		ID:    strconv.Itoa(movie.TmdbID),
+		Kind:  media.Movie,
+		Title: movie.Title,
+		Year:  movie.Year,
+	}
+	if len(movie.Images) > 0 {
+		item.ImageURL = movie.Images[0].URL
+	}
+	return item
+}
+
+// Available reports whether the movie with the TMDB ID is in the library and
+// has been downloaded.
+func (c *Client) Available(ctx context.Context, id string) (bool, error) {
+	body, err := c.do(ctx, "GET", fmt.Sprintf("movie/lookup?term=tmdb:%s&apikey=%s", id, c.token), nil)
+	if err != nil {
+		return false, err
+	}
+
+	var movies Movies
+	if err := json.Unmarshal(body, &movies); err != nil {
+		return false, err
+	}
+	// Lookups return movies in the library with their ID set.
+	return len(movies) > 0 && movies[0].ID != 0 && movies[0].HasFile, nil
+}
+
+// Queue returns the movies being downloaded.
+func (c *Client) Queue(ctx context.Context) ([]media.QueueItem, error) {
+	body, err := c.do(ctx, "GET", fmt.Sprintf("queue?apikey=%s", c.token), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var q queue
+	if err := json.Unmarshal(body, &q); err != nil {
+		return nil, err
+	}
+
+	var items []media.QueueItem
+	for _, record := range q {
+		status := record.Status
+		if record.TrackedDownloadStatus != "" && record.TrackedDownloadStatus != "Ok" {
+			status = fmt.Sprintf("%s (%s)", status, record.TrackedDownloadStatus)
+		}
+		items = append(items, media.QueueItem{
+			Title:    fmt.Sprintf("%s (%d)", record.Movie.Title, record.Movie.Year),
+			Release:  record.Title,
+			Status:   status,
+			Protocol: record.Protocol,
+			Size:     record.Size,
+			SizeLeft: record.Sizeleft,
+			TimeLeft: media.ParseTimeLeft(record.Timeleft),
+		})
+	}
+
+	return items, nil
 }
 
 func (c *Client) Download(ctx context.Context, id string) (AddMovieResponse, error) {
+	return c.download(ctx, id, AddOptions{
+		QualityProfileID: qualityProfileID,
+		RootFolderPath:   rootFolderPath,
+		Monitored:        true,
+	})
+}
+
+func (c *Client) download(ctx context.Context, id string, opts AddOptions) (AddMovieResponse, error) {
 	x, err := c.do(ctx, "GET", fmt.Sprintf("movie/lookup?term=tmdb:%s&apikey=%s", id, c.token), nil)
+	if err != nil {
+		return AddMovieResponse{}, err
+	}
 	var r []AddMovieRequest
 	if err := json.Unmarshal(x, &r); err != nil {
 		return AddMovieResponse{}, err
 	}
+	if len(r) == 0 {
+		return AddMovieResponse{}, fmt.Errorf("no movie with TMDB ID %s", id)
+	}
 
-	r[0].QualityProfileID = qualityProfileID
-	r[0].Monitored = true
-	r[0].RootFolderPath = rootFolderPath
+	r[0].QualityProfileID = opts.QualityProfileID
+	r[0].Monitored = opts.Monitored
+	r[0].RootFolderPath = opts.RootFolderPath
+	// Radarr searches for the movie as soon as it is added.
 	r[0].AddOptions.SearchForMovie = true
 	r[0].MinimumAvailability = minimumAvailability
 
@@ -169,49 +303,34 @@ func (c *Client) Download(ctx context.Context, id string) (AddMovieResponse, err
 		return AddMovieResponse{}, err
 	}
 
-	if err := c.runCommand(ctx, "MoviesSearch", b.ID); err != nil {
-		return AddMovieResponse{}, err
-	}
-
 	return b, nil
 }
 
-func (c *Client) runCommand(ctx context.Context, name string, id int) error {
-
-	i := struct {
-		Name     string
-		MovieIds []int
-	}{
-		Name:     name,
-		MovieIds: []int{id},
-	}
-	im, err := json.Marshal(i)
-	time.Sleep(5 * time.Second)
-	resp, err := c.do(ctx, "POST", fmt.Sprintf("command?apikey=%s", c.token), im)
-	if err != nil {
-		return err
-	}
-	fmt.Print(string(resp))
-
-	return nil
-}
-
 func (c *Client) do(ctx context.Context, method string, path string, input []byte) ([]byte, error) {
 	req, err := http.NewRequest(method, fmt.Sprintf("%s/%s", c.baseURL, path), bytes.NewBuffer(input))
 	if err != nil {
 		return nil, err
 	}
 
-	// req = req.WithContext(ctx)
+	if input != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req = req.WithContext(ctx)
 	response, err := c.http.Do(req)
 	if err != nil {
 		return nil, err
 	}
+	defer response.Body.Close()
 
 	body, err := ioutil.ReadAll(response.Body)
 	if err != nil {
 		return nil, err
 	}
 
+	if response.StatusCode >= http.StatusBadRequest {
+		// Leave the query out, it holds the API key.
+		return nil, fmt.Errorf("radarr returned %s for %s %s: %s", response.Status, method, strings.SplitN(path, "?", 2)[0], body)
+	}
+
 	return body, nil
 }
@@ -0,0 +1,64 @@
+package radarr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"warezbot/media"
+)
+
+// LookupResult is a movie found by Lookup.
+type LookupResult struct {
+	media.Item
+	// InLibrary is set if the movie was added to Radarr, Downloaded once it
+	// has been downloaded.
+	InLibrary  bool
+	Downloaded bool
+	// Released is set once the movie is out digitally or on disc.
+	Released bool
+}
+
+// Lookup finds the movie the term identifies, like imdb:tt0078748 or
+// tmdb:348, and tells whether it is in the library. found is false if there is
+// no such movie.
+func (c *Client) Lookup(ctx context.Context, term string) (LookupResult, bool, error) {
+	body, err := c.do(ctx, "GET", fmt.Sprintf("movie/lookup?term=%s&apikey=%s", url.QueryEscape(term), c.token), nil)
+	if err != nil {
+		return LookupResult{}, false, err
+	}
+
+	var movies Movies
+	if err := json.Unmarshal(body, &movies); err != nil {
+		return LookupResult{}, false, err
+	}
+	if len(movies) == 0 || movies[0].TmdbID == 0 {
+		return LookupResult{}, false, nil
+	}
+
+	movie := movies[0]
+	r := LookupResult{
+		Item: media.Item{
+			ID:       strconv.Itoa(movie.TmdbID),
+			Kind:     media.Movie,
+			Title:    movie.Title,
+			Year:     movie.Year,
+			Overview: movie.Overview,
+			ImageURL: movie.RemotePoster,
+		},
+		InLibrary:  movie.ID != 0,
+		Downloaded: movie.ID != 0 && movie.HasFile,
+		Released:   released(movie.DigitalRelease) || released(movie.PhysicalRelease),
+	}
+	if len(movie.Images) > 0 {
+		r.ImageURL = movie.Images[0].URL
+	}
+	return r, true, nil
+}
+
+func released(date time.Time) bool {
+	return !date.IsZero() && date.Before(time.Now())
+}
@@ -0,0 +1,55 @@
+package radarr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// AddOptions is how a movie is added.
+type AddOptions struct {
+	QualityProfileID int
+	RootFolderPath   string
+	// Monitored has Radarr look for the movie, and for better releases of
+	// it, on its own.
+	Monitored bool
+}
+
+type QualityProfile struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type RootFolder struct {
+	ID        int    `json:"id"`
+	Path      string `json:"path"`
+	FreeSpace int64  `json:"freeSpace"`
+}
+
+// QualityProfiles returns the quality profiles movies can be added with.
+func (c *Client) QualityProfiles(ctx context.Context) ([]QualityProfile, error) {
+	body, err := c.do(ctx, "GET", fmt.Sprintf("profile?apikey=%s", c.token), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles []QualityProfile
+	if err := json.Unmarshal(body, &profiles); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// RootFolders returns the folders movies can be added to.
+func (c *Client) RootFolders(ctx context.Context) ([]RootFolder, error) {
+	body, err := c.do(ctx, "GET", fmt.Sprintf("rootfolder?apikey=%s", c.token), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var folders []RootFolder
+	if err := json.Unmarshal(body, &folders); err != nil {
+		return nil, err
+	}
+	return folders, nil
+}
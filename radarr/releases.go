@@ -0,0 +1,146 @@
+package radarr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Release is a release of a movie found on an indexer.
+type Release struct {
+	GUID        string
+	Title       string
+	Size        int64
+	Indexer     string
+	IndexerID   int
+	Seeders     int
+	Leechers    int
+	Quality     string
+	Protocol    string
+	DownloadURL string
+	PublishDate time.Time
+	// Rejections are the reasons Radarr would not grab the release itself.
+	Rejections []string
+}
+
+type release struct {
+	GUID      string `json:"guid"`
+	Title     string `json:"title"`
+	Size      int64  `json:"size"`
+	Indexer   string `json:"indexer"`
+	IndexerID int    `json:"indexerId"`
+	Seeders   int    `json:"seeders"`
+	Leechers  int    `json:"leechers"`
+	Quality   struct {
+		Quality struct {
+			Name string `json:"name"`
+		} `json:"quality"`
+	} `json:"quality"`
+	Protocol    string    `json:"protocol"`
+	DownloadURL string    `json:"downloadUrl"`
+	PublishDate time.Time `json:"publishDate"`
+	Rejections  []string  `json:"rejections"`
+}
+
+// Releases searches the indexers of Radarr for releases of the movie in the
+// library whose title matches.
+func (c *Client) Releases(ctx context.Context, title string) ([]Release, error) {
+	id, err := c.movieID(ctx, title)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.do(ctx, "GET", fmt.Sprintf("release?movieId=%d&apikey=%s", id, c.token), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []release
+	if err := json.Unmarshal(body, &found); err != nil {
+		return nil, err
+	}
+
+	releases := make([]Release, len(found))
+	for i, r := range found {
+		releases[i] = Release{
+			GUID:        r.GUID,
+			Title:       r.Title,
+			Size:        r.Size,
+			Indexer:     r.Indexer,
+			IndexerID:   r.IndexerID,
+			Seeders:     r.Seeders,
+			Leechers:    r.Leechers,
+			Quality:     r.Quality.Quality.Name,
+			Protocol:    r.Protocol,
+			DownloadURL: r.DownloadURL,
+			PublishDate: r.PublishDate,
+			Rejections:  r.Rejections,
+		}
+	}
+
+	return releases, nil
+}
+
+// Grab sends the release to the download client. Releases found by Radarr
+// are grabbed by their GUID, others are pushed to Radarr, which grabs them if
+// they match a movie.
+func (c *Client) Grab(ctx context.Context, r Release) error {
+	var input []byte
+	var err error
+	path := "release"
+	if r.IndexerID != 0 {
+		input, err = json.Marshal(struct {
+			GUID      string `json:"guid"`
+			IndexerID int    `json:"indexerId"`
+		}{r.GUID, r.IndexerID})
+	} else {
+		path = "release/push"
+		input, err = json.Marshal(struct {
+			Title       string    `json:"title"`
+			DownloadURL string    `json:"downloadUrl"`
+			Protocol    string    `json:"protocol"`
+			PublishDate time.Time `json:"publishDate"`
+		}{r.Title, r.DownloadURL, r.Protocol, r.PublishDate})
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = c.do(ctx, "POST", fmt.Sprintf("%s?apikey=%s", path, c.token), input)
+	return err
+}
+
+// movieID returns the ID of the movie in the library with the title, or the
+// only one whose title contains it.
+func (c *Client) movieID(ctx context.Context, title string) (int, error) {
+	body, err := c.do(ctx, "GET", fmt.Sprintf("movie?apikey=%s", c.token), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var movies Movies
+	if err := json.Unmarshal(body, &movies); err != nil {
+		return 0, err
+	}
+
+	var matches []int
+	for _, movie := range movies {
+		if strings.EqualFold(movie.Title, title) {
+			return movie.ID, nil
+		}
+		if strings.Contains(strings.ToLower(movie.Title), strings.ToLower(title)) {
+			matches = append(matches, movie.ID)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return 0, fmt.Errorf("%q is not in Radarr", title)
+	case 1:
+		return matches[0], nil
+	default:
+		return 0, fmt.Errorf("%d movies in Radarr match %q, be more specific", len(matches), title)
+	}
+}
@@ -0,0 +1,311 @@
+package readarr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"warezbot/media"
+)
+
+const (
+	httpTimeout = 10 * time.Second
+	queueSize   = 50
+)
+
+type Image struct {
+	CoverType string `json:"coverType"`
+	URL       string `json:"url"`
+	RemoteURL string `json:"remoteUrl"`
+}
+
+type Author struct {
+	ID                int     `json:"id,omitempty"`
+	AuthorName        string  `json:"authorName"`
+	ForeignAuthorID   string  `json:"foreignAuthorId"`
+	Overview          string  `json:"overview,omitempty"`
+	Images            []Image `json:"images,omitempty"`
+	QualityProfileID  int     `json:"qualityProfileId,omitempty"`
+	MetadataProfileID int     `json:"metadataProfileId,omitempty"`
+	RootFolderPath    string  `json:"rootFolderPath,omitempty"`
+	Monitored         bool    `json:"monitored"`
+	AddOptions        *struct {
+		Monitor               string `json:"monitor"`
+		SearchForMissingBooks bool   `json:"searchForMissingBooks"`
+	} `json:"addOptions,omitempty"`
+}
+
+type Edition struct {
+	ForeignEditionID string `json:"foreignEditionId"`
+	Title            string `json:"title"`
+	Isbn13           string `json:"isbn13,omitempty"`
+	Format           string `json:"format,omitempty"`
+	IsEbook          bool   `json:"isEbook"`
+	Monitored        bool   `json:"monitored"`
+}
+
+// Book is a book as returned by a lookup. It is added with its author nested
+// in it, and Readarr adds the author too if it does not know them yet.
+type Book struct {
+	ID            int       `json:"id,omitempty"`
+	Title         string    `json:"title"`
+	ForeignBookID string    `json:"foreignBookId"`
+	Overview      string    `json:"overview,omitempty"`
+	ReleaseDate   time.Time `json:"releaseDate"`
+	Images        []Image   `json:"images,omitempty"`
+	RemoteCover   string    `json:"remoteCover,omitempty"`
+	Monitored     bool      `json:"monitored"`
+	AnyEditionOk  bool      `json:"anyEditionOk"`
+	Author        Author    `json:"author"`
+	Editions      []Edition `json:"editions,omitempty"`
+	AddOptions    *struct {
+		SearchForNewBook bool `json:"searchForNewBook"`
+	} `json:"addOptions,omitempty"`
+}
+
+type queue struct {
+	Records []struct {
+		Author struct {
+			AuthorName string `json:"authorName"`
+		} `json:"author"`
+		Book struct {
+			Title string `json:"title"`
+		} `json:"book"`
+		Title                 string  `json:"title"`
+		Size                  float64 `json:"size"`
+		Sizeleft              float64 `json:"sizeleft"`
+		Timeleft              string  `json:"timeleft"`
+		Status                string  `json:"status"`
+		TrackedDownloadStatus string  `json:"trackedDownloadStatus"`
+		Protocol              string  `json:"protocol"`
+	} `json:"records"`
+}
+
+type Client struct {
+	token             string
+	kind              string
+	qualityProfileID  int
+	metadataProfileID int
+	rootFolderPath    string
+	baseURL           *url.URL
+	http              http.Client
+}
+
+// NewClient creates a Readarr client for items of the kind, media.Book or
+// media.Audiobook. Books are added with the quality and metadata profiles to
+// the root folder given.
+func NewClient(host, token string, kind string, qualityProfileID int, metadataProfileID int, rootFolderPath string) (*Client, error) {
+	// Hosts without a scheme are reached over HTTPS.
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	base, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse host %q: %v", host, err)
+	}
+	httpClient := http.Client{
+		Timeout: httpTimeout,
+	}
+	return &Client{
+		token:             token,
+		kind:              kind,
+		qualityProfileID:  qualityProfileID,
+		metadataProfileID: metadataProfileID,
+		rootFolderPath:    rootFolderPath,
+		baseURL:           base,
+		http:              httpClient,
+	}, nil
+}
+
+func (c *Client) Search(ctx context.Context, searchTerm []string) (media.Items, error) {
+	books, err := c.SearchBooks(ctx, strings.Join(searchTerm, " "))
+	if err != nil {
+		return nil, err
+	}
+
+	var items media.Items
+	for _, book := range books {
+		items = append(items, book.item(c.kind))
+	}
+	return items, nil
+}
+
+func (c *Client) Add(ctx context.Context, id string) (media.Item, error) {
+	book, err := c.AddBook(ctx, id)
+	if err != nil {
+		return media.Item{}, err
+	}
+	return book.item(c.kind), nil
+}
+
+func (b Book) item(kind string) media.Item {
+	item := media.Item{
+		ID:       b.ForeignBookID,
+		Kind:     kind,
+		Title:    b.Title,
+		Overview: b.Overview,
+		ImageURL: b.cover(),
+		Creator:  b.Author.AuthorName,
+	}
+	if !b.ReleaseDate.IsZero() {
+		item.Year = b.ReleaseDate.Year()
+	}
+	return item
+}
+
+// cover returns the URL of the cover of the book.
+func (b Book) cover() string {
+	if b.RemoteCover != "" {
+		return b.RemoteCover
+	}
+	for _, i := range b.Images {
+		if i.CoverType == "cover" {
+			if i.RemoteURL != "" {
+				return i.RemoteURL
+			}
+			return i.URL
+		}
+	}
+	return ""
+}
+
+func (c *Client) SearchBooks(ctx context.Context, term string) ([]Book, error) {
+	var books []Book
+	if err := c.lookup(ctx, term, &books); err != nil {
+		return nil, err
+	}
+	return books, nil
+}
+
+// AddBook adds the book with the Readarr ID and starts searching for it. Its
+// author is added too, without monitoring their other books.
+func (c *Client) AddBook(ctx context.Context, id string) (Book, error) {
+	var books []Book
+	if err := c.lookup(ctx, "readarr:"+id, &books); err != nil {
+		return Book{}, err
+	}
+	if len(books) == 0 {
+		return Book{}, fmt.Errorf("no book with ID %s", id)
+	}
+
+	book := books[0]
+	book.Monitored = true
+	book.AnyEditionOk = true
+	for i := range book.Editions {
+		book.Editions[i].Monitored = true
+	}
+	book.AddOptions = &struct {
+		SearchForNewBook bool `json:"searchForNewBook"`
+	}{SearchForNewBook: true}
+
+	book.Author.QualityProfileID = c.qualityProfileID
+	book.Author.MetadataProfileID = c.metadataProfileID
+	book.Author.RootFolderPath = c.rootFolderPath
+	book.Author.Monitored = true
+	book.Author.AddOptions = &struct {
+		Monitor               string `json:"monitor"`
+		SearchForMissingBooks bool   `json:"searchForMissingBooks"`
+	}{Monitor: "none"}
+
+	input, err := json.Marshal(book)
+	if err != nil {
+		return Book{}, err
+	}
+
+	body, err := c.do(ctx, "POST", fmt.Sprintf("book?apikey=%s", c.token), input)
+	if err != nil {
+		return Book{}, err
+	}
+
+	var added Book
+	if err := json.Unmarshal(body, &added); err != nil {
+		return Book{}, err
+	}
+	return added, nil
+}
+
+// Queue returns the books being downloaded.
+func (c *Client) Queue(ctx context.Context) ([]media.QueueItem, error) {
+	query := url.Values{
+		"page":          {"1"},
+		"pageSize":      {strconv.Itoa(queueSize)},
+		"includeAuthor": {"true"},
+		"includeBook":   {"true"},
+		"apikey":        {c.token},
+	}
+	body, err := c.do(ctx, "GET", fmt.Sprintf("queue?%s", query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var q queue
+	if err := json.Unmarshal(body, &q); err != nil {
+		return nil, err
+	}
+
+	var items []media.QueueItem
+	for _, record := range q.Records {
+		status := record.Status
+		if record.TrackedDownloadStatus != "" && record.TrackedDownloadStatus != "ok" {
+			status = fmt.Sprintf("%s (%s)", status, record.TrackedDownloadStatus)
+		}
+		items = append(items, media.QueueItem{
+			Title:    fmt.Sprintf("%s - %s", record.Author.AuthorName, record.Book.Title),
+			Release:  record.Title,
+			Status:   status,
+			Protocol: record.Protocol,
+			Size:     record.Size,
+			SizeLeft: record.Sizeleft,
+			TimeLeft: media.ParseTimeLeft(record.Timeleft),
+		})
+	}
+
+	return items, nil
+}
+
+func (c *Client) lookup(ctx context.Context, term string, v interface{}) error {
+	query := url.Values{
+		"term":   {term},
+		"apikey": {c.token},
+	}
+	body, err := c.do(ctx, "GET", fmt.Sprintf("book/lookup?%s", query.Encode()), nil)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, input []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/%s", c.baseURL, path), bytes.NewBuffer(input))
+	if err != nil {
+		return nil, err
+	}
+
+	if input != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req = req.WithContext(ctx)
+	response, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("readarr returned %s for %s %s", response.Status, method, strings.SplitN(path, "?", 2)[0])
+	}
+
+	return body, nil
+}
@@ -0,0 +1,200 @@
+package readarr
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"warezbot/media"
+)
+
+const lookupResponse = `[{
+	"title": "Dune",
+	"foreignBookId": "44767458",
+	"overview": "Set on the desert planet Arrakis.",
+	"releaseDate": "1965-08-01T00:00:00Z",
+	"images": [{"coverType": "cover", "url": "/MediaCover/1.jpg", "remoteUrl": "https://covers.example/dune.jpg"}],
+	"author": {"authorName": "Frank Herbert", "foreignAuthorId": "58"},
+	"editions": [
+		{"foreignEditionId": "1", "title": "Dune", "isEbook": true},
+		{"foreignEditionId": "2", "title": "Dune", "format": "Audiobook"}
+	]
+}]`
+
+func newTestClient(t *testing.T, kind string, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c, err := NewClient(server.URL, "secret", kind, 2, 3, "/books/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestSearch(t *testing.T) {
+	c := newTestClient(t, media.Audiobook, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/book/lookup" {
+			t.Errorf("path = %s, want /book/lookup", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("term"); got != "dune herbert" {
+			t.Errorf("term = %q, want %q", got, "dune herbert")
+		}
+		if got := r.URL.Query().Get("apikey"); got != "secret" {
+			t.Errorf("apikey = %q, want secret", got)
+		}
+		w.Write([]byte(lookupResponse))
+	})
+
+	items, err := c.Search(context.Background(), []string{"dune", "herbert"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("got %d items, want 1", len(items))
+	}
+	want := media.Item{
+		ID:       "44767458",
+		Kind:     media.Audiobook,
+		Title:    "Dune",
+		Year:     1965,
+		Overview: "Set on the desert planet Arrakis.",
+		ImageURL: "https://covers.example/dune.jpg",
+		Creator:  "Frank Herbert",
+	}
+	if items[0] != want {
+		t.Errorf("item = %+v, want %+v", items[0], want)
+	}
+}
+
+func TestAddBook(t *testing.T) {
+	var added Book
+	c := newTestClient(t, media.Book, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/book/lookup":
+			if got := r.URL.Query().Get("term"); got != "readarr:44767458" {
+				t.Errorf("term = %q, want readarr:44767458", got)
+			}
+			w.Write([]byte(lookupResponse))
+		case r.Method == "POST" && r.URL.Path == "/book":
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(body, &added); err != nil {
+				t.Fatal(err)
+			}
+			w.Write([]byte(`{"id": 7, "title": "Dune", "foreignBookId": "44767458", "author": {"authorName": "Frank Herbert"}}`))
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	book, err := c.AddBook(context.Background(), "44767458")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.ID != 7 {
+		t.Errorf("ID = %d, want 7", book.ID)
+	}
+
+	if !added.Monitored || !added.AnyEditionOk {
+		t.Errorf("book monitored = %v, any edition = %v, want both set", added.Monitored, added.AnyEditionOk)
+	}
+	if added.AddOptions == nil || !added.AddOptions.SearchForNewBook {
+		t.Errorf("book add options = %+v, want a search for the book", added.AddOptions)
+	}
+	for _, e := range added.Editions {
+		if !e.Monitored {
+			t.Errorf("edition %s is not monitored", e.ForeignEditionID)
+		}
+	}
+
+	author := added.Author
+	if author.QualityProfileID != 2 || author.MetadataProfileID != 3 || author.RootFolderPath != "/books/" {
+		t.Errorf("author profiles = %d, %d, folder = %q, want 2, 3, /books/", author.QualityProfileID, author.MetadataProfileID, author.RootFolderPath)
+	}
+	if !author.Monitored {
+		t.Error("author is not monitored")
+	}
+	if author.AddOptions == nil || author.AddOptions.Monitor != "none" || author.AddOptions.SearchForMissingBooks {
+		t.Errorf("author add options = %+v, want only this book monitored", author.AddOptions)
+	}
+}
+
+func TestAddBookNotFound(t *testing.T) {
+	c := newTestClient(t, media.Book, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`[]`))
+	})
+
+	if _, err := c.AddBook(context.Background(), "1"); err == nil {
+		t.Error("AddBook of an unknown book succeeded")
+	}
+}
+
+func TestQueue(t *testing.T) {
+	c := newTestClient(t, media.Book, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/queue" {
+			t.Errorf("path = %s, want /queue", r.URL.Path)
+		}
+		if r.URL.Query().Get("includeBook") != "true" || r.URL.Query().Get("includeAuthor") != "true" {
+			t.Errorf("query = %s, want the book and author included", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"records": [
+			{"author": {"authorName": "Frank Herbert"}, "book": {"title": "Dune"}, "title": "Dune.epub", "size": 100, "sizeleft": 25, "timeleft": "1.02:03:04", "status": "downloading", "trackedDownloadStatus": "ok", "protocol": "torrent"},
+			{"author": {"authorName": "Ursula K. Le Guin"}, "book": {"title": "Earthsea"}, "title": "Earthsea.m4b", "size": 50, "sizeleft": 50, "timeleft": "00:10:00", "status": "queued", "trackedDownloadStatus": "warning", "protocol": "usenet"}
+		]}`))
+	})
+
+	items, err := c.Queue(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []media.QueueItem{
+		{
+			Title:    "Frank Herbert - Dune",
+			Release:  "Dune.epub",
+			Status:   "downloading",
+			Protocol: "torrent",
+			Size:     100,
+			SizeLeft: 25,
+			TimeLeft: 26*time.Hour + 3*time.Minute + 4*time.Second,
+		},
+		{
+			Title:    "Ursula K. Le Guin - Earthsea",
+			Release:  "Earthsea.m4b",
+			Status:   "queued (warning)",
+			Protocol: "usenet",
+			Size:     50,
+			SizeLeft: 50,
+			TimeLeft: 10 * time.Minute,
+		},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d", len(items), len(want))
+	}
+	for i := range want {
+		if items[i] != want[i] {
+			t.Errorf("item %d = %+v, want %+v", i, items[i], want[i])
+		}
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	c := newTestClient(t, media.Book, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	if _, err := c.Queue(context.Background()); err == nil {
+		t.Error("Queue succeeded on a 401")
+	}
+}
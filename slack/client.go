@@ -6,35 +6,62 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/nlopes/slack"
 
 	"warezbot/emby"
-	"warezbot/radarr"
+	"warezbot/media"
 )
 
-var (
-	title      string
-	titleValue string
-	playStatus string
-	footer     string
-	imageURL   string
-	image404   = "https://www.howtogeek.com/wp-content/uploads/2018/05/2018-06-03-2.png"
+var image404 = "https://www.howtogeek.com/wp-content/uploads/2018/05/2018-06-03-2.png"
+
+const (
+	httpTimeout = 10 * time.Second
 )
 
+// idNames says what the IDs of items of each kind are.
+var idNames = map[string]string{
+	media.Movie:     "TMDB ID",
+	media.Artist:    "MusicBrainz ID",
+	media.Album:     "MusicBrainz ID",
+	media.Book:      "Readarr ID",
+	media.Audiobook: "Readarr ID",
+}
+
+// DownloadCallback is the callback ID of the prompt to pick an item of the
+// kind to download.
+func DownloadCallback(kind string) string {
+	return kind + "DownloadPrompt"
+}
+
 type Client struct {
-	channel string
-	botID   string
-	client  *slack.Client
+	channel      string
+	adminChannel string
+	botID        string
+	token        string
+	client       *slack.Client
+	http         http.Client
 }
 
-func NewClient(token string, channel string, botID string) (*Client, error) {
+// NewClient creates a slack client posting to channel. Messages meant for
+// admins go to adminChannel, or to channel if it is empty.
+func NewClient(token string, channel string, adminChannel string, botID string) (*Client, error) {
+	if adminChannel == "" {
+		adminChannel = channel
+	}
+
 	return &Client{
-		channel: channel,
-		botID:   botID,
-		client:  slack.New(token),
+		channel:      channel,
+		adminChannel: adminChannel,
+		botID:        botID,
+		token:        token,
+		client:       slack.New(token),
+		http: http.Client{
+			Timeout: httpTimeout,
+		},
 	}, nil
 }
 
@@ -49,6 +76,15 @@ func (s *Client) MsgUpdate(ctx context.Context, ts string, name string, title st
 }
 
 func (s *Client) PostEmbySearch(ctx context.Context, results emby.SearchResults) {
+	for _, attachment := range EmbySearchAttachments(results) {
+		s.PostMessage(slack.MsgOptionText("", false), slack.MsgOptionAttachments(attachment))
+	}
+}
+
+// EmbySearchAttachments renders the movies and shows found, followed by how
+// many were found.
+func EmbySearchAttachments(results emby.SearchResults) []slack.Attachment {
+	var attachments []slack.Attachment
 	var count int
 	for _, result := range results.SearchHints {
 		if result.Type == "Movie" || result.Type == "Episode" || result.Type == "Series" {
@@ -61,7 +97,7 @@ func (s *Client) PostEmbySearch(ctx context.Context, results emby.SearchResults)
 				image = image404
 			}
 
-			attachment := slack.Attachment{
+			attachments = append(attachments, slack.Attachment{
 				Color:      makeHexColor(),
 				CallbackID: "embySearchResult",
 				Footer:     result.ItemDetail.Overview,
@@ -72,12 +108,11 @@ func (s *Client) PostEmbySearch(ctx context.Context, results emby.SearchResults)
 						Value: strconv.Itoa(result.ProductionYear),
 					},
 				},
-			}
-			s.PostMessage(slack.MsgOptionText("", false), slack.MsgOptionAttachments(attachment))
+			})
 		}
 	}
 
-	attachment := slack.Attachment{
+	return append(attachments, slack.Attachment{
 		Color:    makeHexColor(),
 		ImageURL: "",
 		Fields: []slack.AttachmentField{
@@ -86,55 +121,97 @@ func (s *Client) PostEmbySearch(ctx context.Context, results emby.SearchResults)
 				Value: strconv.Itoa(count),
 			},
 		},
+	})
+}
+
+func (s *Client) PostSearch(ctx context.Context, kind string, items media.Items) {
+	results, prompt := SearchAttachments(kind, items)
+	for _, attachment := range results {
+		s.PostMessage(slack.MsgOptionText("", false), slack.MsgOptionAttachments(attachment))
 	}
-	s.PostMessage(slack.MsgOptionText("", false), slack.MsgOptionAttachments(attachment))
+	s.PostMessage(slack.MsgOptionText("", false), slack.MsgOptionAttachments(prompt))
 }
 
-func (s *Client) PostSearch(ctx context.Context, movies radarr.Movies) {
+// SearchAttachments renders the items found and the prompt to pick one to
+// download. The name of each button is the ID of its item.
+func SearchAttachments(kind string, items media.Items) ([]slack.Attachment, slack.Attachment) {
+	var attachments []slack.Attachment
 	var attachmentActions []slack.AttachmentAction
-	// Only post the first 5 movies in the search
-	if len(movies) > 5 {
-		movies = movies[:5]
+	// Only post the first 5 items in the search
+	if len(items) > 5 {
+		items = items[:5]
 	}
-	for i, movie := range movies {
+	for i, item := range items {
 		i++
+		name := item.Title
+		if item.Creator != "" {
+			name = fmt.Sprintf("%s by %s", item.Title, item.Creator)
+		}
 		attachmentActions = append(attachmentActions, slack.AttachmentAction{
-			Name:  strconv.Itoa(movie.TmdbID),
+			Name:  item.ID,
 			Type:  "button",
-			Text:  fmt.Sprintf("%d.) %s - %d", i, movie.Title, movie.Year),
-			Value: fmt.Sprintf("%d.) %s - %d", i, movie.Title, movie.Year),
+			Text:  fmt.Sprintf("%d.) %s - %d", i, name, item.Year),
+			Value: fmt.Sprintf("%d.) %s - %d", i, name, item.Year),
 			Confirm: &slack.ConfirmationField{
-				Text: fmt.Sprintf("Are you sure you want to download %s (%s)?", movie.Title, strconv.Itoa(movie.Year)),
+				Text: fmt.Sprintf("Are you sure you want to download %s (%s)?", name, strconv.Itoa(item.Year)),
 			},
 		})
+
+		image := item.ImageURL
+		if image == "" {
+			image = image404
+		}
 		attachment := slack.Attachment{
 			Color:      makeHexColor(),
-			CallbackID: "movieSearchResult",
-			Text:       fmt.Sprintf("TMDB ID: %s", strconv.Itoa(movie.TmdbID)),
-			ImageURL:   movie.Images[0].URL,
-			Footer:     movie.Overview,
+			AuthorName: item.Creator,
+			CallbackID: kind + "SearchResult",
+			Text:       fmt.Sprintf("%s: %s", idNames[kind], item.ID),
+			ImageURL:   image,
+			Footer:     item.Overview,
 			Fields: []slack.AttachmentField{
 				{
-					Title: fmt.Sprintf("%d.) %s", i, movie.Title),
-					Value: strconv.Itoa(movie.Year),
+					Title: fmt.Sprintf("%d.) %s", i, name),
+					Value: strconv.Itoa(item.Year),
 				},
 			},
 		}
-		s.PostMessage(slack.MsgOptionText("", false), slack.MsgOptionAttachments(attachment))
+		// Movies can be requested with a quality profile and root folder
+		// of the user's choosing.
+		if kind == media.Movie {
+			attachment.CallbackID = RequestModalCallback
+			attachment.Actions = []slack.AttachmentAction{
+				{
+					Name:  item.ID,
+					Type:  "button",
+					Text:  "Request…",
+					Value: fmt.Sprintf("%s (%d)", name, item.Year),
+				},
+			}
+		}
+		attachments = append(attachments, attachment)
 	}
 
-	attachment := slack.Attachment{
+	prompt := slack.Attachment{
 		Color:      makeHexColor(),
-		Text:       "Select movie to download",
-		CallbackID: "movieDownloadPrompt",
+		Text:       fmt.Sprintf("Select %s to download", kind),
+		CallbackID: DownloadCallback(kind),
 		Actions:    attachmentActions,
 	}
-	s.PostMessage(slack.MsgOptionText("", false), slack.MsgOptionAttachments(attachment))
+	return attachments, prompt
 }
 
 func (s *Client) NowPlaying(sessions emby.Sessions) {
+	for _, attachment := range NowPlayingAttachments(sessions) {
+		s.PostMessage(slack.MsgOptionText("", false), slack.MsgOptionAttachments(attachment))
+	}
+}
+
+// NowPlayingAttachments renders a card for every session playing something.
+func NowPlayingAttachments(sessions emby.Sessions) []slack.Attachment {
+	var attachments []slack.Attachment
 	for _, ses := range sessions {
 		if ses.NowPlayingItem.Name != "" {
+			var title, titleValue, playStatus, footer, imageURL string
 			if ses.NowPlayingItem.Type == "Episode" {
 				title = fmt.Sprintf("%s is playing the TV show:", ses.UserName)
 				titleValue = fmt.Sprintf("%s - %s (Season %d - %d)", ses.NowPlayingItem.SeriesName,
@@ -164,7 +241,7 @@ func (s *Client) NowPlaying(sessions emby.Sessions) {
 				imageURL = image404
 			}
 
-			attachment := slack.Attachment{
+			attachments = append(attachments, slack.Attachment{
 				Text:       fmt.Sprintf("%s - %g%%", playStatus, percentComplete(ses.PlayState.PositionTicks, ses.NowPlayingItem.RunTimeTicks)),
 				ImageURL:   imageURL,
 				Color:      makeHexColor(),
@@ -177,10 +254,10 @@ func (s *Client) NowPlaying(sessions emby.Sessions) {
 						Value: titleValue,
 					},
 				},
-			}
-			s.PostMessage(slack.MsgOptionText("", false), slack.MsgOptionAttachments(attachment))
+			})
 		}
 	}
+	return attachments
 }
 
 func (s *Client) Ping() {
@@ -196,6 +273,54 @@ func (s *Client) Ping() {
 	}
 }
 
+// PostEphemeral posts a message in the channel that is only visible to the
+// given user.
+func (s *Client) PostEphemeral(channel string, user string, text string) error {
+	_, err := s.client.PostEphemeral(channel, user, slack.MsgOptionText(text, false))
+	return err
+}
+
+// PostDirect sends a direct message to the given user.
+func (s *Client) PostDirect(user string, text string) error {
+	_, _, channel, err := s.client.OpenIMChannel(user)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.client.PostMessage(channel, slack.MsgOptionText(text, false))
+	return err
+}
+
+func (s *Client) PostUsers(users emby.Users) {
+	var fields []slack.AttachmentField
+	for _, user := range users {
+		status := "Enabled"
+		if user.Policy.IsDisabled {
+			status = "Disabled"
+		}
+		if user.Policy.IsAdministrator {
+			status += ", Admin"
+		}
+
+		lastActivity := "Never"
+		if !user.LastActivityDate.IsZero() {
+			lastActivity = user.LastActivityDate.Local().Format("2006-01-02 15:04")
+		}
+
+		fields = append(fields, slack.AttachmentField{
+			Title: fmt.Sprintf("%s (%s)", user.Name, status),
+			Value: fmt.Sprintf("Last activity: %s", lastActivity),
+			Short: true,
+		})
+	}
+
+	attachment := slack.Attachment{
+		Color:  makeHexColor(),
+		Text:   fmt.Sprintf("%d Emby users", len(users)),
+		Fields: fields,
+	}
+	s.PostMessage(slack.MsgOptionText("", false), slack.MsgOptionAttachments(attachment))
+}
+
 func (s *Client) PostMessage(options ...slack.MsgOption) (string, string, error) {
 	return s.client.PostMessage(s.channel, options...)
 }
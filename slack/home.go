@@ -0,0 +1,83 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// homeListLimit is how many requests of each status the home tab lists.
+const homeListLimit = 10
+
+// Home is what the App Home tab shows a user.
+type Home struct {
+	// Pending and Completed are the user's requests, one line each.
+	Pending   []string
+	Completed []string
+	// QuotaLeft is how many more titles the user may request this week,
+	// negative if there is no limit.
+	QuotaLeft int
+	// Watching is what the user is playing, one line each.
+	Watching []string
+	// NowPlaying is how many sessions are playing something on the server.
+	NowPlaying int
+}
+
+// PublishHome sets the App Home tab of the user to the dashboard.
+func (s *Client) PublishHome(ctx context.Context, user string, home Home) error {
+	quota := "Unlimited"
+	if home.QuotaLeft >= 0 {
+		quota = fmt.Sprintf("%d left this week", home.QuotaLeft)
+	}
+	playing := "Nothing"
+	if len(home.Watching) > 0 {
+		playing = strings.Join(home.Watching, "\n")
+	}
+
+	blocks := []Block{
+		{
+			Type: "section",
+			Text: markdown("*Your warezbot dashboard*"),
+		},
+		{
+			Type: "section",
+			Fields: []*Text{
+				markdown(fmt.Sprintf("*Requests*\n%s", quota)),
+				markdown(fmt.Sprintf("*Now playing on the server*\n%d", home.NowPlaying)),
+			},
+		},
+		{
+			Type: "section",
+			Text: markdown(fmt.Sprintf("*You are watching*\n%s", playing)),
+		},
+		{Type: "divider"},
+	}
+	blocks = append(blocks,
+		homeList("Pending requests", home.Pending),
+		Block{Type: "divider"},
+		homeList("Available requests", home.Completed),
+		Block{
+			Type:     "context",
+			Elements: []*Text{markdown("Request more with `add movie <title>` in the channel.")},
+		},
+	)
+
+	return s.PublishView(ctx, user, View{
+		Type:   "home",
+		Blocks: blocks,
+	})
+}
+
+func homeList(title string, lines []string) Block {
+	text := "_None_"
+	if len(lines) > homeListLimit {
+		lines = lines[:homeListLimit]
+	}
+	if len(lines) > 0 {
+		text = "• " + strings.Join(lines, "\n• ")
+	}
+	return Block{
+		Type: "section",
+		Text: markdown(fmt.Sprintf("*%s*\n%s", title, text)),
+	}
+}
@@ -0,0 +1,106 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nlopes/slack"
+)
+
+const (
+	InvitePromptCallback   = "embyInvitePrompt"
+	InviteRequestCallback  = "embyInviteRequest"
+	InviteApprovalCallback = "embyInviteApproval"
+
+	InviteUsernameBlock  = "username"
+	InviteUsernameAction = "username"
+
+	InviteApprove = "approve"
+	InviteDeny    = "deny"
+)
+
+// PostInvitePrompt shows the user a button that opens the invite modal. Slack
+// only lets us open a modal in response to an interaction, hence the extra
+// click.
+func (s *Client) PostInvitePrompt(channel string, user string) error {
+	attachment := slack.Attachment{
+		Color:      makeHexColor(),
+		Text:       "Want your own Emby account? Pick a username and an admin will approve it.",
+		CallbackID: InvitePromptCallback,
+		Actions: []slack.AttachmentAction{
+			{
+				Name:  "open",
+				Type:  "button",
+				Text:  "Request an account",
+				Style: "primary",
+			},
+		},
+	}
+	_, err := s.client.PostEphemeral(channel, user, slack.MsgOptionAttachments(attachment))
+	return err
+}
+
+func (s *Client) OpenInviteModal(ctx context.Context, triggerID string) error {
+	return s.OpenView(ctx, triggerID, View{
+		Type:       "modal",
+		CallbackID: InviteRequestCallback,
+		Title:      plainText("Emby account"),
+		Submit:     plainText("Request"),
+		Close:      plainText("Cancel"),
+		Blocks: []Block{
+			{
+				Type: "section",
+				Text: markdown("Your request is sent to the admins. Once approved you will get your credentials by DM."),
+			},
+			{
+				Type:    "input",
+				BlockID: InviteUsernameBlock,
+				Label:   plainText("Username"),
+				Hint:    plainText("Letters, digits, dots, dashes and underscores only."),
+				Element: &Element{
+					Type:      "plain_text_input",
+					ActionID:  InviteUsernameAction,
+					MaxLength: 32,
+				},
+			},
+		},
+	})
+}
+
+// PostInviteRequest asks the admins to approve or deny an invite.
+func (s *Client) PostInviteRequest(id string, user string, username string) error {
+	attachment := slack.Attachment{
+		Color:      makeHexColor(),
+		Text:       fmt.Sprintf("<@%s> requested the Emby account *%s*", user, username),
+		CallbackID: InviteApprovalCallback,
+		Actions: []slack.AttachmentAction{
+			{
+				Name:  InviteApprove,
+				Type:  "button",
+				Text:  "Approve",
+				Style: "primary",
+				Value: id,
+			},
+			{
+				Name:  InviteDeny,
+				Type:  "button",
+				Text:  "Deny",
+				Style: "danger",
+				Value: id,
+			},
+		},
+	}
+	_, _, err := s.client.PostMessage(s.adminChannel, slack.MsgOptionText("", false), slack.MsgOptionAttachments(attachment))
+	return err
+}
+
+// ResolveInviteRequest replaces the buttons of an invite request with the
+// outcome.
+func (s *Client) ResolveInviteRequest(channel string, ts string, text string) error {
+	attachment := slack.Attachment{
+		Color: makeHexColor(),
+		Text:  text,
+	}
+	_, _, _, err := s.client.UpdateMessage(channel, ts, slack.MsgOptionAttachments(attachment))
+	return err
+}
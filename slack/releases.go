@@ -0,0 +1,80 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nlopes/slack"
+
+	"warezbot/downloader"
+	"warezbot/radarr"
+)
+
+const (
+	ReleaseGrabCallback = "releaseGrab"
+	ReleaseGrab         = "grab"
+)
+
+// PostReleases lists releases of a movie with a button to grab each. The
+// buttons carry the IDs given, one per release.
+func (s *Client) PostReleases(title string, releases []radarr.Release, ids []string) {
+	if len(releases) == 0 {
+		s.PostMessage(slack.MsgOptionText(fmt.Sprintf("No releases of %s found.", title), false))
+		return
+	}
+
+	var attachments []slack.Attachment
+	for i, r := range releases {
+		seeders := "-"
+		if r.Protocol == "torrent" {
+			seeders = fmt.Sprintf("%d (%d leechers)", r.Seeders, r.Leechers)
+		}
+		footer := r.Protocol
+		if len(r.Rejections) > 0 {
+			footer = fmt.Sprintf("%s, rejected by Radarr: %s", r.Protocol, strings.Join(r.Rejections, "; "))
+		}
+
+		attachments = append(attachments, slack.Attachment{
+			Color:      makeHexColor(),
+			Title:      r.Title,
+			CallbackID: ReleaseGrabCallback,
+			Footer:     footer,
+			Fields: []slack.AttachmentField{
+				{
+					Title: "Size",
+					Value: downloader.FormatBytes(r.Size),
+					Short: true,
+				},
+				{
+					Title: "Seeders",
+					Value: seeders,
+					Short: true,
+				},
+				{
+					Title: "Quality",
+					Value: r.Quality,
+					Short: true,
+				},
+				{
+					Title: "Indexer",
+					Value: r.Indexer,
+					Short: true,
+				},
+			},
+			Actions: []slack.AttachmentAction{
+				{
+					Name:  ReleaseGrab,
+					Type:  "button",
+					Text:  "Grab",
+					Style: "primary",
+					Value: ids[i],
+					Confirm: &slack.ConfirmationField{
+						Text: fmt.Sprintf("Send %s to Radarr?", r.Title),
+					},
+				},
+			},
+		})
+	}
+
+	s.PostMessage(slack.MsgOptionText(fmt.Sprintf("Releases of %s", title), false), slack.MsgOptionAttachments(attachments...))
+}
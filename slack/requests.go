@@ -0,0 +1,128 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// RequestModalCallback is the callback ID of the buttons opening the
+	// request modal, RequestSubmitCallback the one of the modal.
+	RequestModalCallback  = "requestModalPrompt"
+	RequestSubmitCallback = "requestModal"
+
+	RequestProfileBlock  = "profile"
+	RequestProfileAction = "profile"
+	RequestFolderBlock   = "folder"
+	RequestFolderAction  = "folder"
+	RequestMonitorBlock  = "monitor"
+	RequestMonitorAction = "monitor"
+	RequestNoteBlock     = "note"
+	RequestNoteAction    = "note"
+
+	// RequestMonitored is the value of the monitor checkbox.
+	RequestMonitored = "monitored"
+)
+
+// Choice is an option of a select in the request modal.
+type Choice struct {
+	Name  string
+	Value string
+}
+
+// RequestModal is a title to request with options.
+type RequestModal struct {
+	Kind  string `json:"kind"`
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	// Channel is where the search was posted, for the outcome to go.
+	Channel string `json:"channel"`
+
+	Profiles []Choice `json:"-"`
+	Folders  []Choice `json:"-"`
+}
+
+// ParseRequestModal returns the title a submitted request modal was for.
+func ParseRequestModal(metadata string) (RequestModal, error) {
+	var m RequestModal
+	if err := json.Unmarshal([]byte(metadata), &m); err != nil {
+		return RequestModal{}, fmt.Errorf("invalid request modal metadata: %v", err)
+	}
+	return m, nil
+}
+
+// OpenRequestModal asks how the title should be requested.
+func (s *Client) OpenRequestModal(ctx context.Context, triggerID string, m RequestModal) error {
+	metadata, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	monitored := &Option{Text: plainText("Monitor for better releases"), Value: RequestMonitored}
+	return s.OpenView(ctx, triggerID, View{
+		Type:            "modal",
+		CallbackID:      RequestSubmitCallback,
+		PrivateMetadata: string(metadata),
+		Title:           plainText("Request"),
+		Submit:          plainText("Request"),
+		Close:           plainText("Cancel"),
+		Blocks: []Block{
+			{
+				Type: "section",
+				Text: markdown(fmt.Sprintf("*%s*", m.Title)),
+			},
+			{
+				Type:    "input",
+				BlockID: RequestProfileBlock,
+				Label:   plainText("Quality profile"),
+				Element: choiceSelect(RequestProfileAction, m.Profiles),
+			},
+			{
+				Type:    "input",
+				BlockID: RequestFolderBlock,
+				Label:   plainText("Root folder"),
+				Element: choiceSelect(RequestFolderAction, m.Folders),
+			},
+			{
+				Type:     "input",
+				BlockID:  RequestMonitorBlock,
+				Label:    plainText("Monitoring"),
+				Optional: true,
+				Element: &Element{
+					Type:           "checkboxes",
+					ActionID:       RequestMonitorAction,
+					Options:        []*Option{monitored},
+					InitialOptions: []*Option{monitored},
+				},
+			},
+			{
+				Type:     "input",
+				BlockID:  RequestNoteBlock,
+				Label:    plainText("Note for the admins"),
+				Optional: true,
+				Element: &Element{
+					Type:      "plain_text_input",
+					ActionID:  RequestNoteAction,
+					Multiline: true,
+					MaxLength: 500,
+				},
+			},
+		},
+	})
+}
+
+// choiceSelect is a select of the choices, the first one picked.
+func choiceSelect(actionID string, choices []Choice) *Element {
+	e := &Element{
+		Type:     "static_select",
+		ActionID: actionID,
+	}
+	for _, c := range choices {
+		e.Options = append(e.Options, &Option{Text: plainText(c.Name), Value: c.Value})
+	}
+	if len(e.Options) > 0 {
+		e.InitialOption = e.Options[0]
+	}
+	return e
+}
@@ -0,0 +1,35 @@
+package slack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// maxRequestAge is how old a request may be before its signature is no longer
+// accepted, so captured requests can't be replayed later.
+const maxRequestAge = 5 * time.Minute
+
+// VerifyRequest tells whether body was sent by Slack at timestamp, checking
+// the signature Slack computed with the signing secret of the app.
+func VerifyRequest(signingSecret string, signature string, timestamp string, body []byte, now time.Time) bool {
+	if signingSecret == "" {
+		return false
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := now.Sub(time.Unix(ts, 0)); age > maxRequestAge || age < -maxRequestAge {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(signingSecret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(signature), []byte(expected))
+}
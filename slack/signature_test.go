@@ -0,0 +1,46 @@
+package slack
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifyRequest(t *testing.T) {
+	// The example from Slack's documentation on verifying requests.
+	const (
+		secret    = "8f742231b10e8888abcd99yyyzzz85a5"
+		timestamp = "1531420618"
+		signature = "v0=a2114d57b48eac39b9ad189dd8316235a7b4a8d21a10bd27519666489c69b503"
+		body      = "token=xyzz0WbapA4vBCDEFasx0q6G&team_id=T1DC2JH3J&team_domain=testteamnow&channel_id=G8PSS9T3V&channel_name=foobar&user_id=U2CERLKJA&user_name=roadrunner&command=%2Fwebhook-collect&text=&response_url=https%3A%2F%2Fhooks.slack.com%2Fcommands%2FT1DC2JH3J%2F397700885554%2F96rGlfmibIGlgcZRskXaIFfN&trigger_id=398738663015.47445629121.803a0bc887a14d10d2c447fce8b6703c"
+	)
+	sent, _ := strconv.ParseInt(timestamp, 10, 64)
+	now := time.Unix(sent, 0).Add(time.Minute)
+
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		timestamp string
+		body      string
+		now       time.Time
+		want      bool
+	}{
+		{"valid", secret, signature, timestamp, body, now, true},
+		{"tampered body", secret, signature, timestamp, body + "&admin=1", now, false},
+		{"wrong secret", "other", signature, timestamp, body, now, false},
+		{"no secret", "", signature, timestamp, body, now, false},
+		{"no signature", secret, "", timestamp, body, now, false},
+		{"bad timestamp", secret, signature, "soon", body, now, false},
+		{"too old", secret, signature, timestamp, body, now.Add(5 * time.Minute), false},
+		{"from the future", secret, signature, timestamp, body, time.Unix(sent, 0).Add(-6 * time.Minute), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := VerifyRequest(tt.secret, tt.signature, tt.timestamp, []byte(tt.body), tt.now)
+			if got != tt.want {
+				t.Errorf("VerifyRequest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
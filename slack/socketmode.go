@@ -0,0 +1,218 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	connectionsOpenURL = "https://slack.com/api/apps.connections.open"
+
+	minBackoff = time.Second
+	maxBackoff = 2 * time.Minute
+	// pingInterval keeps the connection from looking idle to proxies.
+	pingInterval = 30 * time.Second
+	// readTimeout is how long the connection may go without a message or
+	// an answer to a ping before it is considered dead.
+	readTimeout  = 2 * pingInterval
+	writeTimeout = 10 * time.Second
+)
+
+// Envelope types carrying what would otherwise be posted to the HTTP
+// endpoints.
+const (
+	EnvelopeEvents      = "events_api"
+	EnvelopeInteractive = "interactive"
+)
+
+// Envelope is a message sent by Slack over a Socket Mode connection.
+type Envelope struct {
+	EnvelopeID string `json:"envelope_id"`
+	Type       string `json:"type"`
+	// Payload is the event or interaction, as it would be posted to the
+	// HTTP endpoints.
+	Payload      json.RawMessage `json:"payload"`
+	RetryAttempt int             `json:"retry_attempt"`
+	// Reason says why Slack is about to close the connection, for
+	// disconnect envelopes.
+	Reason string `json:"reason"`
+}
+
+// EnvelopeHandler handles an envelope. What it returns, if not nil, is sent
+// back with the acknowledgement, e.g. the errors of a modal.
+type EnvelopeHandler func(ctx context.Context, e Envelope) (interface{}, error)
+
+// SocketMode receives events and interactions over a WebSocket opened by the
+// bot, so Slack needn't reach it.
+type SocketMode struct {
+	appToken string
+	// openURL is where connections are opened, apps.connections.open.
+	openURL string
+	logger  log.Logger
+	http    http.Client
+}
+
+// NewSocketMode creates a Socket Mode connection with the app-level token,
+// which starts with xapp-.
+func NewSocketMode(appToken string, logger log.Logger) (*SocketMode, error) {
+	if appToken == "" {
+		return nil, fmt.Errorf("socket mode needs an app-level token")
+	}
+	return &SocketMode{
+		appToken: appToken,
+		openURL:  connectionsOpenURL,
+		logger:   logger,
+		http: http.Client{
+			Timeout: httpTimeout,
+		},
+	}, nil
+}
+
+// Run handles the envelopes Slack sends until ctx is done, reconnecting with
+// a growing backoff whenever the connection fails.
+func (s *SocketMode) Run(ctx context.Context, handle EnvelopeHandler) {
+	backoff := minBackoff
+	for {
+		connected, err := s.serve(ctx, handle)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			backoff = minBackoff
+		}
+		if err != nil {
+			level.Error(s.logger).Log("msg", "slack socket mode connection failed", "error", err, "retry", backoff)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// serve handles the envelopes of one connection until it closes. connected
+// reports whether Slack said hello, so the backoff can start over.
+func (s *SocketMode) serve(ctx context.Context, handle EnvelopeHandler) (connected bool, err error) {
+	wsURL, err := s.open(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	// A half-open connection would block reads forever, so they give up
+	// unless Slack keeps answering the pings.
+	conn.SetReadDeadline(time.Now().Add(readTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(readTimeout))
+	})
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(pingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				conn.Close()
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		var e Envelope
+		if err := conn.ReadJSON(&e); err != nil {
+			return connected, err
+		}
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+
+		switch e.Type {
+		case "hello":
+			connected = true
+			level.Info(s.logger).Log("msg", "slack socket mode connected")
+		case "disconnect":
+			// Slack refreshes connections every few hours.
+			level.Info(s.logger).Log("msg", "slack socket mode disconnecting", "reason", e.Reason)
+			return connected, nil
+		default:
+			if e.EnvelopeID == "" {
+				continue
+			}
+			// Slack retries envelopes that aren't acknowledged within 3
+			// seconds, the handlers answer before doing the work.
+			payload, err := handle(ctx, e)
+			if err != nil {
+				level.Error(s.logger).Log("msg", "failed to handle slack envelope", "type", e.Type, "error", err)
+			}
+
+			ack := struct {
+				EnvelopeID string      `json:"envelope_id"`
+				Payload    interface{} `json:"payload,omitempty"`
+			}{e.EnvelopeID, payload}
+			conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := conn.WriteJSON(ack); err != nil {
+				return connected, err
+			}
+		}
+	}
+}
+
+// open asks Slack for the URL of a new connection.
+func (s *SocketMode) open(ctx context.Context) (string, error) {
+	req, err := http.NewRequest("POST", s.openURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.appToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req = req.WithContext(ctx)
+
+	response, err := s.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var r struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+		URL   string `json:"url"`
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return "", fmt.Errorf("slack returned %s for apps.connections.open", response.Status)
+	}
+	if !r.OK {
+		return "", fmt.Errorf("apps.connections.open failed: %s", r.Error)
+	}
+	return r.URL, nil
+}
@@ -0,0 +1,166 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/websocket"
+)
+
+// fakeSlack opens Socket Mode connections. The first one says hello, sends
+// an event and asks the bot to disconnect once it is acknowledged, later ones
+// only say hello.
+type fakeSlack struct {
+	t           *testing.T
+	server      *httptest.Server
+	upgrader    websocket.Upgrader
+	connections int32
+	connected   chan int32
+	acks        chan json.RawMessage
+}
+
+func newFakeSlack(t *testing.T) *fakeSlack {
+	f := &fakeSlack{
+		t:         t,
+		connected: make(chan int32, 4),
+		acks:      make(chan json.RawMessage, 4),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/apps.connections.open", f.open)
+	mux.HandleFunc("/socket", f.socket)
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeSlack) open(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer xapp-test" {
+		fmt.Fprint(w, `{"ok": false, "error": "invalid_auth"}`)
+		return
+	}
+	fmt.Fprintf(w, `{"ok": true, "url": "ws%s/socket"}`, strings.TrimPrefix(f.server.URL, "http"))
+}
+
+func (f *fakeSlack) socket(w http.ResponseWriter, r *http.Request) {
+	conn, err := f.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		f.t.Error(err)
+		return
+	}
+	defer conn.Close()
+	n := atomic.AddInt32(&f.connections, 1)
+	f.connected <- n
+
+	conn.WriteJSON(Envelope{Type: "hello"})
+	if n == 1 {
+		conn.WriteJSON(Envelope{
+			EnvelopeID: "env-1",
+			Type:       EnvelopeEvents,
+			Payload:    json.RawMessage(`{"event": {"type": "message", "text": "hi"}}`),
+		})
+		var ack json.RawMessage
+		if err := conn.ReadJSON(&ack); err != nil {
+			f.t.Error(err)
+			return
+		}
+		f.acks <- ack
+		conn.WriteJSON(Envelope{Type: "disconnect", Reason: "refresh_requested"})
+	}
+	// Wait for the bot to hang up.
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func TestSocketModeRun(t *testing.T) {
+	fake := newFakeSlack(t)
+	s, err := NewSocketMode("xapp-test", log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.openURL = fake.server.URL + "/apps.connections.open"
+
+	handled := make(chan Envelope, 4)
+	handle := func(ctx context.Context, e Envelope) (interface{}, error) {
+		handled <- e
+		return map[string]string{"response_action": "clear"}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx, handle)
+		close(done)
+	}()
+
+	select {
+	case e := <-handled:
+		if e.EnvelopeID != "env-1" || e.Type != EnvelopeEvents || !strings.Contains(string(e.Payload), `"hi"`) {
+			t.Errorf("handled %+v, want the event envelope", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the envelope was not handled")
+	}
+
+	select {
+	case raw := <-fake.acks:
+		var ack struct {
+			EnvelopeID string `json:"envelope_id"`
+			Payload    struct {
+				ResponseAction string `json:"response_action"`
+			} `json:"payload"`
+		}
+		if err := json.Unmarshal(raw, &ack); err != nil {
+			t.Fatal(err)
+		}
+		if ack.EnvelopeID != "env-1" || ack.Payload.ResponseAction != "clear" {
+			t.Errorf("ack = %s, want env-1 with the payload of the handler", raw)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the envelope was not acknowledged")
+	}
+
+	// After the disconnect the bot connects again.
+	deadline := time.After(5 * time.Second)
+	for n := int32(0); n < 2; {
+		select {
+		case n = <-fake.connected:
+		case <-deadline:
+			t.Fatal("the bot did not reconnect")
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return once cancelled")
+	}
+}
+
+func TestSocketModeOpenFailure(t *testing.T) {
+	fake := newFakeSlack(t)
+	s, err := NewSocketMode("xapp-wrong", log.NewNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.openURL = fake.server.URL + "/apps.connections.open"
+
+	connected, err := s.serve(context.Background(), nil)
+	if err == nil || !strings.Contains(err.Error(), "invalid_auth") {
+		t.Errorf("serve error = %v, want invalid_auth", err)
+	}
+	if connected {
+		t.Error("serve reported a connection")
+	}
+}
@@ -0,0 +1,92 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nlopes/slack"
+)
+
+const maxCellWidth = 40
+
+// PostTable posts rows as a fixed width table. Long cells are cut short so
+// the table stays readable on narrow screens.
+func (s *Client) PostTable(title string, header []string, rows [][]string) {
+	s.PostMessage(slack.MsgOptionText("", false), slack.MsgOptionAttachments(TableAttachment(title, header, rows)))
+}
+
+// TableAttachment renders rows as a fixed width table.
+func TableAttachment(title string, header []string, rows [][]string) slack.Attachment {
+	return slack.Attachment{
+		Color:      makeHexColor(),
+		Title:      title,
+		Text:       formatTable(header, rows),
+		MarkdownIn: []string{"text"},
+	}
+}
+
+func formatTable(header []string, rows [][]string) string {
+	if len(rows) == 0 {
+		return "_Nothing to show_"
+	}
+
+	widths := make([]int, len(header))
+	all := append([][]string{header}, rows...)
+	for _, row := range all {
+		for i, cell := range row {
+			if i < len(widths) {
+				if n := utf8.RuneCountInString(truncate(cell)); n > widths[i] {
+					widths[i] = n
+				}
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("```\n")
+	for _, row := range all {
+		var cells []string
+		for i, cell := range row {
+			if i < len(widths) {
+				cells = append(cells, fmt.Sprintf("%-*s", widths[i], truncate(cell)))
+			}
+		}
+		b.WriteString(strings.TrimRight(strings.Join(cells, "  "), " "))
+		b.WriteString("\n")
+	}
+	b.WriteString("```")
+
+	return b.String()
+}
+
+func truncate(cell string) string {
+	if utf8.RuneCountInString(cell) <= maxCellWidth {
+		return cell
+	}
+	return string([]rune(cell)[:maxCellWidth-1]) + "…"
+}
+
+type Stat struct {
+	Name  string
+	Value string
+}
+
+// PostStats posts named values side by side.
+func (s *Client) PostStats(title string, stats []Stat) {
+	var fields []slack.AttachmentField
+	for _, stat := range stats {
+		fields = append(fields, slack.AttachmentField{
+			Title: stat.Name,
+			Value: stat.Value,
+			Short: true,
+		})
+	}
+
+	attachment := slack.Attachment{
+		Color:  makeHexColor(),
+		Title:  title,
+		Fields: fields,
+	}
+	s.PostMessage(slack.MsgOptionText("", false), slack.MsgOptionAttachments(attachment))
+}
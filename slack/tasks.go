@@ -0,0 +1,79 @@
+package slack
+
+import (
+	"fmt"
+
+	"github.com/nlopes/slack"
+
+	"warezbot/emby"
+)
+
+const (
+	TaskRunCallback = "embyTaskRun"
+)
+
+// PostTasks lists the scheduled tasks of Emby, each with a button to run it.
+func (s *Client) PostTasks(tasks emby.ScheduledTasks) {
+	var attachments []slack.Attachment
+	for _, task := range tasks {
+		lastRun := "Never"
+		if result := task.LastExecutionResult; !result.EndTimeUtc.IsZero() {
+			lastRun = fmt.Sprintf("%s (%s)", result.EndTimeUtc.Local().Format("2006-01-02 15:04"), result.Status)
+		}
+
+		status := task.State
+		if task.State == "Running" {
+			status = fmt.Sprintf("Running %.0f%%", task.CurrentProgressPercentage)
+		}
+
+		attachments = append(attachments, slack.Attachment{
+			Color:      makeHexColor(),
+			Title:      task.Name,
+			CallbackID: TaskRunCallback,
+			Footer:     task.Category,
+			Fields: []slack.AttachmentField{
+				{
+					Title: "Status",
+					Value: status,
+					Short: true,
+				},
+				{
+					Title: "Last run",
+					Value: lastRun,
+					Short: true,
+				},
+			},
+			Actions: []slack.AttachmentAction{
+				{
+					Name:  task.Name,
+					Type:  "button",
+					Text:  "Run",
+					Value: task.ID,
+					Confirm: &slack.ConfirmationField{
+						Text: fmt.Sprintf("Run %s now?", task.Name),
+					},
+				},
+			},
+		})
+	}
+
+	s.PostMessage(slack.MsgOptionText("Emby scheduled tasks", false), slack.MsgOptionAttachments(attachments...))
+}
+
+// PostNotice posts a plain message to the channel and returns where it ended
+// up, so that follow-ups can be threaded under it.
+func (s *Client) PostNotice(text string) (string, string, error) {
+	return s.PostMessage(slack.MsgOptionText(text, false))
+}
+
+// PostAdmin posts a plain message to the admin channel.
+func (s *Client) PostAdmin(text string) error {
+	_, _, err := s.client.PostMessage(s.adminChannel, slack.MsgOptionText(text, false))
+	return err
+}
+
+// PostThread replies in the thread of the message posted at ts.
+func (s *Client) PostThread(channel string, ts string, text string) error {
+	_, _, err := s.client.PostMessage(channel, slack.MsgOptionText(text, false), slack.MsgOptionTS(ts))
+	return err
+}
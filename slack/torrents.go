@@ -0,0 +1,92 @@
+package slack
+
+import (
+	"fmt"
+
+	"github.com/nlopes/slack"
+
+	"warezbot/downloader"
+)
+
+const (
+	TorrentCallback = "torrentAction"
+	TorrentPause    = "pause"
+	TorrentResume   = "resume"
+	TorrentRemove   = "remove"
+)
+
+// PostTorrents lists torrents with their progress and buttons to pause,
+// resume or remove each.
+func (s *Client) PostTorrents(torrents []downloader.Torrent) {
+	if len(torrents) == 0 {
+		s.PostMessage(slack.MsgOptionText("No active torrents.", false))
+		return
+	}
+
+	var attachments []slack.Attachment
+	for _, t := range torrents {
+		eta := "-"
+		if t.ETA > 0 {
+			eta = t.ETA.String()
+		}
+		state := t.State
+		if t.Message != "" {
+			state = fmt.Sprintf("%s: %s", t.State, t.Message)
+		}
+
+		toggle := slack.AttachmentAction{
+			Name:  TorrentPause,
+			Type:  "button",
+			Text:  "Pause",
+			Value: t.Hash,
+		}
+		if t.State == downloader.Paused {
+			toggle.Name = TorrentResume
+			toggle.Text = "Resume"
+		}
+
+		attachments = append(attachments, slack.Attachment{
+			Color:      makeHexColor(),
+			Title:      t.Name,
+			CallbackID: TorrentCallback,
+			Footer:     fmt.Sprintf("%s, %d seeds, %d peers", downloader.FormatBytes(t.Size), t.Seeds, t.Peers),
+			Fields: []slack.AttachmentField{
+				{
+					Title: "State",
+					Value: fmt.Sprintf("%s %.1f%%", state, t.Progress*100),
+					Short: true,
+				},
+				{
+					Title: "ETA",
+					Value: eta,
+					Short: true,
+				},
+				{
+					Title: "Speed",
+					Value: fmt.Sprintf("↓ %s/s ↑ %s/s", downloader.FormatBytes(t.DownloadSpeed), downloader.FormatBytes(t.UploadSpeed)),
+					Short: true,
+				},
+				{
+					Title: "Ratio",
+					Value: fmt.Sprintf("%.2f", t.Ratio),
+					Short: true,
+				},
+			},
+			Actions: []slack.AttachmentAction{
+				toggle,
+				{
+					Name:  TorrentRemove,
+					Type:  "button",
+					Text:  "Remove",
+					Style: "danger",
+					Value: t.Hash,
+					Confirm: &slack.ConfirmationField{
+						Text: fmt.Sprintf("Remove %s? Downloaded files are kept.", t.Name),
+					},
+				},
+			},
+		})
+	}
+
+	s.PostMessage(slack.MsgOptionText("Torrents", false), slack.MsgOptionAttachments(attachments...))
+}
@@ -0,0 +1,89 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nlopes/slack"
+
+	"warezbot/media"
+)
+
+// UnfurlRequestCallback is the callback ID of the Request button of unfurled
+// links.
+const UnfurlRequestCallback = "unfurlRequest"
+
+// Unfurl is a link to a movie and what we have of it.
+type Unfurl struct {
+	URL  string
+	Item media.Item
+	// InLibrary is set if the movie is on the media server.
+	InLibrary bool
+	// InManager is set if the movie was added to Radarr, Downloaded once it
+	// has been downloaded.
+	InManager  bool
+	Downloaded bool
+}
+
+// Unfurl replaces the previews of the links in the message with the movies
+// they link to.
+func (s *Client) Unfurl(ctx context.Context, channel string, ts string, unfurls []Unfurl) error {
+	attachments := make(map[string]slack.Attachment, len(unfurls))
+	for _, u := range unfurls {
+		attachments[u.URL] = unfurlAttachment(u)
+	}
+
+	return s.callAPI(ctx, "chat.unfurl", struct {
+		Channel string                      `json:"channel"`
+		TS      string                      `json:"ts"`
+		Unfurls map[string]slack.Attachment `json:"unfurls"`
+	}{
+		Channel: channel,
+		TS:      ts,
+		Unfurls: attachments,
+	})
+}
+
+func unfurlAttachment(u Unfurl) slack.Attachment {
+	title := u.Item.Title
+	if u.Item.Year > 0 {
+		title = fmt.Sprintf("%s (%d)", u.Item.Title, u.Item.Year)
+	}
+
+	library := "Not in the library"
+	if u.InLibrary {
+		library = "Available"
+	}
+	manager := "Not requested"
+	switch {
+	case u.Downloaded:
+		manager = "Downloaded"
+	case u.InManager:
+		manager = "Requested, not downloaded yet"
+	}
+
+	attachment := slack.Attachment{
+		Color:      makeHexColor(),
+		Title:      title,
+		TitleLink:  u.URL,
+		Text:       u.Item.Overview,
+		ThumbURL:   u.Item.ImageURL,
+		CallbackID: UnfurlRequestCallback,
+		Fields: []slack.AttachmentField{
+			{Title: "Emby", Value: library, Short: true},
+			{Title: "Radarr", Value: manager, Short: true},
+		},
+	}
+	if !u.InLibrary && !u.InManager {
+		attachment.Actions = []slack.AttachmentAction{
+			{
+				Name:  u.Item.ID,
+				Type:  "button",
+				Text:  "Request",
+				Style: "primary",
+				Value: title,
+			},
+		}
+	}
+	return attachment
+}
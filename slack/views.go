@@ -0,0 +1,130 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/nlopes/slack"
+)
+
+// The Block Kit surfaces (modals and the App Home tab) are not covered by the
+// slack library we use, so the views API is called directly with the types
+// below.
+
+type View struct {
+	Type            string  `json:"type"`
+	CallbackID      string  `json:"callback_id,omitempty"`
+	PrivateMetadata string  `json:"private_metadata,omitempty"`
+	Title           *Text   `json:"title,omitempty"`
+	Submit          *Text   `json:"submit,omitempty"`
+	Close           *Text   `json:"close,omitempty"`
+	Blocks          []Block `json:"blocks"`
+}
+
+type Text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type Block struct {
+	Type     string   `json:"type"`
+	BlockID  string   `json:"block_id,omitempty"`
+	Text     *Text    `json:"text,omitempty"`
+	Label    *Text    `json:"label,omitempty"`
+	Hint     *Text    `json:"hint,omitempty"`
+	Element  *Element `json:"element,omitempty"`
+	Optional bool     `json:"optional,omitempty"`
+	// Fields are the columns of a section.
+	Fields []*Text `json:"fields,omitempty"`
+	// Elements are the texts of a context block.
+	Elements []*Text `json:"elements,omitempty"`
+}
+
+type Element struct {
+	Type         string `json:"type"`
+	ActionID     string `json:"action_id,omitempty"`
+	Placeholder  *Text  `json:"placeholder,omitempty"`
+	InitialValue string `json:"initial_value,omitempty"`
+	Multiline    bool   `json:"multiline,omitempty"`
+	MaxLength    int    `json:"max_length,omitempty"`
+	// Options are the choices of selects and checkboxes.
+	Options        []*Option `json:"options,omitempty"`
+	InitialOption  *Option   `json:"initial_option,omitempty"`
+	InitialOptions []*Option `json:"initial_options,omitempty"`
+}
+
+type Option struct {
+	Text  *Text  `json:"text"`
+	Value string `json:"value"`
+}
+
+func plainText(text string) *Text {
+	return &Text{Type: "plain_text", Text: text}
+}
+
+func markdown(text string) *Text {
+	return &Text{Type: "mrkdwn", Text: text}
+}
+
+// OpenView opens a modal in response to the interaction that produced the
+// trigger ID.
+func (s *Client) OpenView(ctx context.Context, triggerID string, view View) error {
+	return s.callAPI(ctx, "views.open", struct {
+		TriggerID string `json:"trigger_id"`
+		View      View   `json:"view"`
+	}{
+		TriggerID: triggerID,
+		View:      view,
+	})
+}
+
+// PublishView sets the App Home tab of the user.
+func (s *Client) PublishView(ctx context.Context, user string, view View) error {
+	return s.callAPI(ctx, "views.publish", struct {
+		UserID string `json:"user_id"`
+		View   View   `json:"view"`
+	}{
+		UserID: user,
+		View:   view,
+	})
+}
+
+func (s *Client) callAPI(ctx context.Context, method string, payload interface{}) error {
+	input, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", slack.APIURL+method, bytes.NewBuffer(input))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req = req.WithContext(ctx)
+
+	response, err := s.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	var r slack.SlackResponse
+	if err := json.Unmarshal(body, &r); err != nil {
+		return fmt.Errorf("failed to decode %s response: %v", method, err)
+	}
+	if !r.Ok {
+		return fmt.Errorf("%s failed: %s", method, r.Error)
+	}
+
+	return nil
+}
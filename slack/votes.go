@@ -0,0 +1,49 @@
+package slack
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nlopes/slack"
+
+	"warezbot/media"
+)
+
+// VoteReaction is the reaction votes are cast with, :+1: in any skin tone.
+const VoteReaction = "+1"
+
+// PostVote posts the movie up for a vote and returns where it ended up, so
+// that reactions to it can be counted.
+func (s *Client) PostVote(item media.Item, threshold int, window time.Duration) (string, string, error) {
+	title := item.Title
+	if item.Year > 0 {
+		title = fmt.Sprintf("%s (%d)", item.Title, item.Year)
+	}
+	thumb := item.ImageURL
+	if thumb == "" {
+		thumb = image404
+	}
+
+	attachment := slack.Attachment{
+		Color:    makeHexColor(),
+		Title:    title,
+		Text:     item.Overview,
+		ThumbURL: thumb,
+		Footer:   fmt.Sprintf("Requested once %d people react with :%s: within %s", threshold, VoteReaction, formatWindow(window)),
+	}
+	return s.PostMessage(slack.MsgOptionText("Should we get this one?", false), slack.MsgOptionAttachments(attachment))
+}
+
+// formatWindow says how long a vote lasts in hours or days.
+func formatWindow(d time.Duration) string {
+	hours := int(d.Hours())
+	switch {
+	case hours%24 == 0 && hours >= 48:
+		return fmt.Sprintf("%d days", hours/24)
+	case hours == 24:
+		return "a day"
+	case hours == 1:
+		return "an hour"
+	}
+	return fmt.Sprintf("%d hours", hours)
+}
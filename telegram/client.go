@@ -0,0 +1,240 @@
+// Package telegram talks to the Telegram Bot API, receiving updates either
+// through a webhook or by long polling.
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	apiURL = "https://api.telegram.org"
+	// PollTimeout is how long Telegram holds a poll open waiting for
+	// updates.
+	PollTimeout = 30 * time.Second
+	// httpTimeout leaves room for the long polling of Updates.
+	httpTimeout = PollTimeout + 10*time.Second
+
+	downloadPrefix = "download:"
+)
+
+type User struct {
+	ID        int64  `json:"id"`
+	FirstName string `json:"first_name"`
+	Username  string `json:"username"`
+}
+
+// Name is how the user is shown to others.
+func (u User) Name() string {
+	if u.Username != "" {
+		return "@" + u.Username
+	}
+	return u.FirstName
+}
+
+type Chat struct {
+	ID int64 `json:"id"`
+}
+
+type Message struct {
+	MessageID int64  `json:"message_id"`
+	From      User   `json:"from"`
+	Chat      Chat   `json:"chat"`
+	Text      string `json:"text"`
+}
+
+// CallbackQuery is the click of an inline keyboard button.
+type CallbackQuery struct {
+	ID      string   `json:"id"`
+	From    User     `json:"from"`
+	Message *Message `json:"message"`
+	Data    string   `json:"data"`
+}
+
+// Update is a message sent to the bot or a click of one of its buttons.
+type Update struct {
+	UpdateID      int64          `json:"update_id"`
+	Message       *Message       `json:"message"`
+	CallbackQuery *CallbackQuery `json:"callback_query"`
+}
+
+type InlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data"`
+}
+
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
+
+type Client struct {
+	token string
+	http  http.Client
+}
+
+func NewClient(token string) (*Client, error) {
+	if token == "" {
+		return nil, fmt.Errorf("telegram needs a bot token")
+	}
+	return &Client{
+		token: token,
+		http: http.Client{
+			Timeout: httpTimeout,
+		},
+	}, nil
+}
+
+// DownloadData is the callback data of the button to download the item of
+// the kind with the ID, e.g. a movie by its TMDB ID.
+func DownloadData(kind string, id string) string {
+	return fmt.Sprintf("%s%s:%s", downloadPrefix, kind, id)
+}
+
+// ParseDownloadData returns the kind and ID of the item of a download button.
+func ParseDownloadData(data string) (string, string, bool) {
+	if !strings.HasPrefix(data, downloadPrefix) {
+		return "", "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(data, downloadPrefix), ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// Command splits a message like /add@warezbot movie Alien into the command
+// and its arguments. ok is false if the message is not a command.
+func Command(text string) (string, []string, bool) {
+	words := strings.Fields(text)
+	if len(words) == 0 || !strings.HasPrefix(words[0], "/") {
+		return "", nil, false
+	}
+	// Commands are addressed to a bot in group chats.
+	name := strings.SplitN(strings.TrimPrefix(words[0], "/"), "@", 2)[0]
+	return strings.ToLower(name), words[1:], true
+}
+
+// SetWebhook has Telegram post updates to the URL instead of waiting to be
+// polled.
+func (c *Client) SetWebhook(ctx context.Context, url string) error {
+	return c.do(ctx, "setWebhook", map[string]interface{}{
+		"url":             url,
+		"allowed_updates": []string{"message", "callback_query"},
+	}, nil)
+}
+
+// DeleteWebhook removes the webhook, which Telegram requires before polling.
+func (c *Client) DeleteWebhook(ctx context.Context) error {
+	return c.do(ctx, "deleteWebhook", struct{}{}, nil)
+}
+
+// Updates returns the updates from offset on, waiting up to timeout for new
+// ones. Updates before offset are confirmed and not returned again.
+func (c *Client) Updates(ctx context.Context, offset int64, timeout time.Duration) ([]Update, error) {
+	var updates []Update
+	err := c.do(ctx, "getUpdates", map[string]interface{}{
+		"offset":          offset,
+		"timeout":         int(timeout.Seconds()),
+		"allowed_updates": []string{"message", "callback_query"},
+	}, &updates)
+	return updates, err
+}
+
+// SendMessage posts the HTML text to the chat, with the keyboard if any.
+func (c *Client) SendMessage(ctx context.Context, chatID int64, text string, keyboard *InlineKeyboardMarkup) error {
+	in := map[string]interface{}{
+		"chat_id":                  chatID,
+		"text":                     text,
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+	}
+	if keyboard != nil {
+		in["reply_markup"] = keyboard
+	}
+	return c.do(ctx, "sendMessage", in, nil)
+}
+
+// SendPhoto posts the photo at the URL with the HTML caption.
+func (c *Client) SendPhoto(ctx context.Context, chatID int64, photoURL string, caption string, keyboard *InlineKeyboardMarkup) error {
+	in := map[string]interface{}{
+		"chat_id":    chatID,
+		"photo":      photoURL,
+		"caption":    caption,
+		"parse_mode": "HTML",
+	}
+	if keyboard != nil {
+		in["reply_markup"] = keyboard
+	}
+	return c.do(ctx, "sendPhoto", in, nil)
+}
+
+// AnswerCallbackQuery stops the spinner of the button clicked, showing text
+// if any.
+func (c *Client) AnswerCallbackQuery(ctx context.Context, id string, text string) error {
+	return c.do(ctx, "answerCallbackQuery", map[string]interface{}{
+		"callback_query_id": id,
+		"text":              text,
+	}, nil)
+}
+
+// RemoveKeyboard removes the buttons from the message.
+func (c *Client) RemoveKeyboard(ctx context.Context, chatID int64, messageID int64) error {
+	return c.do(ctx, "editMessageReplyMarkup", map[string]interface{}{
+		"chat_id":      chatID,
+		"message_id":   messageID,
+		"reply_markup": InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{}},
+	}, nil)
+}
+
+func (c *Client) do(ctx context.Context, method string, in interface{}, out interface{}) error {
+	input, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/bot%s/%s", apiURL, c.token, method), bytes.NewReader(input))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(ctx)
+	response, err := c.http.Do(req)
+	if err != nil {
+		// The URL holds the token, leave it out.
+		if e, ok := err.(*url.Error); ok {
+			err = e.Err
+		}
+		return fmt.Errorf("telegram %s failed: %v", method, err)
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	var r struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return fmt.Errorf("telegram returned %s for %s", response.Status, method)
+	}
+	if !r.OK {
+		return fmt.Errorf("telegram returned %s for %s: %s", response.Status, method, r.Description)
+	}
+	if out != nil {
+		return json.Unmarshal(r.Result, out)
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"warezbot/emby"
+	"warezbot/media"
+)
+
+const (
+	// maxResults is how many search results are posted, one photo each.
+	maxResults = 5
+	// maxText is how long a message may be.
+	maxText    = 4096
+	maxCaption = 1024
+)
+
+// Reply answers in the chat.
+func (c *Client) Reply(ctx context.Context, chatID int64, text string) error {
+	return c.SendMessage(ctx, chatID, html.EscapeString(text), nil)
+}
+
+// PostSearch posts the poster of every item found with a button to download
+// it.
+func (c *Client) PostSearch(ctx context.Context, chatID int64, kind string, items media.Items) error {
+	if len(items) == 0 {
+		return c.Reply(ctx, chatID, fmt.Sprintf("No %s found.", kind))
+	}
+	if len(items) > maxResults {
+		items = items[:maxResults]
+	}
+
+	for _, item := range items {
+		name := item.Title
+		if item.Creator != "" {
+			name = fmt.Sprintf("%s by %s", item.Title, item.Creator)
+		}
+		if item.Year > 0 {
+			name = fmt.Sprintf("%s (%d)", name, item.Year)
+		}
+
+		caption := fmt.Sprintf("<b>%s</b>", html.EscapeString(name))
+		if item.Overview != "" {
+			caption += "\n" + html.EscapeString(truncate(item.Overview, maxCaption-len(caption)-10))
+		}
+		keyboard := &InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{{
+			{Text: "Download", CallbackData: DownloadData(kind, item.ID)},
+		}}}
+
+		var err error
+		if item.ImageURL != "" {
+			err = c.SendPhoto(ctx, chatID, item.ImageURL, caption, keyboard)
+		}
+		// Telegram can't fetch every poster, post the text alone then.
+		if item.ImageURL == "" || err != nil {
+			err = c.SendMessage(ctx, chatID, caption, keyboard)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PostLibrarySearch lists the movies and shows found on the media server.
+func (c *Client) PostLibrarySearch(ctx context.Context, chatID int64, results emby.SearchResults) error {
+	var b strings.Builder
+	var count int
+	for _, result := range results.SearchHints {
+		if result.Type != "Movie" && result.Type != "Episode" && result.Type != "Series" {
+			continue
+		}
+		count++
+
+		name := result.Name
+		if result.ProductionYear > 0 {
+			name = fmt.Sprintf("%s (%d)", name, result.ProductionYear)
+		}
+		fmt.Fprintf(&b, "<b>%s</b> - %s\n", html.EscapeString(name), html.EscapeString(result.Type))
+	}
+	fmt.Fprintf(&b, "Total results found: %d", count)
+
+	return c.SendMessage(ctx, chatID, b.String(), nil)
+}
+
+// NowPlaying lists the sessions playing something.
+func (c *Client) NowPlaying(ctx context.Context, chatID int64, sessions emby.Sessions) error {
+	var b strings.Builder
+	for _, ses := range sessions {
+		item := ses.NowPlayingItem
+		if item.Name == "" {
+			continue
+		}
+
+		title := item.Name
+		if item.Type == "Episode" {
+			title = fmt.Sprintf("%s - %s (Season %d - %d)", item.SeriesName, item.Name, item.ParentIndexNumber, item.IndexNumber)
+		}
+		status := "playing"
+		if ses.PlayState.IsPaused {
+			status = "paused"
+		}
+		var progress float64
+		if item.RunTimeTicks > 0 {
+			progress = math.Round(float64(ses.PlayState.PositionTicks) * 100 / float64(item.RunTimeTicks))
+		}
+
+		fmt.Fprintf(&b, "<b>%s</b> is %s <i>%s</i> on %s - %g%%\n",
+			html.EscapeString(ses.UserName), status, html.EscapeString(title), html.EscapeString(ses.DeviceName), progress)
+	}
+	if b.Len() == 0 {
+		return c.Reply(ctx, chatID, "Nothing is playing.")
+	}
+
+	return c.SendMessage(ctx, chatID, b.String(), nil)
+}
+
+// PostTable posts rows as a fixed width table.
+func (c *Client) PostTable(ctx context.Context, chatID int64, title string, header []string, rows [][]string) error {
+	if len(rows) == 0 {
+		return c.SendMessage(ctx, chatID, fmt.Sprintf("<b>%s</b>\n<i>Nothing to show</i>", html.EscapeString(title)), nil)
+	}
+
+	widths := make([]int, len(header))
+	for i, h := range header {
+		widths[i] = utf8.RuneCountInString(h)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if n := utf8.RuneCountInString(truncate(cell, 30)); i < len(widths) && n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		for i, cell := range cells {
+			if i >= len(widths) {
+				break
+			}
+			cell = truncate(cell, 30)
+			b.WriteString(cell)
+			if i < len(cells)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+2))
+			}
+		}
+		b.WriteString("\n")
+	}
+	writeRow(header)
+	for _, row := range rows {
+		writeRow(row)
+	}
+
+	table := html.EscapeString(b.String())
+	if limit := maxText - len(title) - 30; len(table) > limit {
+		table = table[:strings.LastIndex(table[:limit], "\n")+1]
+	}
+
+	return c.SendMessage(ctx, chatID, fmt.Sprintf("<b>%s</b>\n<pre>%s</pre>", html.EscapeString(title), table), nil)
+}
+
+// truncate cuts s to n runes, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	if n < 1 {
+		return ""
+	}
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
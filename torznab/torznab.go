@@ -0,0 +1,228 @@
+// Package torznab searches indexers through the Torznab API served by
+// Prowlarr and Jackett.
+package torznab
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	httpTimeout = 30 * time.Second
+
+	// MoviesCategory is the Newznab category of all movies.
+	MoviesCategory = 2000
+)
+
+// Item is a release found by a search.
+type Item struct {
+	Title       string
+	GUID        string
+	Link        string
+	Indexer     string
+	Size        int64
+	Seeders     int
+	Peers       int
+	PublishDate time.Time
+	IMDbID      string
+}
+
+type feed struct {
+	Channel struct {
+		Title string `xml:"title"`
+		Items []struct {
+			Title     string `xml:"title"`
+			GUID      string `xml:"guid"`
+			Link      string `xml:"link"`
+			PubDate   string `xml:"pubDate"`
+			Size      int64  `xml:"size"`
+			Enclosure struct {
+				URL    string `xml:"url,attr"`
+				Length int64  `xml:"length,attr"`
+			} `xml:"enclosure"`
+			JackettIndexer  string `xml:"jackettindexer"`
+			ProwlarrIndexer string `xml:"prowlarrindexer"`
+			Attrs           []struct {
+				Name  string `xml:"name,attr"`
+				Value string `xml:"value,attr"`
+			} `xml:"attr"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+type apiError struct {
+	Code        int    `xml:"code,attr"`
+	Description string `xml:"description,attr"`
+}
+
+// Parse decodes a Torznab search response.
+func Parse(data []byte) ([]Item, error) {
+	var e apiError
+	if err := xml.Unmarshal(data, &e); err == nil && e.Description != "" {
+		return nil, fmt.Errorf("torznab error %d: %s", e.Code, e.Description)
+	}
+
+	var f feed
+	if err := xml.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+
+	var items []Item
+	for _, fi := range f.Channel.Items {
+		item := Item{
+			Title:   fi.Title,
+			GUID:    fi.GUID,
+			Link:    fi.Link,
+			Indexer: fi.JackettIndexer,
+			Size:    fi.Size,
+		}
+		if item.Indexer == "" {
+			item.Indexer = fi.ProwlarrIndexer
+		}
+		if item.Indexer == "" {
+			item.Indexer = f.Channel.Title
+		}
+		if item.Link == "" {
+			item.Link = fi.Enclosure.URL
+		}
+		if item.Size == 0 {
+			item.Size = fi.Enclosure.Length
+		}
+		if t, err := time.Parse(time.RFC1123Z, fi.PubDate); err == nil {
+			item.PublishDate = t
+		}
+
+		var imdb string
+		for _, attr := range fi.Attrs {
+			switch attr.Name {
+			case "seeders":
+				item.Seeders, _ = strconv.Atoi(attr.Value)
+			case "peers":
+				item.Peers, _ = strconv.Atoi(attr.Value)
+			case "size":
+				if item.Size == 0 {
+					item.Size, _ = strconv.ParseInt(attr.Value, 10, 64)
+				}
+			case "imdbid":
+				item.IMDbID = attr.Value
+			case "imdb":
+				imdb = attr.Value
+			}
+		}
+		// Jackett often only gives the number, without the tt prefix.
+		if item.IMDbID == "" && imdb != "" {
+			item.IMDbID = "tt" + strings.TrimPrefix(imdb, "tt")
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+var (
+	resolutions = regexp.MustCompile(`(?i)\b(2160p|1080p|720p|576p|480p)\b`)
+	sources     = []struct {
+		pattern *regexp.Regexp
+		name    string
+	}{
+		{regexp.MustCompile(`(?i)\b(remux)\b`), "Remux"},
+		{regexp.MustCompile(`(?i)\b(blu-?ray|bdrip|brrip)\b`), "Bluray"},
+		{regexp.MustCompile(`(?i)\b(web-?dl)\b`), "WEBDL"},
+		{regexp.MustCompile(`(?i)\b(webrip)\b`), "WEBRip"},
+		{regexp.MustCompile(`(?i)\b(hdtv)\b`), "HDTV"},
+		{regexp.MustCompile(`(?i)\b(dvdrip|dvd)\b`), "DVD"},
+		{regexp.MustCompile(`(?i)\b(hdcam|cam|camrip)\b`), "CAM"},
+		{regexp.MustCompile(`(?i)\b(telesync|hdts|ts)\b`), "TELESYNC"},
+	}
+)
+
+// Quality guesses the quality of a release from its title in the way Radarr
+// names them, e.g. Bluray-1080p. Torznab does not report it.
+func Quality(title string) string {
+	var source string
+	for _, s := range sources {
+		if s.pattern.MatchString(title) {
+			source = s.name
+			break
+		}
+	}
+	resolution := resolutions.FindString(title)
+
+	switch {
+	case source != "" && resolution != "":
+		return fmt.Sprintf("%s-%s", source, strings.ToLower(resolution))
+	case source != "":
+		return source
+	case resolution != "":
+		return strings.ToLower(resolution)
+	default:
+		return "Unknown"
+	}
+}
+
+// Client searches one Torznab endpoint, e.g. an indexer in Prowlarr or all
+// indexers of Jackett.
+type Client struct {
+	apiKey  string
+	baseURL *url.URL
+	http    http.Client
+}
+
+// NewClient creates a client of the Torznab API at host, the URL that ends in
+// /api.
+func NewClient(host, apiKey string) (*Client, error) {
+	// Hosts without a scheme are reached over HTTPS.
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	base, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse host %q: %v", host, err)
+	}
+	return &Client{
+		apiKey:  apiKey,
+		baseURL: base,
+		http: http.Client{
+			Timeout: httpTimeout,
+		},
+	}, nil
+}
+
+// SearchMovies searches the movie category for the query.
+func (c *Client) SearchMovies(ctx context.Context, query string) ([]Item, error) {
+	params := url.Values{
+		"t":      {"movie"},
+		"q":      {query},
+		"cat":    {strconv.Itoa(MoviesCategory)},
+		"apikey": {c.apiKey},
+	}
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s?%s", c.baseURL, params.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.WithContext(ctx)
+	response, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("torznab returned %s", response.Status)
+	}
+
+	return Parse(body)
+}
@@ -0,0 +1,167 @@
+package torznab
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readFeed(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		feed string
+		want []Item
+	}{
+		{
+			feed: "jackett.xml",
+			want: []Item{
+				{
+					Title:       "The.Matrix.1999.1080p.BluRay.x264-GROUP",
+					GUID:        "https://tracker.example/details/1001",
+					Link:        "http://127.0.0.1:9117/dl/tracker/?jackett_apikey=xxx&path=1001",
+					Indexer:     "Tracker",
+					Size:        10737418240,
+					Seeders:     120,
+					Peers:       135,
+					PublishDate: time.Date(2021, 1, 4, 10, 15, 0, 0, time.UTC),
+					IMDbID:      "tt0133093",
+				},
+				{
+					Title:       "The.Matrix.1999.720p.WEB-DL.DD5.1.H264",
+					GUID:        "https://other.example/t/77",
+					Link:        "http://127.0.0.1:9117/dl/other/?jackett_apikey=xxx&path=77",
+					Indexer:     "Other",
+					Size:        4294967296,
+					Seeders:     8,
+					Peers:       9,
+					PublishDate: time.Date(2021, 1, 5, 8, 0, 0, 0, time.UTC),
+					IMDbID:      "tt0133093",
+				},
+			},
+		},
+		{
+			feed: "prowlarr.xml",
+			want: []Item{
+				{
+					Title:       "Dune.2021.2160p.UHD.BluRay.REMUX.HDR.HEVC.Atmos-GROUP",
+					GUID:        "https://indexer.example/torrent/555",
+					Link:        "http://prowlarr:9696/3/download?apikey=xxx&link=abc",
+					Indexer:     "Indexer",
+					Size:        68719476736,
+					Seeders:     42,
+					Peers:       50,
+					PublishDate: time.Date(2021, 10, 22, 12, 30, 0, 0, time.UTC),
+					IMDbID:      "tt1160419",
+				},
+			},
+		},
+		{
+			// Only the enclosure gives the link and size, and the channel
+			// names the indexer.
+			feed: "enclosure.xml",
+			want: []Item{
+				{
+					Title:   "Some.Movie.2019.HDTS.x264",
+					GUID:    "minimal-1",
+					Link:    "https://minimal.example/get/1.torrent",
+					Indexer: "Minimal Indexer",
+					Size:    1500000000,
+					Seeders: 3,
+					Peers:   4,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.feed, func(t *testing.T) {
+			items, err := Parse(readFeed(t, tt.feed))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != len(tt.want) {
+				t.Fatalf("got %d items, want %d", len(items), len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := items[i]
+				if !got.PublishDate.Equal(want.PublishDate) {
+					t.Errorf("item %d published %v, want %v", i, got.PublishDate, want.PublishDate)
+				}
+				got.PublishDate, want.PublishDate = time.Time{}, time.Time{}
+				if got != want {
+					t.Errorf("item %d = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseError(t *testing.T) {
+	_, err := Parse(readFeed(t, "error.xml"))
+	if err == nil {
+		t.Fatal("Parse of an error response succeeded")
+	}
+	if !strings.Contains(err.Error(), "100") || !strings.Contains(err.Error(), "Invalid API Key") {
+		t.Errorf("error = %v, want the code and description", err)
+	}
+}
+
+func TestQuality(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Movie.2019.1080p.BluRay.x264", "Bluray-1080p"},
+		{"Movie.2019.HDTS.x264", "TELESYNC"},
+		{"Movie.2019.2160p.UHD.BluRay.REMUX.HEVC", "Remux-2160p"},
+		{"Movie 2019 720p WEB-DL DD5.1 H264", "WEBDL-720p"},
+		{"Movie.2019.1080p.WEBRip.x265", "WEBRip-1080p"},
+		{"Movie.2019.HDTV.x264", "HDTV"},
+		{"Movie.2019.DVDRip.XviD", "DVD"},
+		{"Movie.2019.HDCAM.x264", "CAM"},
+		{"Movie.2019.480p", "480p"},
+		{"Movie.2019", "Unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := Quality(tt.title); got != tt.want {
+			t.Errorf("Quality(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestSearchMovies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("t") != "movie" || query.Get("q") != "the matrix" || query.Get("cat") != "2000" || query.Get("apikey") != "secret" {
+			t.Errorf("query = %s", r.URL.RawQuery)
+		}
+		w.Write(readFeed(t, "prowlarr.xml"))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(server.URL+"/api", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, err := c.SearchMovies(context.Background(), "the matrix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Indexer != "Indexer" {
+		t.Errorf("items = %+v, want the Prowlarr release", items)
+	}
+}
@@ -0,0 +1,110 @@
+package warez
+
+import (
+	"sync"
+	"time"
+)
+
+// Link ties a Slack user to their Emby account.
+type Link struct {
+	SlackUser  string    `json:"slack_user"`
+	EmbyUserID string    `json:"emby_user_id"`
+	EmbyUser   string    `json:"emby_user"`
+	Linked     time.Time `json:"linked"`
+}
+
+// Invite is an Emby account requested by a Slack user, waiting for an admin.
+type Invite struct {
+	ID        string    `json:"id"`
+	SlackUser string    `json:"slack_user"`
+	Username  string    `json:"username"`
+	Requested time.Time `json:"requested"`
+}
+
+// accounts keeps the Slack to Emby user links and the pending invites.
+type accounts struct {
+	mu   sync.Mutex
+	file jsonFile
+
+	Links   map[string]Link   `json:"links"`
+	Invites map[string]Invite `json:"invites"`
+}
+
+func loadAccounts(file jsonFile) (*accounts, error) {
+	a := &accounts{
+		file:    file,
+		Links:   map[string]Link{},
+		Invites: map[string]Invite{},
+	}
+	if err := file.load(a); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func (a *accounts) link(l Link) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.Links[l.SlackUser] = l
+	return a.file.save(a)
+}
+
+// bySlackUser returns the Emby account linked to the Slack user.
+func (a *accounts) bySlackUser(user string) (Link, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	l, ok := a.Links[user]
+	return l, ok
+}
+
+// byEmbyUser returns the Slack user linked to the Emby user ID.
+func (a *accounts) byEmbyUser(id string) (Link, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, l := range a.Links {
+		if l.EmbyUserID == id {
+			return l, true
+		}
+	}
+	return Link{}, false
+}
+
+func (a *accounts) addInvite(i Invite) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.Invites[i.ID] = i
+	return a.file.save(a)
+}
+
+// takeInvite removes the invite and returns it, so that it can only be
+// resolved once.
+func (a *accounts) takeInvite(id string) (Invite, bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	i, ok := a.Invites[id]
+	if !ok {
+		return Invite{}, false, nil
+	}
+	delete(a.Invites, id)
+
+	return i, true, a.file.save(a)
+}
+
+// pendingInvite reports whether the Slack user already has an invite waiting.
+func (a *accounts) pendingInvite(user string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, i := range a.Invites {
+		if i.SlackUser == user {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,350 @@
+package warez
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+
+	"warezbot/media"
+)
+
+// The request API speaks a subset of the Overseerr API, so the mobile apps
+// made for Overseerr and Jellyseerr can search and request titles. Requests
+// go through the same pipeline as the ones made in Slack.
+
+const (
+	apiPageSize = 20
+	// tmdbImagePrefix is stripped from poster URLs, the apps add their own.
+	tmdbImagePrefix = "https://image.tmdb.org/t/p/"
+)
+
+// Overseerr media and request statuses.
+const (
+	overseerrMediaUnknown    = 1
+	overseerrMediaProcessing = 3
+	overseerrMediaAvailable  = 5
+
+	overseerrRequestApproved = 2
+	overseerrRequestFailed   = 4
+)
+
+// apiMediaTypes maps the media types of the API to the kinds they are
+// requested as. There is no manager for TV shows, see apiMediaTV.
+var apiMediaTypes = map[string]string{
+	"movie": media.Movie,
+}
+
+// apiMediaTV is the media type of TV shows, which can't be requested.
+const apiMediaTV = "tv"
+
+// APIUser is a user of the request API. Requests made with their key are made
+// as their Slack user, so the same permissions and history apply.
+type APIUser struct {
+	Key       string
+	SlackUser string
+	Name      string
+}
+
+// APISearch searches titles to request.
+type APISearch struct {
+	APIKey string
+	Query  string
+	Page   int
+}
+
+// APIMediaRequest requests a title by its TMDB ID.
+type APIMediaRequest struct {
+	APIKey    string
+	MediaType string `json:"mediaType"`
+	MediaID   int    `json:"mediaId"`
+}
+
+// APIRequestList lists requests, take at a time after skipping skip.
+type APIRequestList struct {
+	APIKey string
+	Take   int
+	Skip   int
+}
+
+// APIRequestStatus gets a single request.
+type APIRequestStatus struct {
+	APIKey    string
+	RequestID int
+}
+
+type APISearchFunc func(context.Context, APISearch) (Response, error)
+
+type APIMediaRequestFunc func(context.Context, APIMediaRequest) (Response, error)
+
+type APIRequestListFunc func(context.Context, APIRequestList) (Response, error)
+
+type APIRequestStatusFunc func(context.Context, APIRequestStatus) (Response, error)
+
+type apiMessage struct {
+	Message string `json:"message"`
+}
+
+type apiMediaInfo struct {
+	ID        int    `json:"id"`
+	TmdbID    int    `json:"tmdbId"`
+	MediaType string `json:"mediaType"`
+	Status    int    `json:"status"`
+}
+
+type apiSearchResult struct {
+	ID          int           `json:"id"`
+	MediaType   string        `json:"mediaType"`
+	Title       string        `json:"title"`
+	ReleaseDate string        `json:"releaseDate,omitempty"`
+	Overview    string        `json:"overview"`
+	PosterPath  string        `json:"posterPath,omitempty"`
+	MediaInfo   *apiMediaInfo `json:"mediaInfo,omitempty"`
+}
+
+type apiSearchResults struct {
+	Page         int               `json:"page"`
+	TotalPages   int               `json:"totalPages"`
+	TotalResults int               `json:"totalResults"`
+	Results      []apiSearchResult `json:"results"`
+}
+
+type apiUser struct {
+	ID          int    `json:"id"`
+	DisplayName string `json:"displayName"`
+}
+
+type apiRequest struct {
+	ID          int          `json:"id"`
+	Status      int          `json:"status"`
+	Type        string       `json:"type"`
+	Is4k        bool         `json:"is4k"`
+	CreatedAt   time.Time    `json:"createdAt"`
+	UpdatedAt   time.Time    `json:"updatedAt"`
+	Media       apiMediaInfo `json:"media"`
+	RequestedBy apiUser      `json:"requestedBy"`
+}
+
+type apiPageInfo struct {
+	Pages    int `json:"pages"`
+	PageSize int `json:"pageSize"`
+	Results  int `json:"results"`
+	Page     int `json:"page"`
+}
+
+type apiRequests struct {
+	PageInfo apiPageInfo  `json:"pageInfo"`
+	Results  []apiRequest `json:"results"`
+}
+
+// apiUser returns the user with the key and their Overseerr user ID.
+func (s *service) apiUser(key string) (APIUser, int, bool) {
+	if key == "" {
+		return APIUser{}, 0, false
+	}
+	for i, u := range s.apiUsers {
+		if subtle.ConstantTimeCompare([]byte(u.Key), []byte(key)) == 1 {
+			return u, i + 1, true
+		}
+	}
+	return APIUser{}, 0, false
+}
+
+// apiRequester names the Slack user who made a request the way the API does.
+func (s *service) apiRequester(slackUser string) apiUser {
+	for i, u := range s.apiUsers {
+		if u.SlackUser == slackUser {
+			return apiUser{ID: i + 1, DisplayName: u.Name}
+		}
+	}
+	return apiUser{DisplayName: s.userName(slackUser)}
+}
+
+func apiResponse(status int, payload interface{}) Response {
+	return Response{
+		StatusCode: status,
+		Payload:    payload,
+	}
+}
+
+func apiError(status int, format string, a ...interface{}) Response {
+	return apiResponse(status, apiMessage{Message: fmt.Sprintf(format, a...)})
+}
+
+func (s *service) SearchMedia(ctx context.Context, request APISearch) (Response, error) {
+	if _, _, ok := s.apiUser(request.APIKey); !ok {
+		return apiError(http.StatusUnauthorized, "Unauthorized"), nil
+	}
+	manager, ok := s.managers[media.Movie]
+	if !ok || strings.TrimSpace(request.Query) == "" {
+		return apiResponse(http.StatusOK, apiSearchResults{Page: 1, Results: []apiSearchResult{}}), nil
+	}
+
+	items, err := manager.Search(ctx, strings.Fields(request.Query))
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		return apiError(http.StatusInternalServerError, "Search failed"), nil
+	}
+
+	page := request.Page
+	if page < 1 {
+		page = 1
+	}
+	results := apiSearchResults{
+		Page:         page,
+		TotalPages:   (len(items) + apiPageSize - 1) / apiPageSize,
+		TotalResults: len(items),
+		Results:      []apiSearchResult{},
+	}
+	for i := (page - 1) * apiPageSize; i < len(items) && i < page*apiPageSize; i++ {
+		item := items[i]
+		id, _ := strconv.Atoi(item.ID)
+		result := apiSearchResult{
+			ID:        id,
+			MediaType: "movie",
+			Title:     item.Title,
+			Overview:  item.Overview,
+		}
+		// Only the year is known, which is all the apps show anyway.
+		if item.Year > 0 {
+			result.ReleaseDate = fmt.Sprintf("%d-01-01", item.Year)
+		}
+		if strings.HasPrefix(item.ImageURL, tmdbImagePrefix) {
+			// Drop the size, e.g. original/abc.jpg becomes /abc.jpg.
+			path := strings.TrimPrefix(item.ImageURL, tmdbImagePrefix)
+			if i := strings.Index(path, "/"); i >= 0 {
+				result.PosterPath = path[i:]
+			}
+		}
+		if r, ok := s.requests.active(media.Movie, item.ID); ok {
+			info := s.apiRequest(ctx, r).Media
+			result.MediaInfo = &info
+		}
+		results.Results = append(results.Results, result)
+	}
+
+	return apiResponse(http.StatusOK, results), nil
+}
+
+func (s *service) RequestMedia(ctx context.Context, request APIMediaRequest) (Response, error) {
+	user, _, ok := s.apiUser(request.APIKey)
+	if !ok {
+		return apiError(http.StatusUnauthorized, "Unauthorized"), nil
+	}
+	if request.MediaType == apiMediaTV {
+		return apiError(http.StatusNotImplemented, "TV requests are not supported"), nil
+	}
+	kind, ok := apiMediaTypes[request.MediaType]
+	if !ok || s.managers[kind] == nil {
+		return apiError(http.StatusBadRequest, "Requesting %s is not set up", request.MediaType), nil
+	}
+	if request.MediaID <= 0 {
+		return apiError(http.StatusBadRequest, "mediaId is required"), nil
+	}
+
+	r, err := s.requestMedia(ctx, kind, strconv.Itoa(request.MediaID), user.SlackUser, sourceAPI)
+	if err == errAlreadyRequested {
+		return apiError(http.StatusConflict, "Request for this media already exists"), nil
+	}
+	if err == errQuotaExceeded {
+		return apiError(http.StatusForbidden, "Quota exceeded"), nil
+	}
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		return apiError(http.StatusInternalServerError, "Request failed: %v", err), nil
+	}
+
+	return apiResponse(http.StatusCreated, s.apiRequest(ctx, r)), nil
+}
+
+// ListRequests lists every request to admins and their own to everyone else.
+func (s *service) ListRequests(ctx context.Context, request APIRequestList) (Response, error) {
+	user, _, ok := s.apiUser(request.APIKey)
+	if !ok {
+		return apiError(http.StatusUnauthorized, "Unauthorized"), nil
+	}
+
+	owner := user.SlackUser
+	if s.isAdmin(user.SlackUser) {
+		owner = ""
+	}
+	requests := s.requests.list(owner)
+
+	take := request.Take
+	if take <= 0 {
+		take = apiPageSize
+	}
+	skip := request.Skip
+	if skip < 0 {
+		skip = 0
+	}
+
+	list := apiRequests{
+		PageInfo: apiPageInfo{
+			Pages:    (len(requests) + take - 1) / take,
+			PageSize: take,
+			Results:  len(requests),
+			Page:     skip/take + 1,
+		},
+		Results: []apiRequest{},
+	}
+	for i := skip; i < len(requests) && i < skip+take; i++ {
+		list.Results = append(list.Results, s.apiRequest(ctx, requests[i]))
+	}
+
+	return apiResponse(http.StatusOK, list), nil
+}
+
+// RequestStatus gets a request, if it is the user's own or they are an
+// admin.
+func (s *service) RequestStatus(ctx context.Context, request APIRequestStatus) (Response, error) {
+	user, _, ok := s.apiUser(request.APIKey)
+	if !ok {
+		return apiError(http.StatusUnauthorized, "Unauthorized"), nil
+	}
+
+	r, ok := s.requests.get(request.RequestID)
+	if !ok {
+		return apiError(http.StatusNotFound, "Request not found"), nil
+	}
+	if r.User != user.SlackUser && !s.isAdmin(user.SlackUser) {
+		return apiError(http.StatusForbidden, "You do not have permission to view this request"), nil
+	}
+
+	return apiResponse(http.StatusOK, s.apiRequest(ctx, r)), nil
+}
+
+// apiRequest converts the request to its API form, checking first whether
+// it has become available.
+func (s *service) apiRequest(ctx context.Context, r MediaRequest) apiRequest {
+	r = s.refreshRequest(ctx, r)
+
+	tmdbID, _ := strconv.Atoi(r.MediaID)
+	result := apiRequest{
+		ID:        r.ID,
+		Status:    overseerrRequestApproved,
+		Type:      r.Kind,
+		CreatedAt: r.Requested,
+		UpdatedAt: r.Updated,
+		Media: apiMediaInfo{
+			ID:        r.ID,
+			TmdbID:    tmdbID,
+			MediaType: r.Kind,
+			Status:    overseerrMediaProcessing,
+		},
+		RequestedBy: s.apiRequester(r.User),
+	}
+	switch r.Status {
+	case requestAvailable:
+		result.Media.Status = overseerrMediaAvailable
+	case requestFailed:
+		result.Status = overseerrRequestFailed
+		result.Media.Status = overseerrMediaUnknown
+	}
+	return result
+}
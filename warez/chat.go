@@ -0,0 +1,202 @@
+package warez
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-kit/kit/log/level"
+
+	"warezbot/emby"
+	"warezbot/media"
+	"warezbot/slack"
+)
+
+const (
+	platformSlack      = "slack"
+	platformDiscord    = "discord"
+	platformMatrix     = "matrix"
+	platformTelegram   = "telegram"
+	platformMattermost = "mattermost"
+)
+
+// Conversation is where a command came from and where its answer goes.
+type Conversation struct {
+	// Platform is the chat platform, e.g. slack or discord.
+	Platform string
+	Channel  string
+	User     string
+	// Token identifies the command on platforms that answer commands rather
+	// than post to channels, like Discord interactions.
+	Token string
+}
+
+// requester is the user as recorded in requests. Slack users are recorded by
+// their ID alone, as they always have been.
+func (c Conversation) requester() string {
+	if c.Platform == platformSlack {
+		return c.User
+	}
+	return c.Platform + ":" + c.User
+}
+
+// Chat renders the answers to the commands every chat platform supports.
+type Chat interface {
+	// Reply answers the user privately where the platform allows it.
+	Reply(to Conversation, text string) error
+	PostTable(to Conversation, title string, header []string, rows [][]string) error
+	PostSearch(to Conversation, kind string, items media.Items) error
+	PostLibrarySearch(to Conversation, results emby.SearchResults) error
+	NowPlaying(to Conversation, sessions emby.Sessions) error
+}
+
+// slackChat posts to the channel of the Slack client, wherever the command
+// came from.
+type slackChat struct {
+	client *slack.Client
+}
+
+func (c slackChat) Reply(to Conversation, text string) error {
+	return c.client.PostEphemeral(to.Channel, to.User, text)
+}
+
+func (c slackChat) PostTable(to Conversation, title string, header []string, rows [][]string) error {
+	c.client.PostTable(title, header, rows)
+	return nil
+}
+
+func (c slackChat) PostSearch(to Conversation, kind string, items media.Items) error {
+	c.client.PostSearch(context.Background(), kind, items)
+	return nil
+}
+
+func (c slackChat) PostLibrarySearch(to Conversation, results emby.SearchResults) error {
+	c.client.PostEmbySearch(context.Background(), results)
+	return nil
+}
+
+func (c slackChat) NowPlaying(to Conversation, sessions emby.Sessions) error {
+	c.client.NowPlaying(sessions)
+	return nil
+}
+
+// slackConversation is the conversation of a Slack message event.
+func slackConversation(request SlackEvent) Conversation {
+	return Conversation{
+		Platform: platformSlack,
+		Channel:  request.Event.Channel,
+		User:     request.Event.User,
+	}
+}
+
+// chatCommand returns the handler of the command in words if it is one every
+// chat platform supports.
+func (s *service) chatCommand(words []string) (func(context.Context, Chat, Conversation), bool) {
+	add := func(kind string) func(context.Context, Chat, Conversation) {
+		return func(ctx context.Context, c Chat, to Conversation) {
+			s.addMedia(ctx, c, to, kind, words[2:])
+		}
+	}
+
+	switch {
+	case hasCommand(words, nowPlaying):
+		return s.nowPlaying, true
+	case hasCommand(words, search):
+		return func(ctx context.Context, c Chat, to Conversation) {
+			s.searchLibrary(ctx, c, to, words[1:])
+		}, true
+	case hasCommand(words, addMovie):
+		return add(media.Movie), true
+	case hasCommand(words, addArtist):
+		return add(media.Artist), true
+	case hasCommand(words, addAlbum):
+		return add(media.Album), true
+	case hasCommand(words, addBook):
+		return add(media.Book), true
+	case hasCommand(words, addAudiobook):
+		return add(media.Audiobook), true
+	case hasCommand(words, queue):
+		return s.queue, true
+	case hasCommand(words, subs):
+		return func(ctx context.Context, c Chat, to Conversation) {
+			s.subs(ctx, c, to, words[1:])
+		}, true
+	case hasCommand(words, listRequests):
+		return s.listRequests, true
+	}
+	return nil, false
+}
+
+// slackOnlyCommands are the commands that only Slack has, as they post Slack
+// prompts and buttons, act on Slack users or are admin commands.
+var slackOnlyCommands = []string{
+	ping,
+	listTorrents,
+	listReleases,
+	usenet,
+	userCreate,
+	userPassword,
+	userEnable,
+	userDisable,
+	userLink,
+	listUsers,
+	invite,
+	history,
+	top,
+	scan,
+	listLibraries,
+	tasks,
+	vote,
+	watchlistMine,
+}
+
+// slackOnlyCommand returns the command in words if only Slack has it, so the
+// other platforms can say so rather than ignore it.
+func slackOnlyCommand(words []string) (string, bool) {
+	for _, command := range slackOnlyCommands {
+		if hasCommand(words, command) {
+			return command, true
+		}
+	}
+	return "", false
+}
+
+// slackOnlyAnswer is the answer to commands only Slack has.
+const slackOnlyAnswer = "`%s` is not supported on this platform, it is only available in Slack."
+
+// answer replies to the user who sent the command.
+func (s *service) answer(c Chat, to Conversation, format string, a ...interface{}) {
+	if err := c.Reply(to, fmt.Sprintf(format, a...)); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+}
+
+// nowPlaying shows what is playing on the media server.
+func (s *service) nowPlaying(ctx context.Context, c Chat, to Conversation) {
+	sessions, err := s.media.Sessions(ctx)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.answer(c, to, "Failed to get the sessions: %v", err)
+		return
+	}
+	if err := c.NowPlaying(to, sessions); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+}
+
+// searchLibrary searches the media server.
+func (s *service) searchLibrary(ctx context.Context, c Chat, to Conversation, args []string) {
+	if len(args) == 0 {
+		s.answer(c, to, "Usage: search <term>")
+		return
+	}
+
+	results, err := s.media.Search(ctx, args)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.answer(c, to, "Failed to search: %v", err)
+		return
+	}
+	if err := c.PostLibrarySearch(to, results); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+}
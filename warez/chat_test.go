@@ -0,0 +1,38 @@
+package warez
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlackOnlyCommand(t *testing.T) {
+	tests := []struct {
+		text    string
+		command string
+		ok      bool
+	}{
+		{"torrents", listTorrents, true},
+		{"user password bob", userPassword, true},
+		{"Watchlist add Heat", watchlistMine, true},
+		{"history 30", history, true},
+		{"user", "", false},
+		{"add movie Heat", "", false},
+		{"queue", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		command, ok := slackOnlyCommand(strings.Fields(tt.text))
+		if command != tt.command || ok != tt.ok {
+			t.Errorf("slackOnlyCommand(%q) = %q, %v, want %q, %v", tt.text, command, ok, tt.command, tt.ok)
+		}
+	}
+}
+
+func TestChatCommandsNotSlackOnly(t *testing.T) {
+	s := &service{}
+	for _, command := range slackOnlyCommands {
+		if _, ok := s.chatCommand(strings.Fields(command + " x y")); ok {
+			t.Errorf("%q is both a chat command and Slack only", command)
+		}
+	}
+}
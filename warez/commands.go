@@ -0,0 +1,50 @@
+package warez
+
+import (
+	"strings"
+)
+
+// commandWords splits the text of a message event into words, dropping the
+// leading bot mention. Messages posted by bots yield no words so the bot never
+// reacts to its own output.
+func commandWords(request SlackEvent) []string {
+	if request.Event.BotID != "" {
+		return nil
+	}
+
+	words := strings.Fields(request.Event.Text)
+	if len(words) > 0 && strings.HasPrefix(words[0], "<@") {
+		words = words[1:]
+	}
+
+	return words
+}
+
+// hasCommand reports whether words start with every word of command.
+func hasCommand(words []string, command string) bool {
+	cmd := strings.Fields(command)
+	if len(words) < len(cmd) {
+		return false
+	}
+	for i, w := range cmd {
+		if !strings.EqualFold(words[i], w) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// slackUser extracts the user ID from a mention like <@U123> or <@U123|name>.
+func slackUser(word string) (string, bool) {
+	if !strings.HasPrefix(word, "<@") || !strings.HasSuffix(word, ">") {
+		return "", false
+	}
+
+	id := strings.TrimSuffix(strings.TrimPrefix(word, "<@"), ">")
+	if i := strings.Index(id, "|"); i >= 0 {
+		id = id[:i]
+	}
+
+	return id, id != ""
+}
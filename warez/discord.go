@@ -0,0 +1,190 @@
+package warez
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-kit/kit/log/level"
+
+	"warezbot/discord"
+	"warezbot/emby"
+	"warezbot/media"
+)
+
+// DiscordInteraction is a slash command or a button click sent by Discord.
+type DiscordInteraction struct {
+	ID            string `json:"id"`
+	ApplicationID string `json:"application_id"`
+	Type          int    `json:"type"`
+	Token         string `json:"token"`
+	ChannelID     string `json:"channel_id"`
+	GuildID       string `json:"guild_id"`
+	// Member is set in guilds, User in direct messages.
+	Member struct {
+		User DiscordUser `json:"user"`
+	} `json:"member"`
+	User DiscordUser `json:"user"`
+	Data struct {
+		Name    string `json:"name"`
+		Options []struct {
+			Name  string      `json:"name"`
+			Value interface{} `json:"value"`
+		} `json:"options"`
+		CustomID string `json:"custom_id"`
+	} `json:"data"`
+	Message struct {
+		ID      string `json:"id"`
+		Content string `json:"content"`
+	} `json:"message"`
+}
+
+type DiscordInteractionFunc func(context.Context, DiscordInteraction) (Response, error)
+
+type DiscordUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+// user returns who sent the interaction.
+func (i DiscordInteraction) user() DiscordUser {
+	if i.Member.User.ID != "" {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// option returns the string option with the name.
+func (i DiscordInteraction) option(name string) string {
+	for _, o := range i.Data.Options {
+		if o.Name == name {
+			if v, ok := o.Value.(string); ok {
+				return v
+			}
+			return fmt.Sprint(o.Value)
+		}
+	}
+	return ""
+}
+
+// discordChat answers Discord interactions through their webhook.
+type discordChat struct {
+	client *discord.Client
+}
+
+func (c discordChat) Reply(to Conversation, text string) error {
+	return c.client.Reply(context.Background(), to.Token, text)
+}
+
+func (c discordChat) PostTable(to Conversation, title string, header []string, rows [][]string) error {
+	return c.client.PostTable(context.Background(), to.Token, title, header, rows)
+}
+
+func (c discordChat) PostSearch(to Conversation, kind string, items media.Items) error {
+	return c.client.PostSearch(context.Background(), to.Token, kind, items)
+}
+
+func (c discordChat) PostLibrarySearch(to Conversation, results emby.SearchResults) error {
+	return c.client.PostLibrarySearch(context.Background(), to.Token, results)
+}
+
+func (c discordChat) NowPlaying(to Conversation, sessions emby.Sessions) error {
+	return c.client.NowPlaying(context.Background(), to.Token, sessions)
+}
+
+// ProcessDiscordInteractions answers Discord right away, as it wants an answer
+// within 3 seconds, and follows up once the command is done.
+func (s *service) ProcessDiscordInteractions(ctx context.Context, request DiscordInteraction) (Response, error) {
+	if s.discord == nil {
+		return Response{StatusCode: http.StatusNotFound}, nil
+	}
+
+	switch request.Type {
+	case discord.InteractionPing:
+		return discordResponse(discord.ResponsePong), nil
+	case discord.InteractionCommand:
+		go s.discordCommand(context.Background(), request)
+		return discordResponse(discord.ResponseDeferredMessage), nil
+	case discord.InteractionComponent:
+		if kind, id, ok := discord.ParseDownloadCustomID(request.Data.CustomID); ok {
+			go s.discordDownload(context.Background(), request, kind, id)
+		}
+		return discordResponse(discord.ResponseDeferredUpdateMessage), nil
+	}
+
+	return Response{StatusCode: http.StatusBadRequest}, nil
+}
+
+func discordResponse(kind int) Response {
+	return Response{
+		StatusCode: http.StatusOK,
+		Payload:    map[string]int{"type": kind},
+	}
+}
+
+// discordCommand runs the slash command. Admin commands are only available in
+// Slack.
+func (s *service) discordCommand(ctx context.Context, request DiscordInteraction) {
+	c := discordChat{s.discord}
+	to := Conversation{
+		Platform: platformDiscord,
+		Channel:  request.ChannelID,
+		User:     request.user().ID,
+		Token:    request.Token,
+	}
+
+	switch request.Data.Name {
+	case "search":
+		s.searchLibrary(ctx, c, to, strings.Fields(request.option("term")))
+	case "nowplaying":
+		s.nowPlaying(ctx, c, to)
+	case "add":
+		s.addMedia(ctx, c, to, request.option("kind"), strings.Fields(request.option("title")))
+	case "queue":
+		s.queue(ctx, c, to)
+	case "requests":
+		s.listRequests(ctx, c, to)
+	case "subs":
+		args := strings.Fields(request.option("title"))
+		if lang := request.option("language"); lang != "" && len(args) > 0 {
+			args = append(args, strings.ToLower(lang))
+		}
+		s.subs(ctx, c, to, args)
+	default:
+		s.answer(c, to, "Unknown command %q.", request.Data.Name)
+	}
+}
+
+// discordDownload requests the item picked from the search results and
+// replaces the buttons with who picked what.
+func (s *service) discordDownload(ctx context.Context, request DiscordInteraction, kind string, id string) {
+	user := request.user()
+	to := Conversation{
+		Platform: platformDiscord,
+		Channel:  request.ChannelID,
+		User:     user.ID,
+		Token:    request.Token,
+	}
+
+	r, err := s.requestMedia(ctx, kind, id, to.requester(), platformDiscord)
+	if err == errAlreadyRequested {
+		err = fmt.Errorf("it was already requested by %s on %s", s.userName(r.User), r.Requested.Format("Jan 2"))
+	}
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		title := r.Title
+		if title == "" {
+			title = id
+		}
+		s.answer(discordChat{s.discord}, to, "Failed to add %s: %v", title, err)
+		return
+	}
+
+	if err := s.discord.EditOriginal(ctx, request.Token, discord.Message{
+		Content:    fmt.Sprintf("Download process started by <@%s> for %s", user.ID, r.Title),
+		Components: &[]discord.Component{},
+	}); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+}
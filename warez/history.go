@@ -0,0 +1,217 @@
+package warez
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+)
+
+const (
+	defaultHistoryDays = 7
+	defaultTopDays     = 30
+	historyLimit       = 20
+	topLimit           = 10
+)
+
+// history posts what a user watched in the last days. The user is the one
+// mentioned, or the one asking when nobody is. Everyone's plays are shown
+// when the user asking has no linked Emby account.
+func (s *service) history(ctx context.Context, request SlackEvent, args []string) {
+	days := defaultHistoryDays
+	target := request.Event.User
+	explicit := false
+	for _, arg := range args {
+		if id, ok := slackUser(arg); ok {
+			target = id
+			explicit = true
+		} else if n, err := strconv.Atoi(arg); err == nil && n > 0 {
+			days = n
+		} else {
+			s.reply(request, "Usage: history [@user] [days]")
+			return
+		}
+	}
+
+	link, linked := s.accounts.bySlackUser(target)
+	if explicit && !linked {
+		s.reply(request, "<@%s> has no linked Emby account.", target)
+		return
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	names, err := s.embyUserNames(ctx)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.reply(request, "Failed to get the watch history: %v", err)
+		return
+	}
+
+	plays, err := s.media.PlaybackActivity(ctx, since)
+	if err != nil {
+		level.Warn(s.logger).Log("event", "playback reporting unavailable", "error", err)
+		if !linked {
+			s.reply(request, "Failed to get the watch history: %v", err)
+			return
+		}
+		s.playedItems(ctx, request, link, since, days)
+		return
+	}
+
+	title := fmt.Sprintf("Watched in the last %d days", days)
+	if linked {
+		title = fmt.Sprintf("%s watched in the last %d days", link.EmbyUser, days)
+	}
+
+	var rows [][]string
+	for _, play := range plays {
+		if linked && play.UserID != link.EmbyUserID {
+			continue
+		}
+		rows = append(rows, []string{
+			play.Date.Format("Jan 02 15:04"),
+			names[play.UserID],
+			play.ItemName,
+			formatDuration(play.Duration),
+			play.Device,
+		})
+		if len(rows) == historyLimit {
+			break
+		}
+	}
+
+	s.slack.PostTable(title, []string{"When", "User", "Title", "Time", "Device"}, rows)
+}
+
+// playedItems posts the history of a user from the items Emby marked as
+// played, for servers without the Playback Reporting plugin.
+func (s *service) playedItems(ctx context.Context, request SlackEvent, link Link, since time.Time, days int) {
+	items, err := s.media.PlayedItems(ctx, link.EmbyUserID, historyLimit)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.reply(request, "Failed to get the watch history: %v", err)
+		return
+	}
+
+	var rows [][]string
+	for _, item := range items.Items {
+		if item.UserData.LastPlayedDate.Before(since) {
+			continue
+		}
+		name := item.Name
+		if item.Type == "Episode" {
+			name = fmt.Sprintf("%s S%02dE%02d", item.SeriesName, item.ParentIndexNumber, item.IndexNumber)
+		}
+		rows = append(rows, []string{
+			item.UserData.LastPlayedDate.Local().Format("Jan 02 15:04"),
+			name,
+			strconv.Itoa(item.UserData.PlayCount),
+		})
+	}
+
+	s.slack.PostTable(fmt.Sprintf("%s watched in the last %d days", link.EmbyUser, days), []string{"When", "Title", "Plays"}, rows)
+}
+
+// top posts the most watched titles and the users that watched the most.
+func (s *service) top(ctx context.Context, request SlackEvent, args []string) {
+	days := defaultTopDays
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n <= 0 || len(args) > 1 {
+			s.reply(request, "Usage: top [days]")
+			return
+		}
+		days = n
+	}
+
+	plays, err := s.media.PlaybackActivity(ctx, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.reply(request, "Failed to get the playback activity: %v", err)
+		return
+	}
+	names, err := s.embyUserNames(ctx)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.reply(request, "Failed to get the playback activity: %v", err)
+		return
+	}
+
+	titles, users := tally{}, tally{}
+	for _, play := range plays {
+		titles.add(play.ItemName, play.Duration)
+		users.add(names[play.UserID], play.Duration)
+	}
+
+	s.slack.PostTable(fmt.Sprintf("Most watched titles in the last %d days", days), []string{"Title", "Plays", "Time"}, countRows(titles.top(topLimit)))
+	s.slack.PostTable(fmt.Sprintf("Top users in the last %d days", days), []string{"User", "Plays", "Time"}, countRows(users.top(topLimit)))
+}
+
+func countRows(counts []count) [][]string {
+	var rows [][]string
+	for _, c := range counts {
+		rows = append(rows, []string{c.key, strconv.Itoa(c.plays), formatDuration(c.duration)})
+	}
+	return rows
+}
+
+type count struct {
+	key      string
+	plays    int
+	duration time.Duration
+}
+
+// tally adds up plays and watch time per key.
+type tally map[string]*count
+
+func (t tally) add(key string, d time.Duration) {
+	c, ok := t[key]
+	if !ok {
+		c = &count{key: key}
+		t[key] = c
+	}
+	c.plays++
+	c.duration += d
+}
+
+// top returns the n keys with the most watch time.
+func (t tally) top(n int) []count {
+	counts := make([]count, 0, len(t))
+	for _, c := range t {
+		counts = append(counts, *c)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].duration == counts[j].duration {
+			return counts[i].key < counts[j].key
+		}
+		return counts[i].duration > counts[j].duration
+	})
+	if len(counts) > n {
+		counts = counts[:n]
+	}
+
+	return counts
+}
+
+// embyUserNames maps Emby user IDs to user names.
+func (s *service) embyUserNames(ctx context.Context) (map[string]string, error) {
+	users, err := s.media.Users(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(users))
+	for _, user := range users {
+		names[user.ID] = user.Name
+	}
+
+	return names, nil
+}
+
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
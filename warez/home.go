@@ -0,0 +1,70 @@
+package warez
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-kit/kit/log/level"
+
+	"warezbot/slack"
+)
+
+// refreshHome updates the App Home tab of the user, if they are a Slack user,
+// e.g. after the status of one of their requests changed.
+func (s *service) refreshHome(user string) {
+	// Users of other platforms are recorded as platform:user.
+	if s.slack == nil || user == "" || strings.Contains(user, ":") {
+		return
+	}
+	go s.publishHome(context.Background(), user)
+}
+
+// publishHome shows the user their requests, how many more they may make and
+// what is playing.
+func (s *service) publishHome(ctx context.Context, user string) {
+	home := slack.Home{
+		QuotaLeft: s.quotaLeft(user),
+	}
+
+	for _, r := range s.requests.list(user) {
+		r = s.refreshRequest(ctx, r)
+		line := r.Title
+		if r.Year > 0 {
+			line = fmt.Sprintf("%s (%d)", r.Title, r.Year)
+		}
+		line = fmt.Sprintf("%s - %s, requested %s", line, r.Kind, r.Requested.Format("Jan 2"))
+
+		switch r.Status {
+		case requestProcessing:
+			home.Pending = append(home.Pending, line)
+		case requestAvailable:
+			home.Completed = append(home.Completed, line)
+		}
+	}
+
+	sessions, err := s.media.Sessions(ctx)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+	link, linked := s.accounts.bySlackUser(user)
+	for _, ses := range sessions {
+		if ses.NowPlayingItem.Name == "" {
+			continue
+		}
+		home.NowPlaying++
+
+		if !linked || ses.UserID != link.EmbyUserID {
+			continue
+		}
+		title := ses.NowPlayingItem.Name
+		if ses.NowPlayingItem.Type == "Episode" {
+			title = fmt.Sprintf("%s - %s", ses.NowPlayingItem.SeriesName, ses.NowPlayingItem.Name)
+		}
+		home.Watching = append(home.Watching, fmt.Sprintf("%s on %s", title, ses.DeviceName))
+	}
+
+	if err := s.slack.PublishHome(ctx, user, home); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+}
@@ -0,0 +1,201 @@
+package warez
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+
+	"warezbot/emby"
+	"warezbot/slack"
+)
+
+var validUsername = regexp.MustCompile(`^[A-Za-z0-9._-]{2,32}$`)
+
+func (s *service) promptInvite(request SlackEvent) {
+	if l, ok := s.accounts.bySlackUser(request.Event.User); ok {
+		s.reply(request, "You already have the Emby account %s.", l.EmbyUser)
+		return
+	}
+
+	if err := s.slack.PostInvitePrompt(request.Event.Channel, request.Event.User); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+}
+
+func (s *service) openInviteModal(ctx context.Context, request SlackAction) {
+	if err := s.slack.OpenInviteModal(ctx, request.TriggerID); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+}
+
+// requestInvite handles the submission of the invite modal. Problems with the
+// username are reported inline so the user can fix them without starting
+// over.
+func (s *service) requestInvite(ctx context.Context, request SlackAction) (Response, error) {
+	username := request.value(slack.InviteUsernameBlock, slack.InviteUsernameAction).Value
+
+	var problem string
+	if !validUsername.MatchString(username) {
+		problem = "Use 2 to 32 letters, digits, dots, dashes or underscores."
+	} else if l, ok := s.accounts.bySlackUser(request.User.ID); ok {
+		problem = fmt.Sprintf("You already have the Emby account %s.", l.EmbyUser)
+	} else if s.accounts.pendingInvite(request.User.ID) {
+		problem = "You already have a request waiting for an admin."
+	} else if _, err := s.media.UserByName(ctx, username); err == nil {
+		problem = "That username is taken."
+	}
+	if problem != "" {
+		return Response{
+			StatusCode: http.StatusOK,
+			Payload:    viewErrors(map[string]string{slack.InviteUsernameBlock: problem}),
+		}, nil
+	}
+
+	id, err := newID()
+	if err != nil {
+		return Response{}, err
+	}
+	invite := Invite{
+		ID:        id,
+		SlackUser: request.User.ID,
+		Username:  username,
+		Requested: time.Now(),
+	}
+	if err := s.accounts.addInvite(invite); err != nil {
+		level.Error(s.logger).Log("error", err)
+		return Response{}, err
+	}
+
+	go func() {
+		if err := s.slack.PostInviteRequest(invite.ID, invite.SlackUser, invite.Username); err != nil {
+			level.Error(s.logger).Log("error", err)
+		}
+	}()
+
+	return Response{
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+func (s *service) resolveInvite(ctx context.Context, request SlackAction) {
+	if !s.isAdmin(request.User.ID) {
+		if err := s.slack.PostEphemeral(request.Channel.ID, request.User.ID, "Sorry, only admins can do that."); err != nil {
+			level.Error(s.logger).Log("error", err)
+		}
+		return
+	}
+
+	action := request.Actions[0]
+	invite, ok, err := s.accounts.takeInvite(action.Value)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+	if !ok {
+		s.resolveInviteRequest(request, "This request was already handled.")
+		return
+	}
+
+	if action.Name == slack.InviteDeny {
+		s.audit.Log("action", "invite_deny", "admin", request.User.ID, "slack_user", invite.SlackUser, "emby_user", invite.Username)
+		s.resolveInviteRequest(request, fmt.Sprintf("<@%s> denied the Emby account *%s* for <@%s>", request.User.ID, invite.Username, invite.SlackUser))
+		if err := s.slack.PostDirect(invite.SlackUser, "Sorry, your Emby account request was denied."); err != nil {
+			level.Error(s.logger).Log("error", err)
+		}
+		return
+	}
+
+	password, err := s.createInvitedUser(ctx, invite)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		// Keep the invite around so that the admin can try again.
+		if err := s.accounts.addInvite(invite); err != nil {
+			level.Error(s.logger).Log("error", err)
+		}
+		if err := s.slack.PostEphemeral(request.Channel.ID, request.User.ID, fmt.Sprintf("Failed to create the Emby account %s: %v", invite.Username, err)); err != nil {
+			level.Error(s.logger).Log("error", err)
+		}
+		return
+	}
+
+	s.audit.Log("action", "invite_approve", "admin", request.User.ID, "slack_user", invite.SlackUser, "emby_user", invite.Username)
+	s.resolveInviteRequest(request, fmt.Sprintf("<@%s> approved the Emby account *%s* for <@%s>", request.User.ID, invite.Username, invite.SlackUser))
+	credentials := fmt.Sprintf("Your Emby account is ready!\nServer: %s\nUsername: `%s`\nPassword: `%s`", s.media.URL(), invite.Username, password)
+	if err := s.slack.PostDirect(invite.SlackUser, credentials); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+}
+
+// createInvitedUser creates the Emby account of the invite and links it to
+// the Slack user that asked for it. The account is deleted again if it can't
+// be set up, so that approving the invite can be retried.
+func (s *service) createInvitedUser(ctx context.Context, invite Invite) (string, error) {
+	user, err := s.media.CreateUser(ctx, invite.Username)
+	if err != nil {
+		return "", err
+	}
+
+	password, err := s.setUpInvitedUser(ctx, invite, user)
+	if err != nil {
+		s.deleteUser(ctx, user)
+		return "", err
+	}
+	return password, nil
+}
+
+func (s *service) setUpInvitedUser(ctx context.Context, invite Invite, user emby.User) (string, error) {
+	if err := s.media.ApplyPolicy(ctx, user.ID, s.invitePolicy); err != nil {
+		return "", err
+	}
+
+	password, err := generatePassword()
+	if err != nil {
+		return "", err
+	}
+	if err := s.media.SetPassword(ctx, user.ID, password); err != nil {
+		return "", err
+	}
+
+	if err := s.accounts.link(Link{
+		SlackUser:  invite.SlackUser,
+		EmbyUserID: user.ID,
+		EmbyUser:   user.Name,
+		Linked:     time.Now(),
+	}); err != nil {
+		return "", err
+	}
+
+	return password, nil
+}
+
+func (s *service) resolveInviteRequest(request SlackAction, text string) {
+	if err := s.slack.ResolveInviteRequest(request.Channel.ID, request.MessageTs, text); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+}
+
+// viewErrors is the response to a modal submission that shows the errors next
+// to the offending blocks.
+func viewErrors(errors map[string]string) interface{} {
+	return struct {
+		ResponseAction string            `json:"response_action"`
+		Errors         map[string]string `json:"errors"`
+	}{
+		ResponseAction: "errors",
+		Errors:         errors,
+	}
+}
+
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate id: %v", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,183 @@
+package warez
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+)
+
+const (
+	// refreshLibraryTask is the key of the Emby task that scans all libraries.
+	refreshLibraryTask = "RefreshLibrary"
+
+	taskPollInterval = 5 * time.Second
+	taskTimeout      = 2 * time.Hour
+	// taskProgressStep is how much progress is made between updates posted
+	// to the thread.
+	taskProgressStep = 25
+)
+
+// scan rescans one library, or all of them when no name is given.
+func (s *service) scan(ctx context.Context, request SlackEvent, args []string) {
+	if !s.requireAdmin(request) {
+		return
+	}
+
+	if len(args) > 0 {
+		name := strings.Join(args, " ")
+		libraries, err := s.media.Libraries(ctx)
+		if err != nil {
+			level.Error(s.logger).Log("error", err)
+			s.reply(request, "Failed to list the Emby libraries: %v", err)
+			return
+		}
+		library, ok := libraries.Find(name)
+		if !ok {
+			s.reply(request, "There is no library called %s.", name)
+			return
+		}
+		if err := s.media.RefreshItem(ctx, library.ItemID); err != nil {
+			level.Error(s.logger).Log("error", err)
+			s.reply(request, "Failed to scan %s: %v", library.Name, err)
+			return
+		}
+		s.audit.Log("action", "library_scan", "admin", request.Event.User, "library", library.Name)
+		// Refreshing one library doesn't run the library scan task, so
+		// there is no progress to follow.
+		s.reply(request, "Scanning %s. Progress is only posted for scans of all libraries.", library.Name)
+		return
+	}
+
+	if err := s.media.RefreshLibrary(ctx); err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.reply(request, "Failed to scan the libraries: %v", err)
+		return
+	}
+	s.audit.Log("action", "library_scan", "admin", request.Event.User, "library", "all")
+
+	channel, ts, err := s.slack.PostNotice(fmt.Sprintf("<@%s> started a scan of all Emby libraries", request.Event.User))
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		return
+	}
+
+	tasks, err := s.media.ScheduledTasks(ctx)
+	if err == errUnsupported {
+		return
+	}
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		return
+	}
+	for _, task := range tasks {
+		if task.Key == refreshLibraryTask {
+			s.followTask(ctx, task.ID, channel, ts)
+			return
+		}
+	}
+}
+
+// libraries lists the libraries and how many titles they hold.
+func (s *service) libraries(ctx context.Context, request SlackEvent) {
+	stats, err := s.media.LibraryStats(ctx)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.reply(request, "Failed to list the libraries: %v", err)
+		return
+	}
+
+	var rows [][]string
+	for _, stat := range stats {
+		rows = append(rows, []string{stat.Name, stat.CollectionType, strconv.Itoa(stat.Titles)})
+	}
+	s.slack.PostTable("Libraries", []string{"Library", "Type", "Titles"}, rows)
+}
+
+func (s *service) tasks(ctx context.Context, request SlackEvent) {
+	if !s.requireAdmin(request) {
+		return
+	}
+
+	tasks, err := s.media.ScheduledTasks(ctx)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.reply(request, "Failed to list the Emby tasks: %v", err)
+		return
+	}
+
+	s.slack.PostTasks(tasks)
+}
+
+// runTask runs the task of the button that was clicked and reports its
+// progress in the thread of the task list.
+func (s *service) runTask(ctx context.Context, request SlackAction) {
+	if !s.isAdmin(request.User.ID) {
+		if err := s.slack.PostEphemeral(request.Channel.ID, request.User.ID, "Sorry, only admins can do that."); err != nil {
+			level.Error(s.logger).Log("error", err)
+		}
+		return
+	}
+
+	action := request.Actions[0]
+	if err := s.media.RunScheduledTask(ctx, action.Value); err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.postThread(request.Channel.ID, request.MessageTs, fmt.Sprintf("Failed to run %s: %v", action.Name, err))
+		return
+	}
+	s.audit.Log("action", "task_run", "admin", request.User.ID, "task", action.Name)
+
+	s.postThread(request.Channel.ID, request.MessageTs, fmt.Sprintf("<@%s> started %s", request.User.ID, action.Name))
+	s.followTask(ctx, action.Value, request.Channel.ID, request.MessageTs)
+}
+
+// followTask polls a running task and posts its progress to the thread until
+// it is done.
+func (s *service) followTask(ctx context.Context, id string, channel string, ts string) {
+	since := time.Now().Add(-taskPollInterval)
+	deadline := time.Now().Add(taskTimeout)
+	reported := 0.0
+
+	for time.Now().Before(deadline) {
+		time.Sleep(taskPollInterval)
+
+		task, err := s.media.ScheduledTask(ctx, id)
+		if err != nil {
+			level.Error(s.logger).Log("error", err)
+			s.postThread(channel, ts, fmt.Sprintf("Lost track of the task: %v", err))
+			return
+		}
+
+		if task.State == "Running" {
+			if task.CurrentProgressPercentage >= reported+taskProgressStep {
+				reported = task.CurrentProgressPercentage
+				s.postThread(channel, ts, fmt.Sprintf("%s: %.0f%%", task.Name, task.CurrentProgressPercentage))
+			}
+			continue
+		}
+
+		// Tasks may take a moment to start, or be done before the first
+		// poll, so only an end time after we started following means the
+		// task ran.
+		result := task.LastExecutionResult
+		if result.EndTimeUtc.After(since) {
+			text := fmt.Sprintf("%s finished: %s", task.Name, result.Status)
+			if result.ErrorMessage != "" {
+				text += fmt.Sprintf(" (%s)", result.ErrorMessage)
+			}
+			s.postThread(channel, ts, text)
+			return
+		}
+	}
+
+	s.postThread(channel, ts, "Stopped following the task, it is taking too long.")
+}
+
+func (s *service) postThread(channel string, ts string, text string) {
+	if err := s.slack.PostThread(channel, ts, text); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+}
@@ -0,0 +1,213 @@
+package warez
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+
+	"warezbot/emby"
+	"warezbot/matrix"
+	"warezbot/media"
+)
+
+const (
+	// matrixPrefix starts every command, as rooms have no mentions to
+	// address the bot by.
+	matrixPrefix = "!warez"
+	// matrixChoiceTTL is how long the results of a search can be picked
+	// from.
+	matrixChoiceTTL = 10 * time.Minute
+	matrixRetry     = 30 * time.Second
+)
+
+// matrixChoice is a search someone can pick a result from by number.
+type matrixChoice struct {
+	kind   string
+	items  media.Items
+	posted time.Time
+}
+
+// matrixChoices holds the latest search of everyone, by room and user.
+type matrixChoices struct {
+	mu      sync.Mutex
+	choices map[string]matrixChoice
+}
+
+func newMatrixChoices() *matrixChoices {
+	return &matrixChoices{choices: make(map[string]matrixChoice)}
+}
+
+func matrixChoiceKey(to Conversation) string {
+	return to.Channel + " " + to.User
+}
+
+func (m *matrixChoices) set(to Conversation, c matrixChoice) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.choices[matrixChoiceKey(to)] = c
+}
+
+func (m *matrixChoices) get(to Conversation) (matrixChoice, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.choices[matrixChoiceKey(to)]
+	if ok && time.Since(c.posted) > matrixChoiceTTL {
+		delete(m.choices, matrixChoiceKey(to))
+		return matrixChoice{}, false
+	}
+	return c, ok
+}
+
+func (m *matrixChoices) remove(to Conversation) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.choices, matrixChoiceKey(to))
+}
+
+// matrixChat posts to the room the command came from.
+type matrixChat struct {
+	client  *matrix.Client
+	choices *matrixChoices
+}
+
+func (c matrixChat) Reply(to Conversation, text string) error {
+	return c.client.Reply(context.Background(), to.Channel, to.User, text)
+}
+
+func (c matrixChat) PostTable(to Conversation, title string, header []string, rows [][]string) error {
+	return c.client.PostTable(context.Background(), to.Channel, title, header, rows)
+}
+
+// PostSearch also remembers the results, for the user to reply with the
+// number of one.
+func (c matrixChat) PostSearch(to Conversation, kind string, items media.Items) error {
+	if len(items) > matrix.MaxChoices {
+		items = items[:matrix.MaxChoices]
+	}
+	if len(items) > 0 {
+		c.choices.set(to, matrixChoice{kind: kind, items: items, posted: time.Now()})
+	}
+	return c.client.PostSearch(context.Background(), to.Channel, kind, items)
+}
+
+func (c matrixChat) PostLibrarySearch(to Conversation, results emby.SearchResults) error {
+	return c.client.PostLibrarySearch(context.Background(), to.Channel, results)
+}
+
+func (c matrixChat) NowPlaying(to Conversation, sessions emby.Sessions) error {
+	return c.client.NowPlaying(context.Background(), to.Channel, sessions)
+}
+
+// matrixSync joins the rooms and answers the commands sent to them until the
+// process exits.
+func (s *service) matrixSync(rooms []string) {
+	ctx := context.Background()
+
+	var self string
+	for {
+		var err error
+		if self, err = s.matrix.client.WhoAmI(ctx); err == nil {
+			break
+		}
+		level.Error(s.logger).Log("msg", "failed to reach the matrix homeserver", "error", err)
+		time.Sleep(matrixRetry)
+	}
+
+	joined := make(map[string]bool, len(rooms))
+	for _, room := range rooms {
+		id, err := s.matrix.client.JoinRoom(ctx, room)
+		if err != nil {
+			level.Error(s.logger).Log("msg", "failed to join matrix room", "room", room, "error", err)
+			// The bot may have been invited to it already.
+			id = room
+		}
+		joined[id] = true
+	}
+
+	var since string
+	for {
+		sync, err := s.matrix.client.Sync(ctx, since, matrix.SyncTimeout)
+		if err != nil {
+			level.Error(s.logger).Log("error", err)
+			time.Sleep(matrixRetry)
+			continue
+		}
+		// The first sync returns old messages, which were answered, if
+		// ever, before a restart.
+		if since != "" {
+			for id, room := range sync.Rooms.Join {
+				if !joined[id] {
+					continue
+				}
+				for _, event := range room.Timeline.Events {
+					if event.Type == "m.room.message" && event.Sender != self {
+						s.matrixMessage(id, event)
+					}
+				}
+			}
+		}
+		since = sync.NextBatch
+	}
+}
+
+// matrixMessage runs the command in the message, or downloads the search
+// result picked by replying with its number.
+func (s *service) matrixMessage(roomID string, event matrix.Event) {
+	to := Conversation{
+		Platform: platformMatrix,
+		Channel:  roomID,
+		User:     event.Sender,
+	}
+	body := matrix.StripReply(event.Content.Body)
+
+	if n, err := strconv.Atoi(body); err == nil {
+		go s.matrixPick(context.Background(), to, n)
+		return
+	}
+
+	words := strings.Fields(body)
+	if len(words) == 0 || !strings.EqualFold(words[0], matrixPrefix) {
+		return
+	}
+	if handler, ok := s.chatCommand(words[1:]); ok {
+		go handler(context.Background(), s.matrix, to)
+		return
+	}
+	if command, ok := slackOnlyCommand(words[1:]); ok {
+		go s.answer(s.matrix, to, slackOnlyAnswer, command)
+		return
+	}
+	go s.answer(s.matrix, to, "Unknown command, try `%s search <term>`, `%s add movie <title>`, `%s queue` or `%s requests`.", matrixPrefix, matrixPrefix, matrixPrefix, matrixPrefix)
+}
+
+// matrixPick downloads the search result with the number. Numbers are
+// ignored when the user has no search to pick from.
+func (s *service) matrixPick(ctx context.Context, to Conversation, n int) {
+	choice, ok := s.matrix.choices.get(to)
+	if !ok {
+		return
+	}
+	if n < 1 || n > len(choice.items) {
+		s.answer(s.matrix, to, "Pick a number from 1 to %d.", len(choice.items))
+		return
+	}
+	s.matrix.choices.remove(to)
+
+	item := choice.items[n-1]
+	r, err := s.requestMedia(ctx, choice.kind, item.ID, to.requester(), platformMatrix)
+	if err == errAlreadyRequested {
+		err = fmt.Errorf("it was already requested by %s on %s", s.userName(r.User), r.Requested.Format("Jan 2"))
+	}
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.answer(s.matrix, to, "Failed to add %s: %v", item.Title, err)
+		return
+	}
+
+	s.answer(s.matrix, to, "Download process started for %s", r.Title)
+}
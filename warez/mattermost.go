@@ -0,0 +1,147 @@
+package warez
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-kit/kit/log/level"
+
+	"warezbot/emby"
+	"warezbot/mattermost"
+	"warezbot/media"
+)
+
+// MattermostCommand is a slash command, sent as a form.
+type MattermostCommand struct {
+	Token     string
+	ChannelID string
+	UserID    string
+	UserName  string
+	Command   string
+	Text      string
+}
+
+// MattermostAction is the click of a button posted by the bot.
+type MattermostAction struct {
+	UserID    string                   `json:"user_id"`
+	UserName  string                   `json:"user_name"`
+	ChannelID string                   `json:"channel_id"`
+	PostID    string                   `json:"post_id"`
+	Context   mattermost.ActionContext `json:"context"`
+}
+
+type MattermostCommandFunc func(context.Context, MattermostCommand) (Response, error)
+
+type MattermostActionFunc func(context.Context, MattermostAction) (Response, error)
+
+// mattermostChat posts to the channel the command came from.
+type mattermostChat struct {
+	client *mattermost.Client
+}
+
+func (c mattermostChat) Reply(to Conversation, text string) error {
+	return c.client.PostEphemeral(context.Background(), to.Channel, to.User, text)
+}
+
+func (c mattermostChat) PostTable(to Conversation, title string, header []string, rows [][]string) error {
+	return c.client.PostTable(context.Background(), to.Channel, title, header, rows)
+}
+
+func (c mattermostChat) PostSearch(to Conversation, kind string, items media.Items) error {
+	return c.client.PostSearch(context.Background(), to.Channel, kind, items)
+}
+
+func (c mattermostChat) PostLibrarySearch(to Conversation, results emby.SearchResults) error {
+	return c.client.PostLibrarySearch(context.Background(), to.Channel, results)
+}
+
+func (c mattermostChat) NowPlaying(to Conversation, sessions emby.Sessions) error {
+	return c.client.NowPlaying(context.Background(), to.Channel, sessions)
+}
+
+// mattermostResponse is the answer to a slash command, only shown to the user
+// who ran it.
+func mattermostResponse(text string) Response {
+	return Response{
+		StatusCode: http.StatusOK,
+		Payload: map[string]string{
+			"response_type": "ephemeral",
+			"text":          text,
+		},
+	}
+}
+
+// ProcessMattermostCommands runs the slash command. The answer is posted to
+// the channel once the command is done.
+func (s *service) ProcessMattermostCommands(ctx context.Context, request MattermostCommand) (Response, error) {
+	if s.mattermost.client == nil {
+		return Response{StatusCode: http.StatusNotFound}, nil
+	}
+	if s.mattermostToken == "" || subtle.ConstantTimeCompare([]byte(request.Token), []byte(s.mattermostToken)) != 1 {
+		return Response{StatusCode: http.StatusUnauthorized}, nil
+	}
+
+	to := Conversation{
+		Platform: platformMattermost,
+		Channel:  request.ChannelID,
+		User:     request.UserID,
+	}
+	words := strings.Fields(request.Text)
+	handler, ok := s.chatCommand(words)
+	if !ok {
+		if command, ok := slackOnlyCommand(words); ok {
+			return mattermostResponse(fmt.Sprintf(slackOnlyAnswer, command)), nil
+		}
+		return mattermostResponse(fmt.Sprintf("Try `%[1]s search <term>`, `%[1]s add movie <title>`, `%[1]s now playing`, `%[1]s queue`, `%[1]s requests` or `%[1]s subs`.", request.Command)), nil
+	}
+	go handler(context.Background(), s.mattermost, to)
+
+	return mattermostResponse(""), nil
+}
+
+// ProcessMattermostActions handles the buttons of the bot. The post is
+// updated right away, like in Slack, and the user told if the request fails.
+func (s *service) ProcessMattermostActions(ctx context.Context, request MattermostAction) (Response, error) {
+	if s.mattermost.client == nil {
+		return Response{StatusCode: http.StatusNotFound}, nil
+	}
+	if !s.mattermost.client.ValidAction(request.Context) {
+		return Response{StatusCode: http.StatusUnauthorized}, nil
+	}
+	if request.Context.Action != mattermost.ActionDownload {
+		return Response{StatusCode: http.StatusBadRequest}, nil
+	}
+
+	go s.mattermostDownload(context.Background(), request)
+
+	return Response{
+		StatusCode: http.StatusOK,
+		Payload: map[string]interface{}{
+			"update": map[string]interface{}{
+				"message": fmt.Sprintf("Download process started by @%s for %s", request.UserName, request.Context.Title),
+				"props":   map[string]interface{}{"attachments": []interface{}{}},
+			},
+		},
+	}, nil
+}
+
+// mattermostDownload requests the item picked from the search results.
+func (s *service) mattermostDownload(ctx context.Context, request MattermostAction) {
+	to := Conversation{
+		Platform: platformMattermost,
+		Channel:  request.ChannelID,
+		User:     request.UserID,
+	}
+
+	r, err := s.requestMedia(ctx, request.Context.Kind, request.Context.ID, to.requester(), platformMattermost)
+	if err == errAlreadyRequested {
+		err = fmt.Errorf("it was already requested by %s on %s", s.userName(r.User), r.Requested.Format("Jan 2"))
+	}
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.answer(s.mattermost, to, "Failed to add %s: %v", request.Context.Title, err)
+	}
+}
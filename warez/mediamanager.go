@@ -0,0 +1,118 @@
+package warez
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-kit/kit/log/level"
+
+	"warezbot/media"
+	"warezbot/slack"
+)
+
+// MediaManager finds titles of one kind and adds them to be downloaded, e.g.
+// Radarr for movies.
+type MediaManager interface {
+	Search(ctx context.Context, searchTerm []string) (media.Items, error)
+	Add(ctx context.Context, id string) (media.Item, error)
+	Queue(ctx context.Context) ([]media.QueueItem, error)
+}
+
+// addMedia searches the manager of the kind and posts the results to pick one
+// to download.
+func (s *service) addMedia(ctx context.Context, c Chat, to Conversation, kind string, args []string) {
+	manager, ok := s.managers[kind]
+	if !ok {
+		s.answer(c, to, "Adding %ss is not set up.", kind)
+		return
+	}
+	if len(args) == 0 {
+		s.answer(c, to, "Usage: add %s <title>", kind)
+		return
+	}
+
+	items, err := manager.Search(ctx, args)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.answer(c, to, "Failed to search for %s: %v", strings.Join(args, " "), err)
+		return
+	}
+	if err := c.PostSearch(to, kind, items); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+}
+
+// download requests the item picked from the search results.
+func (s *service) download(ctx context.Context, request SlackAction, kind string) {
+	if _, ok := s.managers[kind]; !ok {
+		return
+	}
+
+	action := request.Actions[0]
+	s.slack.MsgUpdate(ctx, request.OriginalMessage.Ts, request.User.Name, action.Value)
+	r, err := s.requestMedia(ctx, kind, action.Name, request.User.ID, sourceSlack)
+	if err == errAlreadyRequested {
+		err = fmt.Errorf("it was already requested by <@%s> on %s", r.User, r.Requested.Format("Jan 2"))
+	}
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		if err := s.slack.PostEphemeral(request.Channel.ID, request.User.ID, fmt.Sprintf("Failed to add %s: %v", action.Value, err)); err != nil {
+			level.Error(s.logger).Log("error", err)
+		}
+	}
+}
+
+// downloadKind returns the kind of item the download prompt was for.
+func (s *service) downloadKind(callbackID string) (string, bool) {
+	for kind := range s.managers {
+		if callbackID == slack.DownloadCallback(kind) {
+			return kind, true
+		}
+	}
+	return "", false
+}
+
+// queue lists what every media manager is downloading.
+func (s *service) queue(ctx context.Context, c Chat, to Conversation) {
+	var kinds []string
+	for kind := range s.managers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	var rows [][]string
+	seen := make(map[media.QueueItem]bool)
+	for _, kind := range kinds {
+		items, err := s.managers[kind].Queue(ctx)
+		if err != nil {
+			level.Error(s.logger).Log("error", err)
+			s.answer(c, to, "Failed to get the %s queue: %v", kind, err)
+			continue
+		}
+		for _, item := range items {
+			// Managers can share a queue, like artists and albums do in
+			// Lidarr.
+			if seen[item] {
+				continue
+			}
+			seen[item] = true
+
+			timeLeft := "-"
+			if item.TimeLeft > 0 {
+				timeLeft = formatDuration(item.TimeLeft)
+			}
+			rows = append(rows, []string{
+				item.Title,
+				item.Status,
+				fmt.Sprintf("%.0f%%", item.Progress()),
+				timeLeft,
+			})
+		}
+	}
+
+	if err := c.PostTable(to, "Download queue", []string{"Title", "Status", "Done", "Left"}, rows); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+}
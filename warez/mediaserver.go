@@ -0,0 +1,186 @@
+package warez
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"warezbot/emby"
+)
+
+var errUnsupported = errors.New("not supported by this media server")
+
+// MediaServer is the server the bot reports on. Results use the emby types,
+// which the other backends convert their responses to.
+type MediaServer interface {
+	URL() string
+	Sessions(ctx context.Context) (emby.Sessions, error)
+	Search(ctx context.Context, searchTerm []string) (emby.SearchResults, error)
+}
+
+// userManager is implemented by media servers whose accounts can be managed
+// by the bot.
+type userManager interface {
+	Users(ctx context.Context) (emby.Users, error)
+	UserByName(ctx context.Context, name string) (emby.User, error)
+	CreateUser(ctx context.Context, name string) (emby.User, error)
+	DeleteUser(ctx context.Context, id string) error
+	SetPassword(ctx context.Context, id string, password string) error
+	SetUserDisabled(ctx context.Context, id string, disabled bool) error
+	ApplyPolicy(ctx context.Context, id string, template emby.PolicyTemplate) error
+}
+
+// playMethodReporter is implemented by media servers that can tell whether a
+// session is transcoded.
+type playMethodReporter interface {
+	PlayMethod(ctx context.Context, sessionID string) (string, error)
+}
+
+// historyReporter is implemented by media servers that keep track of what was
+// played.
+type historyReporter interface {
+	PlayedItems(ctx context.Context, userID string, limit int) (emby.PlayedItems, error)
+	PlaybackActivity(ctx context.Context, since time.Time) (emby.Plays, error)
+}
+
+// libraryManager is implemented by media servers whose libraries can be
+// listed and scanned by the bot.
+type libraryManager interface {
+	Libraries(ctx context.Context) (emby.Libraries, error)
+	LibraryStats(ctx context.Context) ([]emby.LibraryStat, error)
+	RefreshLibrary(ctx context.Context) error
+	RefreshItem(ctx context.Context, id string) error
+}
+
+// taskManager is implemented by media servers whose scheduled tasks can be
+// run by the bot.
+type taskManager interface {
+	ScheduledTasks(ctx context.Context) (emby.ScheduledTasks, error)
+	ScheduledTask(ctx context.Context, id string) (emby.ScheduledTask, error)
+	RunScheduledTask(ctx context.Context, id string) error
+}
+
+// mediaServer gives the handlers every capability, failing with
+// errUnsupported where the configured server lacks one.
+type mediaServer struct {
+	MediaServer
+}
+
+func (m mediaServer) Users(ctx context.Context) (emby.Users, error) {
+	if u, ok := m.MediaServer.(userManager); ok {
+		return u.Users(ctx)
+	}
+	return nil, errUnsupported
+}
+
+func (m mediaServer) UserByName(ctx context.Context, name string) (emby.User, error) {
+	if u, ok := m.MediaServer.(userManager); ok {
+		return u.UserByName(ctx, name)
+	}
+	return emby.User{}, errUnsupported
+}
+
+func (m mediaServer) CreateUser(ctx context.Context, name string) (emby.User, error) {
+	if u, ok := m.MediaServer.(userManager); ok {
+		return u.CreateUser(ctx, name)
+	}
+	return emby.User{}, errUnsupported
+}
+
+func (m mediaServer) DeleteUser(ctx context.Context, id string) error {
+	if u, ok := m.MediaServer.(userManager); ok {
+		return u.DeleteUser(ctx, id)
+	}
+	return errUnsupported
+}
+
+func (m mediaServer) SetPassword(ctx context.Context, id string, password string) error {
+	if u, ok := m.MediaServer.(userManager); ok {
+		return u.SetPassword(ctx, id, password)
+	}
+	return errUnsupported
+}
+
+func (m mediaServer) SetUserDisabled(ctx context.Context, id string, disabled bool) error {
+	if u, ok := m.MediaServer.(userManager); ok {
+		return u.SetUserDisabled(ctx, id, disabled)
+	}
+	return errUnsupported
+}
+
+func (m mediaServer) ApplyPolicy(ctx context.Context, id string, template emby.PolicyTemplate) error {
+	if u, ok := m.MediaServer.(userManager); ok {
+		return u.ApplyPolicy(ctx, id, template)
+	}
+	return errUnsupported
+}
+
+func (m mediaServer) PlayMethod(ctx context.Context, sessionID string) (string, error) {
+	if p, ok := m.MediaServer.(playMethodReporter); ok {
+		return p.PlayMethod(ctx, sessionID)
+	}
+	return "", errUnsupported
+}
+
+func (m mediaServer) PlayedItems(ctx context.Context, userID string, limit int) (emby.PlayedItems, error) {
+	if p, ok := m.MediaServer.(historyReporter); ok {
+		return p.PlayedItems(ctx, userID, limit)
+	}
+	return emby.PlayedItems{}, errUnsupported
+}
+
+func (m mediaServer) PlaybackActivity(ctx context.Context, since time.Time) (emby.Plays, error) {
+	if p, ok := m.MediaServer.(historyReporter); ok {
+		return p.PlaybackActivity(ctx, since)
+	}
+	return nil, errUnsupported
+}
+
+func (m mediaServer) Libraries(ctx context.Context) (emby.Libraries, error) {
+	if l, ok := m.MediaServer.(libraryManager); ok {
+		return l.Libraries(ctx)
+	}
+	return nil, errUnsupported
+}
+
+func (m mediaServer) LibraryStats(ctx context.Context) ([]emby.LibraryStat, error) {
+	if l, ok := m.MediaServer.(libraryManager); ok {
+		return l.LibraryStats(ctx)
+	}
+	return nil, errUnsupported
+}
+
+func (m mediaServer) RefreshLibrary(ctx context.Context) error {
+	if l, ok := m.MediaServer.(libraryManager); ok {
+		return l.RefreshLibrary(ctx)
+	}
+	return errUnsupported
+}
+
+func (m mediaServer) RefreshItem(ctx context.Context, id string) error {
+	if l, ok := m.MediaServer.(libraryManager); ok {
+		return l.RefreshItem(ctx, id)
+	}
+	return errUnsupported
+}
+
+func (m mediaServer) ScheduledTasks(ctx context.Context) (emby.ScheduledTasks, error) {
+	if t, ok := m.MediaServer.(taskManager); ok {
+		return t.ScheduledTasks(ctx)
+	}
+	return nil, errUnsupported
+}
+
+func (m mediaServer) ScheduledTask(ctx context.Context, id string) (emby.ScheduledTask, error) {
+	if t, ok := m.MediaServer.(taskManager); ok {
+		return t.ScheduledTask(ctx, id)
+	}
+	return emby.ScheduledTask{}, errUnsupported
+}
+
+func (m mediaServer) RunScheduledTask(ctx context.Context, id string) error {
+	if t, ok := m.MediaServer.(taskManager); ok {
+		return t.RunScheduledTask(ctx, id)
+	}
+	return errUnsupported
+}
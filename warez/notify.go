@@ -0,0 +1,141 @@
+package warez
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+
+	"warezbot/notify"
+)
+
+// Events sent to the notifiers.
+const (
+	EventRequestCreated  = "request.created"
+	EventDownloadStarted = "download.started"
+	EventItemAvailable   = "item.available"
+	EventPlaybackStarted = "playback.started"
+)
+
+const (
+	notifyTimeout = 30 * time.Second
+	// requestPollInterval is how often the queues and requested titles are
+	// checked for downloads that started or finished.
+	requestPollInterval = 5 * time.Minute
+)
+
+// Notifier sends notifications outside of Slack.
+type Notifier interface {
+	Notify(ctx context.Context, m notify.Message) error
+}
+
+// Sink is a notifier and the events it gets. It gets every event if Events
+// is empty.
+type Sink struct {
+	Notifier Notifier
+	Events   []string
+}
+
+func (s Sink) wants(event string) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, e := range s.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// notify sends the message to every sink that wants it, without waiting for
+// them.
+func (s *service) notify(m notify.Message) {
+	if m.Time.IsZero() {
+		m.Time = time.Now()
+	}
+	for _, sink := range s.sinks {
+		if !sink.wants(m.Event) {
+			continue
+		}
+		go func(n Notifier) {
+			ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+			defer cancel()
+			if err := n.Notify(ctx, m); err != nil {
+				level.Error(s.logger).Log("event", "failed to notify", "notification", m.Event, "error", err)
+			}
+		}(sink.Notifier)
+	}
+}
+
+// notifyRequest sends a notification about the request.
+func (s *service) notifyRequest(event string, r MediaRequest) {
+	title := r.Title
+	if r.Year > 0 {
+		title = fmt.Sprintf("%s (%d)", r.Title, r.Year)
+	}
+
+	m := notify.Message{
+		Event: event,
+		User:  s.userName(r.User),
+	}
+	switch event {
+	case EventRequestCreated:
+		m.Title = "New request"
+		m.Message = fmt.Sprintf("%s requested the %s %s", m.User, r.Kind, title)
+	case EventItemAvailable:
+		m.Title = "Now available"
+		m.Message = fmt.Sprintf("%s, requested by %s, is available", title, m.User)
+	}
+	s.notify(m)
+}
+
+// watchRequests notifies when the media managers start downloading
+// something and when requested titles become available. It never returns.
+func (s *service) watchRequests(interval time.Duration) {
+	// Downloads queued before the bot started are not new.
+	var seen map[string]bool
+
+	for {
+		ctx := context.Background()
+		queued := make(map[string]bool)
+		ok := true
+		for kind, manager := range s.managers {
+			items, err := manager.Queue(ctx)
+			if err != nil {
+				level.Warn(s.logger).Log("event", "failed to check queue", "kind", kind, "error", err)
+				ok = false
+				continue
+			}
+			for _, item := range items {
+				queued[item.Release] = true
+				if seen == nil || seen[item.Release] {
+					continue
+				}
+				s.notify(notify.Message{
+					Event:   EventDownloadStarted,
+					Title:   "Download started",
+					Message: fmt.Sprintf("Downloading %s", item.Title),
+				})
+			}
+		}
+		// Keep what was seen when a queue could not be checked, or its
+		// downloads would be announced again.
+		if ok {
+			seen = queued
+		} else if seen != nil {
+			for release := range queued {
+				seen[release] = true
+			}
+		}
+
+		for _, r := range s.requests.list("") {
+			if r.Status == requestProcessing {
+				s.refreshRequest(ctx, r)
+			}
+		}
+
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,286 @@
+package warez
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+
+	"warezbot/notify"
+	"warezbot/slack"
+)
+
+const (
+	playbackStart = "playback.start"
+	playbackStop  = "playback.stop"
+
+	// playbackRetention is how long finished sessions are kept around.
+	playbackRetention = 90 * 24 * time.Hour
+	// abandonedAfter is how long a session can go without a stop event
+	// before it is considered lost.
+	abandonedAfter = 24 * time.Hour
+
+	reportTopLimit = 5
+)
+
+// PlaybackSession is one user playing one item on one device, from the start
+// to the stop event Emby sent for it.
+type PlaybackSession struct {
+	Key        string    `json:"key"`
+	UserID     string    `json:"user_id"`
+	UserName   string    `json:"user_name"`
+	ItemID     string    `json:"item_id"`
+	ItemName   string    `json:"item_name"`
+	ItemType   string    `json:"item_type"`
+	SeriesName string    `json:"series_name,omitempty"`
+	Device     string    `json:"device"`
+	Client     string    `json:"client"`
+	PlayMethod string    `json:"play_method,omitempty"`
+	Start      time.Time `json:"start"`
+	Stop       time.Time `json:"stop"`
+}
+
+func (p PlaybackSession) Duration() time.Duration {
+	return p.Stop.Sub(p.Start)
+}
+
+// Title is the movie or series name of the item played.
+func (p PlaybackSession) Title() string {
+	if p.SeriesName != "" {
+		return p.SeriesName
+	}
+	return p.ItemName
+}
+
+// playbackLog persists the playback sessions seen in Emby events.
+type playbackLog struct {
+	mu   sync.Mutex
+	file jsonFile
+
+	Sessions []PlaybackSession `json:"sessions"`
+}
+
+func loadPlaybackLog(file jsonFile) (*playbackLog, error) {
+	l := &playbackLog{file: file}
+	if err := file.load(l); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// start records the session, forgetting the ones prune would so that the log
+// doesn't grow without a weekly report to prune it.
+func (l *playbackLog) start(p PlaybackSession) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.drop(p.Start)
+	l.Sessions = append(l.Sessions, p)
+	return l.file.save(l)
+}
+
+// stop ends the most recent open session with the key.
+func (l *playbackLog) stop(key string, at time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if i := l.open(key); i >= 0 {
+		l.Sessions[i].Stop = at
+		return l.file.save(l)
+	}
+	return nil
+}
+
+func (l *playbackLog) setPlayMethod(key string, method string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if i := l.open(key); i >= 0 {
+		l.Sessions[i].PlayMethod = method
+		return l.file.save(l)
+	}
+	return nil
+}
+
+// open returns the index of the most recent open session with the key, or -1.
+func (l *playbackLog) open(key string) int {
+	for i := len(l.Sessions) - 1; i >= 0; i-- {
+		if l.Sessions[i].Key == key && l.Sessions[i].Stop.IsZero() {
+			return i
+		}
+	}
+	return -1
+}
+
+// finished returns the sessions that stopped between from and to.
+func (l *playbackLog) finished(from time.Time, to time.Time) []PlaybackSession {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var sessions []PlaybackSession
+	for _, p := range l.Sessions {
+		if !p.Stop.IsZero() && !p.Stop.Before(from) && p.Stop.Before(to) {
+			sessions = append(sessions, p)
+		}
+	}
+	return sessions
+}
+
+// prune forgets old sessions and the ones that never got a stop event.
+func (l *playbackLog) prune(now time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.drop(now)
+	return l.file.save(l)
+}
+
+// drop removes the sessions prune forgets without saving the log.
+func (l *playbackLog) drop(now time.Time) {
+	var kept []PlaybackSession
+	for _, p := range l.Sessions {
+		if p.Stop.IsZero() && now.Sub(p.Start) > abandonedAfter {
+			continue
+		}
+		if !p.Stop.IsZero() && now.Sub(p.Stop) > playbackRetention {
+			continue
+		}
+		kept = append(kept, p)
+	}
+	l.Sessions = kept
+}
+
+// recordPlayback keeps track of the playback sessions reported by Emby.
+func (s *service) recordPlayback(request EmbyEvent) {
+	key := request.Session.ID + "/" + request.Item.ID
+
+	switch request.Event {
+	case playbackStart:
+		session := PlaybackSession{
+			Key:        key,
+			UserID:     request.User.ID,
+			UserName:   request.User.Name,
+			ItemID:     request.Item.ID,
+			ItemName:   request.Item.Name,
+			ItemType:   request.Item.Type,
+			SeriesName: request.Item.SeriesName,
+			Device:     request.Session.DeviceName,
+			Client:     request.Session.Client,
+			Start:      time.Now(),
+		}
+		s.notify(notify.Message{
+			Event:   EventPlaybackStarted,
+			Title:   "Playback started",
+			Message: fmt.Sprintf("%s is watching %s on %s", session.UserName, session.Title(), session.Device),
+			User:    session.UserName,
+		})
+		if err := s.playback.start(session); err != nil {
+			level.Error(s.logger).Log("error", err)
+			return
+		}
+
+		// The webhook does not say how the item is played, so ask Emby.
+		go func() {
+			method, err := s.media.PlayMethod(context.Background(), request.Session.ID)
+			if err != nil {
+				level.Warn(s.logger).Log("event", "failed to get play method", "error", err)
+				return
+			}
+			if err := s.playback.setPlayMethod(key, method); err != nil {
+				level.Error(s.logger).Log("error", err)
+			}
+		}()
+	case playbackStop:
+		if err := s.playback.stop(key, time.Now()); err != nil {
+			level.Error(s.logger).Log("error", err)
+		}
+	}
+}
+
+// usage summarizes a set of playback sessions.
+type usage struct {
+	total      time.Duration
+	plays      int
+	users      tally
+	titles     tally
+	peak       int
+	peakAt     time.Time
+	transcodes int
+	methods    int
+}
+
+func summarize(sessions []PlaybackSession) usage {
+	u := usage{
+		plays:  len(sessions),
+		users:  tally{},
+		titles: tally{},
+	}
+
+	type edge struct {
+		at    time.Time
+		delta int
+	}
+	var edges []edge
+	for _, p := range sessions {
+		u.total += p.Duration()
+		u.users.add(p.UserName, p.Duration())
+		u.titles.add(p.Title(), p.Duration())
+		if p.PlayMethod != "" {
+			u.methods++
+			if p.PlayMethod == "Transcode" {
+				u.transcodes++
+			}
+		}
+		edges = append(edges, edge{p.Start, 1}, edge{p.Stop, -1})
+	}
+
+	// Sweep through starts and stops, stops first when they coincide, to
+	// find how many sessions ran at the same time.
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].at.Equal(edges[j].at) {
+			return edges[i].delta < edges[j].delta
+		}
+		return edges[i].at.Before(edges[j].at)
+	})
+	var running int
+	for _, e := range edges {
+		running += e.delta
+		if running > u.peak {
+			u.peak = running
+			u.peakAt = e.at
+		}
+	}
+
+	return u
+}
+
+func (s *service) postWeeklyReport(ctx context.Context) {
+	now := time.Now()
+	if err := s.playback.prune(now); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+
+	u := summarize(s.playback.finished(now.AddDate(0, 0, -7), now))
+
+	transcodeRatio := "n/a"
+	if u.methods > 0 {
+		transcodeRatio = fmt.Sprintf("%.0f%% (%d of %d)", float64(u.transcodes)*100/float64(u.methods), u.transcodes, u.methods)
+	}
+	peak := "n/a"
+	if u.peak > 0 {
+		peak = fmt.Sprintf("%d streams on %s", u.peak, u.peakAt.Local().Format("Mon Jan 02 15:04"))
+	}
+
+	s.slack.PostStats("Weekly Emby usage report", []slack.Stat{
+		{Name: "Total watch time", Value: fmt.Sprintf("%.1f hours", u.total.Hours())},
+		{Name: "Plays", Value: fmt.Sprintf("%d", u.plays)},
+		{Name: "Peak concurrency", Value: peak},
+		{Name: "Transcoded", Value: transcodeRatio},
+	})
+	s.slack.PostTable("Top users this week", []string{"User", "Plays", "Time"}, countRows(u.users.top(reportTopLimit)))
+	s.slack.PostTable("Top titles this week", []string{"Title", "Plays", "Time"}, countRows(u.titles.top(reportTopLimit)))
+}
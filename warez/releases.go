@@ -0,0 +1,185 @@
+package warez
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+
+	"warezbot/media"
+	"warezbot/radarr"
+	"warezbot/torznab"
+)
+
+const (
+	// releasesLimit is how many releases are listed at most, the best seeded
+	// first.
+	releasesLimit = 10
+	// releasesTTL is how long the releases listed can be grabbed.
+	releasesTTL = time.Hour
+)
+
+// releaseGrabber is implemented by movie managers that can search indexers
+// and grab a release picked by hand, i.e. Radarr.
+type releaseGrabber interface {
+	Releases(ctx context.Context, title string) ([]radarr.Release, error)
+	Grab(ctx context.Context, r radarr.Release) error
+}
+
+// Indexer searches indexers directly, e.g. Prowlarr or Jackett.
+type Indexer interface {
+	SearchMovies(ctx context.Context, query string) ([]torznab.Item, error)
+}
+
+// releaseCache keeps the releases listed so the buttons only need to carry a
+// short ID instead of the whole release.
+type releaseCache struct {
+	mu       sync.Mutex
+	releases map[string]cachedRelease
+}
+
+type cachedRelease struct {
+	release radarr.Release
+	expires time.Time
+}
+
+func newReleaseCache() *releaseCache {
+	return &releaseCache{
+		releases: make(map[string]cachedRelease),
+	}
+}
+
+// add stores the release and returns its ID.
+func (c *releaseCache) add(r radarr.Release) (string, error) {
+	id, err := newID()
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for k, cached := range c.releases {
+		if now.After(cached.expires) {
+			delete(c.releases, k)
+		}
+	}
+	c.releases[id] = cachedRelease{release: r, expires: now.Add(releasesTTL)}
+	return id, nil
+}
+
+func (c *releaseCache) get(id string) (radarr.Release, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.releases[id]
+	if !ok || time.Now().After(cached.expires) {
+		return radarr.Release{}, false
+	}
+	return cached.release, true
+}
+
+// releases lists the releases of a movie in Radarr with buttons to grab one.
+// With an indexer set up it is searched instead of the indexers of Radarr.
+func (s *service) releases(ctx context.Context, request SlackEvent, args []string) {
+	if !s.requireAdmin(request) {
+		return
+	}
+	grabber, ok := s.managers[media.Movie].(releaseGrabber)
+	if !ok {
+		s.reply(request, "Searching releases needs Radarr.")
+		return
+	}
+	if len(args) == 0 {
+		s.reply(request, "Usage: releases <movie>")
+		return
+	}
+	title := strings.Join(args, " ")
+
+	var found []radarr.Release
+	var err error
+	if s.indexer != nil {
+		found, err = s.indexerReleases(ctx, title)
+	} else {
+		found, err = grabber.Releases(ctx, title)
+	}
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.reply(request, "Failed to search releases of %s: %v", title, err)
+		return
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].Seeders > found[j].Seeders
+	})
+	if len(found) > releasesLimit {
+		found = found[:releasesLimit]
+	}
+
+	ids := make([]string, len(found))
+	for i, r := range found {
+		if ids[i], err = s.releaseCache.add(r); err != nil {
+			level.Error(s.logger).Log("error", err)
+			return
+		}
+	}
+
+	s.slack.PostReleases(title, found, ids)
+}
+
+// indexerReleases searches the indexer for the movie. Torznab does not know
+// the quality, so it is guessed from the release name.
+func (s *service) indexerReleases(ctx context.Context, title string) ([]radarr.Release, error) {
+	items, err := s.indexer.SearchMovies(ctx, title)
+	if err != nil {
+		return nil, err
+	}
+
+	releases := make([]radarr.Release, len(items))
+	for i, item := range items {
+		releases[i] = radarr.Release{
+			GUID:        item.GUID,
+			Title:       item.Title,
+			Size:        item.Size,
+			Indexer:     item.Indexer,
+			Seeders:     item.Seeders,
+			Leechers:    item.Peers - item.Seeders,
+			Quality:     torznab.Quality(item.Title),
+			Protocol:    "torrent",
+			DownloadURL: item.Link,
+			PublishDate: item.PublishDate,
+		}
+	}
+	return releases, nil
+}
+
+// grabRelease sends the release of the button that was clicked to Radarr.
+func (s *service) grabRelease(ctx context.Context, request SlackAction) {
+	if !s.isAdmin(request.User.ID) {
+		if err := s.slack.PostEphemeral(request.Channel.ID, request.User.ID, "Sorry, only admins can do that."); err != nil {
+			level.Error(s.logger).Log("error", err)
+		}
+		return
+	}
+	grabber, ok := s.managers[media.Movie].(releaseGrabber)
+	if !ok {
+		return
+	}
+
+	r, ok := s.releaseCache.get(request.Actions[0].Value)
+	if !ok {
+		s.postThread(request.Channel.ID, request.MessageTs, "That release has expired, search again.")
+		return
+	}
+
+	if err := grabber.Grab(ctx, r); err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.postThread(request.Channel.ID, request.MessageTs, fmt.Sprintf("Failed to grab %s: %v", r.Title, err))
+		return
+	}
+	s.audit.Log("action", "release_grab", "admin", request.User.ID, "release", r.Title, "indexer", r.Indexer)
+	s.postThread(request.Channel.ID, request.MessageTs, fmt.Sprintf("<@%s> grabbed %s", request.User.ID, r.Title))
+}
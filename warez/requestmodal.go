@@ -0,0 +1,154 @@
+package warez
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/log/level"
+
+	"warezbot/downloader"
+	"warezbot/media"
+	"warezbot/radarr"
+	"warezbot/slack"
+)
+
+// advancedAdder is implemented by media managers that let the requester pick
+// the quality profile and root folder of a title, e.g. Radarr.
+type advancedAdder interface {
+	QualityProfiles(ctx context.Context) ([]radarr.QualityProfile, error)
+	RootFolders(ctx context.Context) ([]radarr.RootFolder, error)
+	AddWithOptions(ctx context.Context, id string, opts radarr.AddOptions) (media.Item, error)
+}
+
+// replyAction answers the user who clicked with a message only they can see.
+func (s *service) replyAction(request SlackAction, format string, a ...interface{}) {
+	if err := s.slack.PostEphemeral(request.Channel.ID, request.User.ID, fmt.Sprintf(format, a...)); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+}
+
+// openRequestModal asks the user how to request the movie they picked.
+func (s *service) openRequestModal(ctx context.Context, request SlackAction) {
+	adder, ok := s.managers[media.Movie].(advancedAdder)
+	if !ok {
+		s.replyAction(request, "Requesting with options is not supported.")
+		return
+	}
+
+	profiles, err := adder.QualityProfiles(ctx)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.replyAction(request, "Failed to get the quality profiles: %v", err)
+		return
+	}
+	folders, err := adder.RootFolders(ctx)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.replyAction(request, "Failed to get the root folders: %v", err)
+		return
+	}
+
+	action := request.Actions[0]
+	modal := slack.RequestModal{
+		Kind:    media.Movie,
+		ID:      action.Name,
+		Title:   action.Value,
+		Channel: request.Channel.ID,
+	}
+	for _, p := range profiles {
+		modal.Profiles = append(modal.Profiles, slack.Choice{Name: p.Name, Value: strconv.Itoa(p.ID)})
+	}
+	for _, f := range folders {
+		modal.Folders = append(modal.Folders, slack.Choice{
+			Name:  fmt.Sprintf("%s (%s free)", f.Path, downloader.FormatBytes(f.FreeSpace)),
+			Value: f.Path,
+		})
+	}
+
+	if err := s.slack.OpenRequestModal(ctx, request.TriggerID, modal); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+}
+
+// submitRequest handles the submission of the request modal. What can be
+// checked right away is reported inline; the title is then added in the
+// background, as Slack only waits 3 seconds for an answer.
+func (s *service) submitRequest(ctx context.Context, request SlackAction) (Response, error) {
+	modal, err := slack.ParseRequestModal(request.View.PrivateMetadata)
+	if err != nil {
+		return Response{}, err
+	}
+	if _, ok := s.managers[modal.Kind].(advancedAdder); !ok {
+		return Response{}, fmt.Errorf("requesting %ss with options is not supported", modal.Kind)
+	}
+
+	problems := make(map[string]string)
+	profileID, err := strconv.Atoi(request.value(slack.RequestProfileBlock, slack.RequestProfileAction).SelectedOption.Value)
+	if err != nil {
+		problems[slack.RequestProfileBlock] = "Pick a quality profile."
+	}
+	folder := request.value(slack.RequestFolderBlock, slack.RequestFolderAction).SelectedOption.Value
+	if folder == "" {
+		problems[slack.RequestFolderBlock] = "Pick a root folder."
+	}
+	// Problems with the request itself go under the note, so they don't hide
+	// the ones with the fields.
+	if r, ok := s.requests.active(modal.Kind, modal.ID); ok {
+		problems[slack.RequestNoteBlock] = fmt.Sprintf("It was already requested by %s on %s.", s.userName(r.User), r.Requested.Format("Jan 2"))
+	} else if s.quotaLeft(request.User.ID) == 0 {
+		problems[slack.RequestNoteBlock] = "You have used up your requests for the week."
+	}
+	if len(problems) > 0 {
+		return Response{
+			StatusCode: http.StatusOK,
+			Payload:    viewErrors(problems),
+		}, nil
+	}
+
+	opts := radarr.AddOptions{
+		QualityProfileID: profileID,
+		RootFolderPath:   folder,
+		Monitored:        len(request.value(slack.RequestMonitorBlock, slack.RequestMonitorAction).SelectedOptions) > 0,
+	}
+	note := strings.TrimSpace(request.value(slack.RequestNoteBlock, slack.RequestNoteAction).Value)
+	go s.requestWithOptions(context.Background(), request.User.ID, modal, opts, note)
+
+	return Response{
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+// requestWithOptions requests the title of the modal and passes the note on
+// to the admins.
+func (s *service) requestWithOptions(ctx context.Context, user string, modal slack.RequestModal, opts radarr.AddOptions, note string) {
+	adder := s.managers[modal.Kind].(advancedAdder)
+	add := func(ctx context.Context, id string) (media.Item, error) {
+		return adder.AddWithOptions(ctx, id, opts)
+	}
+
+	r, err := s.requestMediaWith(ctx, modal.Kind, modal.ID, user, sourceSlack, note, add)
+	if err == errAlreadyRequested {
+		err = fmt.Errorf("it was already requested by <@%s> on %s", r.User, r.Requested.Format("Jan 2"))
+	}
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		if err := s.slack.PostEphemeral(modal.Channel, user, fmt.Sprintf("Failed to add %s: %v", modal.Title, err)); err != nil {
+			level.Error(s.logger).Log("error", err)
+		}
+		return
+	}
+
+	text := fmt.Sprintf("Download process started by <@%s> for %s", user, modal.Title)
+	if err := s.slack.PostEphemeral(modal.Channel, user, text); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+	if note != "" {
+		text := fmt.Sprintf("<@%s> requested %s to %s:\n>%s", user, modal.Title, opts.RootFolderPath, strings.Replace(note, "\n", "\n>", -1))
+		if err := s.slack.PostAdmin(text); err != nil {
+			level.Error(s.logger).Log("error", err)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package warez
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+
+	"warezbot/media"
+)
+
+const (
+	requestProcessing = "processing"
+	requestAvailable  = "available"
+	requestFailed     = "failed"
+
+	// Requests come from Slack or the request API.
+	sourceSlack = "slack"
+	sourceAPI   = "api"
+
+	requestsLimit = 15
+	// quotaPeriod is the period request quotas apply to.
+	quotaPeriod = 7 * 24 * time.Hour
+)
+
+var (
+	errAlreadyRequested = errors.New("already requested")
+	errQuotaExceeded    = errors.New("you have used up your requests for the week")
+)
+
+// availabilityChecker is implemented by media managers that can tell whether
+// a title they added has been downloaded.
+type availabilityChecker interface {
+	Available(ctx context.Context, id string) (bool, error)
+}
+
+// MediaRequest is a title someone asked for, through Slack or the request
+// API.
+type MediaRequest struct {
+	ID      int    `json:"id"`
+	Kind    string `json:"kind"`
+	MediaID string `json:"media_id"`
+	Title   string `json:"title"`
+	Year    int    `json:"year"`
+	User    string `json:"user"`
+	Source  string `json:"source"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+	// Note is what the user told the admins about the request.
+	Note      string    `json:"note,omitempty"`
+	Requested time.Time `json:"requested"`
+	Updated   time.Time `json:"updated"`
+}
+
+// requestLog is the history of every request.
+type requestLog struct {
+	mu   sync.Mutex
+	file jsonFile
+
+	LastID   int            `json:"last_id"`
+	Requests []MediaRequest `json:"requests"`
+}
+
+func loadRequestLog(file jsonFile) (*requestLog, error) {
+	l := &requestLog{file: file}
+	if err := file.load(l); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// reserve records the request under a new ID, unless the title has an
+// active request already or the user made limit requests within quotaPeriod,
+// 0 meaning no limit. Checking and recording under one lock keeps concurrent
+// requests from both getting through. The error is errAlreadyRequested,
+// along with the active request, errQuotaExceeded, or a failure to save the
+// reserved request.
+func (l *requestLog) reserve(r MediaRequest, limit int) (MediaRequest, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if active, ok := l.activeLocked(r.Kind, r.MediaID); ok {
+		return active, errAlreadyRequested
+	}
+	if limit > 0 && l.usedLocked(r.User, r.Requested) >= limit {
+		return MediaRequest{}, errQuotaExceeded
+	}
+
+	l.LastID++
+	r.ID = l.LastID
+	l.Requests = append(l.Requests, r)
+	return r, l.file.save(l)
+}
+
+// update replaces the request with the same ID.
+func (l *requestLog) update(r MediaRequest) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i := range l.Requests {
+		if l.Requests[i].ID == r.ID {
+			l.Requests[i] = r
+			return l.file.save(l)
+		}
+	}
+	return fmt.Errorf("no request %d", r.ID)
+}
+
+func (l *requestLog) get(id int) (MediaRequest, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, r := range l.Requests {
+		if r.ID == id {
+			return r, true
+		}
+	}
+	return MediaRequest{}, false
+}
+
+// active returns the latest request for the title that did not fail.
+func (l *requestLog) active(kind string, mediaID string) (MediaRequest, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.activeLocked(kind, mediaID)
+}
+
+func (l *requestLog) activeLocked(kind string, mediaID string) (MediaRequest, bool) {
+	for i := len(l.Requests) - 1; i >= 0; i-- {
+		r := l.Requests[i]
+		if r.Kind == kind && r.MediaID == mediaID && r.Status != requestFailed {
+			return r, true
+		}
+	}
+	return MediaRequest{}, false
+}
+
+// used returns how many requests the user made within quotaPeriod before
+// now. Failed requests don't count.
+func (l *requestLog) used(user string, now time.Time) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.usedLocked(user, now)
+}
+
+func (l *requestLog) usedLocked(user string, now time.Time) int {
+	since := now.Add(-quotaPeriod)
+	used := 0
+	for _, r := range l.Requests {
+		if r.User == user && r.Status != requestFailed && r.Requested.After(since) {
+			used++
+		}
+	}
+	return used
+}
+
+// setStatus moves the request from one status to another. It reports false
+// if the request did not have the status from, e.g. because it was updated
+// meanwhile.
+func (l *requestLog) setStatus(id int, from string, to string) (MediaRequest, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i := range l.Requests {
+		if l.Requests[i].ID == id {
+			if l.Requests[i].Status != from {
+				return l.Requests[i], false, nil
+			}
+			l.Requests[i].Status = to
+			l.Requests[i].Updated = time.Now()
+			return l.Requests[i], true, l.file.save(l)
+		}
+	}
+	return MediaRequest{}, false, fmt.Errorf("no request %d", id)
+}
+
+// list returns the requests of the user, or of everyone if user is empty,
+// the newest first.
+func (l *requestLog) list(user string) []MediaRequest {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var requests []MediaRequest
+	for i := len(l.Requests) - 1; i >= 0; i-- {
+		if user == "" || l.Requests[i].User == user {
+			requests = append(requests, l.Requests[i])
+		}
+	}
+	return requests
+}
+
+// requestMedia adds the title to its media manager on behalf of the Slack
+// user and records the request. It is the one way titles get requested, from
+// Slack or the request API. A title can't be requested again unless the
+// earlier request failed.
+func (s *service) requestMedia(ctx context.Context, kind string, id string, user string, source string) (MediaRequest, error) {
+	manager, ok := s.managers[kind]
+	if !ok {
+		return MediaRequest{}, fmt.Errorf("adding %ss is not set up", kind)
+	}
+	return s.requestMediaWith(ctx, kind, id, user, source, "", manager.Add)
+}
+
+// requestMediaWith is requestMedia with the note of the user and the way the
+// title is added, e.g. with options picked by the user.
+func (s *service) requestMediaWith(ctx context.Context, kind string, id string, user string, source string, note string, add func(context.Context, string) (media.Item, error)) (MediaRequest, error) {
+	now := time.Now()
+	r := MediaRequest{
+		Kind:      kind,
+		MediaID:   id,
+		Title:     id,
+		User:      user,
+		Source:    source,
+		Status:    requestProcessing,
+		Note:      note,
+		Requested: now,
+		Updated:   now,
+	}
+	limit := s.requestQuota
+	if s.isAdmin(user) {
+		limit = 0
+	}
+	// The request is reserved before the title is added, so the same title
+	// or a request over the quota can't get in meanwhile.
+	r, err := s.requests.reserve(r, limit)
+	switch err {
+	case nil:
+	case errAlreadyRequested, errQuotaExceeded:
+		return r, err
+	default:
+		level.Error(s.logger).Log("error", err)
+	}
+
+	item, err := add(ctx, id)
+	if err != nil {
+		// Failed requests don't count, which releases the reservation.
+		r.Status = requestFailed
+		r.Error = err.Error()
+	}
+	if item.Title != "" {
+		r.Title = item.Title
+	}
+	r.Year = item.Year
+	r.Updated = time.Now()
+
+	if saveErr := s.requests.update(r); saveErr != nil {
+		level.Error(s.logger).Log("error", saveErr)
+	}
+	if err != nil {
+		return r, err
+	}
+
+	s.audit.Log("action", "request", "user", user, "source", source, "kind", kind, "id", id, "title", r.Title)
+	s.notifyRequest(EventRequestCreated, r)
+	s.refreshHome(user)
+	return r, nil
+}
+
+// quotaLeft returns how many more titles the user may request this week, or
+// -1 if they have no limit. Failed requests don't count.
+func (s *service) quotaLeft(user string) int {
+	if s.requestQuota <= 0 || s.isAdmin(user) {
+		return -1
+	}
+
+	left := s.requestQuota - s.requests.used(user, time.Now())
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// refreshRequest marks the request available once its media manager has
+// downloaded it.
+func (s *service) refreshRequest(ctx context.Context, r MediaRequest) MediaRequest {
+	if r.Status != requestProcessing {
+		return r
+	}
+	checker, ok := s.managers[r.Kind].(availabilityChecker)
+	if !ok {
+		return r
+	}
+
+	available, err := checker.Available(ctx, r.MediaID)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		return r
+	}
+	if !available {
+		return r
+	}
+
+	updated, changed, err := s.requests.setStatus(r.ID, requestProcessing, requestAvailable)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		return r
+	}
+	if changed {
+		s.notifyRequest(EventItemAvailable, updated)
+		s.refreshHome(updated.User)
+	}
+	return updated
+}
+
+// listRequests shows the latest requests, from Slack and the request API
+// alike.
+func (s *service) listRequests(ctx context.Context, c Chat, to Conversation) {
+	requests := s.requests.list("")
+	if len(requests) > requestsLimit {
+		requests = requests[:requestsLimit]
+	}
+
+	var rows [][]string
+	for _, r := range requests {
+		r = s.refreshRequest(ctx, r)
+		title := r.Title
+		if r.Year > 0 {
+			title = fmt.Sprintf("%s (%d)", r.Title, r.Year)
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(r.ID),
+			title,
+			r.Kind,
+			s.userName(r.User),
+			r.Status,
+			r.Requested.Format("Jan 2"),
+		})
+	}
+
+	if err := c.PostTable(to, "Requests", []string{"#", "Title", "Kind", "By", "Status", "Date"}, rows); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+}
+
+// userName names the Slack user by their Emby account if they have one.
+func (s *service) userName(user string) string {
+	if l, ok := s.accounts.bySlackUser(user); ok {
+		return l.EmbyUser
+	}
+	return user
+}
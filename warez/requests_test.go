@@ -0,0 +1,88 @@
+package warez
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"warezbot/media"
+)
+
+// reserveConcurrently reserves the requests all at once and returns how many
+// got through.
+func reserveConcurrently(l *requestLog, requests []MediaRequest, limit int) int {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	reserved := 0
+	for _, r := range requests {
+		wg.Add(1)
+		go func(r MediaRequest) {
+			defer wg.Done()
+			if _, err := l.reserve(r, limit); err == nil {
+				mu.Lock()
+				reserved++
+				mu.Unlock()
+			}
+		}(r)
+	}
+	wg.Wait()
+	return reserved
+}
+
+func TestReserveSameTitle(t *testing.T) {
+	l := &requestLog{}
+	now := time.Now()
+	var requests []MediaRequest
+	for i := 0; i < 10; i++ {
+		requests = append(requests, MediaRequest{Kind: media.Movie, MediaID: "603", User: "U" + strconv.Itoa(i), Status: requestProcessing, Requested: now})
+	}
+
+	if n := reserveConcurrently(l, requests, 0); n != 1 {
+		t.Errorf("%d requests for the same title were reserved, want 1", n)
+	}
+	if r, err := l.reserve(requests[0], 0); err != errAlreadyRequested || r.MediaID != "603" {
+		t.Errorf("reserve() = %+v, %v, want the active request and errAlreadyRequested", r, err)
+	}
+}
+
+func TestReserveQuota(t *testing.T) {
+	l := &requestLog{}
+	now := time.Now()
+	// An old request and a failed one don't count.
+	l.Requests = []MediaRequest{
+		{ID: 1, Kind: media.Movie, MediaID: "1", User: "U1", Status: requestAvailable, Requested: now.Add(-8 * 24 * time.Hour)},
+		{ID: 2, Kind: media.Movie, MediaID: "2", User: "U1", Status: requestFailed, Requested: now},
+	}
+	l.LastID = 2
+	var requests []MediaRequest
+	for i := 0; i < 10; i++ {
+		requests = append(requests, MediaRequest{Kind: media.Movie, MediaID: strconv.Itoa(100 + i), User: "U1", Status: requestProcessing, Requested: now})
+	}
+
+	if n := reserveConcurrently(l, requests[:9], 2); n != 2 {
+		t.Errorf("%d requests were reserved with a quota of 2, want 2", n)
+	}
+	if _, err := l.reserve(requests[9], 2); err != errQuotaExceeded {
+		t.Errorf("reserve() over the quota = %v, want errQuotaExceeded", err)
+	}
+	if _, err := l.reserve(requests[9], 0); err != nil {
+		t.Errorf("reserve() without a quota = %v", err)
+	}
+}
+
+func TestReserveReleasedOnFailure(t *testing.T) {
+	l := &requestLog{}
+	r, err := l.reserve(MediaRequest{Kind: media.Movie, MediaID: "603", User: "U1", Status: requestProcessing, Requested: time.Now()}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Status = requestFailed
+	if err := l.update(r); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.reserve(MediaRequest{Kind: media.Movie, MediaID: "603", User: "U1", Status: requestProcessing, Requested: time.Now()}, 1); err != nil {
+		t.Errorf("reserve() after a failed request = %v, want the title and quota free again", err)
+	}
+}
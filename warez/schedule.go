@@ -0,0 +1,34 @@
+package warez
+
+import (
+	"context"
+	"time"
+)
+
+// ReportSchedule is the local weekday and hour a weekly report is posted at.
+type ReportSchedule struct {
+	Enabled bool
+	Weekday time.Weekday
+	Hour    int
+}
+
+// weekly calls fn every week on the given weekday and hour, local time. It
+// never returns.
+func (s *service) weekly(day time.Weekday, hour int, fn func(context.Context)) {
+	for {
+		time.Sleep(time.Until(nextWeekly(time.Now(), day, hour)))
+		fn(context.Background())
+	}
+}
+
+// nextWeekly returns the first time after now that falls on the weekday and
+// hour.
+func nextWeekly(now time.Time, day time.Weekday, hour int) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, now.Location())
+	next = next.AddDate(0, 0, (int(day)-int(now.Weekday())+7)%7)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 7)
+	}
+
+	return next
+}
@@ -6,9 +6,13 @@ import (
 	"strings"
 	"time"
 
+	"warezbot/discord"
 	"warezbot/emby"
-	"warezbot/radarr"
+	"warezbot/matrix"
+	"warezbot/mattermost"
+	"warezbot/media"
 	"warezbot/slack"
+	"warezbot/telegram"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
@@ -16,10 +20,39 @@ import (
 )
 
 const (
-	addMovie   = "add movie"
-	nowPlaying = "now playing"
-	ping       = "ping"
-	search     = "search"
+	addMovie     = "add movie"
+	addArtist    = "add artist"
+	addAlbum     = "add album"
+	addBook      = "add book"
+	addAudiobook = "add audiobook"
+	queue        = "queue"
+	listTorrents = "torrents"
+	listReleases = "releases"
+	subs         = "subs"
+	listRequests = "requests"
+	usenet       = "usenet"
+	nowPlaying   = "now playing"
+	ping         = "ping"
+	search       = "search"
+
+	userCreate    = "user create"
+	userPassword  = "user password"
+	userEnable    = "user enable"
+	userDisable   = "user disable"
+	userLink      = "user link"
+	listUsers     = "users"
+	invite        = "invite"
+	history       = "history"
+	top           = "top"
+	scan          = "scan"
+	tasks         = "tasks"
+	listLibraries = "libraries"
+	vote          = "vote"
+
+	watchlistAdd    = "watchlist add"
+	watchlistRemove = "watchlist remove"
+	watchlistAll    = "watchlist all"
+	watchlistMine   = "watchlist"
 )
 
 type SlackEvent struct {
@@ -31,6 +64,7 @@ type SlackEvent struct {
 		Subtype     string `json:"subtype"`
 		Text        string `json:"text"`
 		Ts          string `json:"ts"`
+		User        string `json:"user"`
 		Username    string `json:"username"`
 		BotID       string `json:"bot_id"`
 		Attachments []struct {
@@ -65,6 +99,22 @@ type SlackEvent struct {
 		Channel     string `json:"channel"`
 		EventTs     string `json:"event_ts"`
 		ChannelType string `json:"channel_type"`
+		// Tab is the tab of the App Home that was opened.
+		Tab string `json:"tab"`
+		// MessageTs and Links are set on link_shared events.
+		MessageTs string `json:"message_ts"`
+		Links     []struct {
+			Domain string `json:"domain"`
+			URL    string `json:"url"`
+		} `json:"links"`
+		// Reaction and Item are set on reaction_added and reaction_removed
+		// events.
+		Reaction string `json:"reaction"`
+		Item     struct {
+			Type    string `json:"type"`
+			Channel string `json:"channel"`
+			Ts      string `json:"ts"`
+		} `json:"item"`
 	} `json:"event"`
 	Type        string   `json:"type"`
 	EventID     string   `json:"event_id"`
@@ -106,6 +156,7 @@ type SlackAction struct {
 		BotID       string `json:"bot_id"`
 		Attachments []struct {
 			CallbackID string `json:"callback_id"`
+			Title      string `json:"title"`
 			Text       string `json:"text"`
 			ID         int    `json:"id"`
 			Color      string `json:"color"`
@@ -127,6 +178,31 @@ type SlackAction struct {
 	} `json:"original_message"`
 	ResponseURL string `json:"response_url"`
 	TriggerID   string `json:"trigger_id"`
+	View        struct {
+		ID              string `json:"id"`
+		CallbackID      string `json:"callback_id"`
+		PrivateMetadata string `json:"private_metadata"`
+		State           struct {
+			Values map[string]map[string]SlackViewValue `json:"values"`
+		} `json:"state"`
+	} `json:"view"`
+}
+
+// SlackViewValue is the state of an input element in a submitted modal.
+type SlackViewValue struct {
+	Type           string `json:"type"`
+	Value          string `json:"value"`
+	SelectedOption struct {
+		Value string `json:"value"`
+	} `json:"selected_option"`
+	SelectedOptions []struct {
+		Value string `json:"value"`
+	} `json:"selected_options"`
+}
+
+// value returns the state of the input element in the submitted modal.
+func (a SlackAction) value(blockID string, actionID string) SlackViewValue {
+	return a.View.State.Values[blockID][actionID]
 }
 
 type EmbyEvent struct {
@@ -241,6 +317,7 @@ type EmbyEvent struct {
 		SeasonName              string        `json:"SeasonName"`
 		MediaStreams            []struct {
 			Codec                  string  `json:"Codec"`
+			Language               string  `json:"Language,omitempty"`
 			ColorTransfer          string  `json:"ColorTransfer,omitempty"`
 			ColorPrimaries         string  `json:"ColorPrimaries,omitempty"`
 			ColorSpace             string  `json:"ColorSpace,omitempty"`
@@ -299,6 +376,11 @@ type EmbyEvent struct {
 		ApplicationVersion string `json:"ApplicationVersion"`
 		ID                 string `json:"Id"`
 	} `json:"Session"`
+	PlaybackInfo struct {
+		PlayedToCompletion bool   `json:"PlayedToCompletion"`
+		PositionTicks      int64  `json:"PositionTicks"`
+		PlaySessionID      string `json:"PlaySessionId"`
+	} `json:"PlaybackInfo"`
 }
 
 type SlackEventFunc func(context.Context, SlackEvent) (Response, error)
@@ -310,66 +392,314 @@ type SlackActionFunc func(context.Context, SlackAction) (Response, error)
 type Response struct {
 	EventType  string
 	StatusCode int
+	// Payload, if set, is encoded as the body of the response to a Slack
+	// interaction, e.g. to show validation errors in a modal.
+	Payload interface{} `json:"-"`
 }
 
 type Service interface {
 	ProcessSlackEvents(context.Context, SlackEvent) (Response, error)
 	ProcessSlackActions(context.Context, SlackAction) (Response, error)
 	ProcessEmbyEvents(context.Context, EmbyEvent) (Response, error)
+	SearchMedia(context.Context, APISearch) (Response, error)
+	RequestMedia(context.Context, APIMediaRequest) (Response, error)
+	ListRequests(context.Context, APIRequestList) (Response, error)
+	RequestStatus(context.Context, APIRequestStatus) (Response, error)
+	ProcessDiscordInteractions(context.Context, DiscordInteraction) (Response, error)
+	ProcessTelegramUpdates(context.Context, telegram.Update) (Response, error)
+	ProcessMattermostCommands(context.Context, MattermostCommand) (Response, error)
+	ProcessMattermostActions(context.Context, MattermostAction) (Response, error)
+}
+
+type Config struct {
+	// Media is the Emby, Jellyfin or Plex server.
+	Media MediaServer
+	// Movies, Artists, Albums, Books and Audiobooks are where titles of each
+	// kind are added. Kinds without a manager can't be requested.
+	Movies     MediaManager
+	Artists    MediaManager
+	Albums     MediaManager
+	Books      MediaManager
+	Audiobooks MediaManager
+	// Downloader is the torrent client, if any.
+	Downloader Downloader
+	// Usenet is the usenet client, if any.
+	Usenet Usenet
+	// UsenetAlerts posts failed usenet downloads to the admin channel.
+	UsenetAlerts bool
+	// Subtitles is the subtitle manager, if any.
+	Subtitles Subtitles
+	// SubtitleLanguages are the two letter codes of the languages whose
+	// missing subtitles are listed. All are listed when empty.
+	SubtitleLanguages []string
+	// Indexer, if set, is searched for releases instead of the indexers of
+	// Radarr.
+	Indexer Indexer
+	Slack   *slack.Client
+	// Discord, if set, answers the Discord slash commands.
+	Discord *discord.Client
+	// Matrix, if set, answers commands in MatrixRooms.
+	Matrix      *matrix.Client
+	MatrixRooms []string
+	// Telegram, if set, answers the TelegramUsers. Updates are polled for if
+	// TelegramPolling is set, otherwise they are posted to the webhook.
+	Telegram        *telegram.Client
+	TelegramUsers   []int64
+	TelegramPolling bool
+	// Mattermost, if set, answers the slash commands sent with
+	// MattermostToken.
+	Mattermost      *mattermost.Client
+	MattermostToken string
+	Logger          log.Logger
+	// Admins holds the Slack user IDs that are allowed to run admin commands.
+	Admins []string
+	// AuditLogger records every change made through admin commands.
+	AuditLogger log.Logger
+	// Sinks get notified of requests, downloads and playback.
+	Sinks []Sink
+	// APIUsers may use the request API.
+	APIUsers []APIUser
+	// RequestQuota is how many titles each user may request a week. There
+	// is no limit for admins, or for anyone when it is 0.
+	RequestQuota int
+	// VoteThreshold is how many people must react to a movie put up for a
+	// vote within VoteWindow for it to be requested. Voting is off when it
+	// is 0.
+	VoteThreshold int
+	VoteWindow    time.Duration
+	// WatchlistRequests requests movies on the watchlist once they are out,
+	// on behalf of whoever added them first.
+	WatchlistRequests bool
+	// DataDir is where state is persisted. Nothing is persisted when empty.
+	DataDir string
+	// InvitePolicy is applied to Emby accounts created through invites.
+	InvitePolicy emby.PolicyTemplate
+	// WeeklyReport schedules the playback usage report.
+	WeeklyReport ReportSchedule
 }
 
 type service struct {
-	emby   *emby.Client
-	radarr *radarr.Client
-	slack  *slack.Client
-	logger log.Logger
+	media         mediaServer
+	managers      map[string]MediaManager
+	torrentClient Downloader
+	usenetClient  Usenet
+	indexer       Indexer
+	releaseCache  *releaseCache
+	subtitles     Subtitles
+	// subtitleLanguages holds the languages missing subtitles are listed
+	// for.
+	subtitleLanguages map[string]bool
+	slack             *slack.Client
+	logger            log.Logger
+	admins            map[string]bool
+	audit             log.Logger
+	accounts          *accounts
+	invitePolicy      emby.PolicyTemplate
+	playback          *playbackLog
+	requests          *requestLog
+	apiUsers          []APIUser
+	requestQuota      int
+	ballots           *ballots
+	voteThreshold     int
+	voteWindow        time.Duration
+	watchlist         *watchlist
+	watchlistRequests bool
+	sinks             []Sink
+	slackChat         Chat
+	discord           *discord.Client
+	matrix            matrixChat
+	telegram          telegramChat
+	telegramUsers     map[int64]bool
+	mattermost        mattermostChat
+	mattermostToken   string
 }
 
-func NewService(embyClient *emby.Client, radarrClient *radarr.Client, slackClient *slack.Client, log log.Logger) (Service, error) {
-	return &service{
-		emby:   embyClient,
-		radarr: radarrClient,
-		slack:  slackClient,
-		logger: log,
-	}, nil
+func NewService(cfg Config) (Service, error) {
+	admins := make(map[string]bool, len(cfg.Admins))
+	for _, id := range cfg.Admins {
+		admins[id] = true
+	}
+
+	audit := cfg.AuditLogger
+	if audit == nil {
+		audit = log.NewNopLogger()
+	}
+
+	accounts, err := loadAccounts(newJSONFile(cfg.DataDir, "accounts.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	playback, err := loadPlaybackLog(newJSONFile(cfg.DataDir, "playback.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	requests, err := loadRequestLog(newJSONFile(cfg.DataDir, "requests.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	ballots, err := loadBallots(newJSONFile(cfg.DataDir, "votes.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	watchlist, err := loadWatchlist(newJSONFile(cfg.DataDir, "watchlist.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	voteWindow := cfg.VoteWindow
+	if voteWindow <= 0 {
+		voteWindow = defaultVoteWindow
+	}
+
+	subtitleLanguages := make(map[string]bool, len(cfg.SubtitleLanguages))
+	for _, code := range cfg.SubtitleLanguages {
+		subtitleLanguages[strings.ToLower(code)] = true
+	}
+
+	telegramUsers := make(map[int64]bool, len(cfg.TelegramUsers))
+	for _, id := range cfg.TelegramUsers {
+		telegramUsers[id] = true
+	}
+
+	managers := make(map[string]MediaManager)
+	for kind, manager := range map[string]MediaManager{
+		media.Movie:     cfg.Movies,
+		media.Artist:    cfg.Artists,
+		media.Album:     cfg.Albums,
+		media.Book:      cfg.Books,
+		media.Audiobook: cfg.Audiobooks,
+	} {
+		if manager != nil {
+			managers[kind] = manager
+		}
+	}
+
+	s := &service{
+		media:             mediaServer{cfg.Media},
+		managers:          managers,
+		torrentClient:     cfg.Downloader,
+		usenetClient:      cfg.Usenet,
+		indexer:           cfg.Indexer,
+		releaseCache:      newReleaseCache(),
+		subtitles:         cfg.Subtitles,
+		subtitleLanguages: subtitleLanguages,
+		slack:             cfg.Slack,
+		logger:            cfg.Logger,
+		admins:            admins,
+		audit:             audit,
+		accounts:          accounts,
+		invitePolicy:      cfg.InvitePolicy,
+		playback:          playback,
+		requests:          requests,
+		apiUsers:          cfg.APIUsers,
+		requestQuota:      cfg.RequestQuota,
+		ballots:           ballots,
+		voteThreshold:     cfg.VoteThreshold,
+		voteWindow:        voteWindow,
+		watchlist:         watchlist,
+		watchlistRequests: cfg.WatchlistRequests,
+		sinks:             cfg.Sinks,
+		slackChat:         slackChat{cfg.Slack},
+		discord:           cfg.Discord,
+		matrix:            matrixChat{cfg.Matrix, newMatrixChoices()},
+		telegram:          telegramChat{cfg.Telegram},
+		telegramUsers:     telegramUsers,
+		mattermost:        mattermostChat{cfg.Mattermost},
+		mattermostToken:   cfg.MattermostToken,
+	}
+
+	if cfg.Usenet != nil && cfg.UsenetAlerts {
+		go s.watchUsenetFailures(usenetPollInterval)
+	}
+
+	if _, ok := managers[media.Movie].(movieLookup); ok {
+		go s.watchReleases(watchlistPollInterval)
+	}
+
+	// The App Home tabs show the status of requests too.
+	if len(cfg.Sinks) > 0 || cfg.Slack != nil {
+		go s.watchRequests(requestPollInterval)
+	}
+
+	if cfg.Matrix != nil {
+		go s.matrixSync(cfg.MatrixRooms)
+	}
+
+	if cfg.Telegram != nil && cfg.TelegramPolling {
+		go s.pollTelegram()
+	}
+
+	if cfg.WeeklyReport.Enabled {
+		go s.weekly(cfg.WeeklyReport.Weekday, cfg.WeeklyReport.Hour, s.postWeeklyReport)
+	}
+
+	return s, nil
 }
 
 func (s *service) ProcessSlackEvents(ctx context.Context, request SlackEvent) (Response, error) {
+	if request.Event.Type == "app_home_opened" && request.Event.Tab == "home" {
+		go s.publishHome(context.Background(), request.Event.User)
+	}
+	if (request.Event.Type == "reaction_added" || request.Event.Type == "reaction_removed") && s.voteThreshold > 0 {
+		go s.countVote(context.Background(), request)
+	}
+	if request.Event.Type == "link_shared" {
+		go s.unfurl(context.Background(), request)
+	}
 	if request.Event.Type == "message" {
 		if strings.Contains(request.Event.Text, ping) {
 			s.slack.Ping()
 		}
-		if strings.Contains(request.Event.Text, nowPlaying) {
-			sessions, err := s.emby.Sessions(ctx)
-			if err != nil {
-				level.Error(s.logger).Log("error", err)
-				return Response{}, err
-			}
-			s.slack.NowPlaying(sessions)
-		}
-		if strings.Contains(request.Event.Text, addMovie) {
-			go func() {
-				text := strings.Split(request.Event.Text, " ")
-				if len(text) >= 4 {
-					movies, err := s.radarr.Search(ctx, text[3:])
-					if err != nil {
-						level.Error(s.logger).Log("error", err)
-					}
-					s.slack.PostSearch(ctx, movies)
-				}
-			}()
+
+		words := commandWords(request)
+		if handler, ok := s.chatCommand(words); ok {
+			go handler(context.Background(), s.slackChat, slackConversation(request))
 		}
-		if strings.Contains(request.Event.Text, search) {
-			go func() {
-				text := strings.Split(request.Event.Text, " ")
-				if len(text) >= 3 {
-					sResults, err := s.emby.Search(ctx, text[2:])
-					if err != nil {
-						level.Error(s.logger).Log("error", err)
-					}
-					s.slack.PostEmbySearch(ctx, sResults)
-				}
-			}()
+		switch {
+		case hasCommand(words, listTorrents):
+			go s.torrents(context.Background(), request)
+		case hasCommand(words, listReleases):
+			go s.releases(context.Background(), request, words[1:])
+		case hasCommand(words, usenet):
+			go s.usenet(context.Background(), request, words[1:])
+		case hasCommand(words, userCreate):
+			go s.createUser(context.Background(), request, words[2:])
+		case hasCommand(words, userPassword):
+			go s.resetPassword(context.Background(), request, words[2:])
+		case hasCommand(words, userEnable):
+			go s.setUserDisabled(context.Background(), request, words[2:], false)
+		case hasCommand(words, userDisable):
+			go s.setUserDisabled(context.Background(), request, words[2:], true)
+		case hasCommand(words, userLink):
+			go s.linkUser(context.Background(), request, words[2:])
+		case hasCommand(words, listUsers):
+			go s.listUsers(context.Background(), request)
+		case hasCommand(words, invite):
+			go s.promptInvite(request)
+		case hasCommand(words, history):
+			go s.history(context.Background(), request, words[1:])
+		case hasCommand(words, top):
+			go s.top(context.Background(), request, words[1:])
+		case hasCommand(words, scan):
+			go s.scan(context.Background(), request, words[1:])
+		case hasCommand(words, listLibraries):
+			go s.libraries(context.Background(), request)
+		case hasCommand(words, tasks):
+			go s.tasks(context.Background(), request)
+		case hasCommand(words, vote):
+			go s.proposeVote(context.Background(), request, words[1:])
+		case hasCommand(words, watchlistAdd):
+			go s.watch(context.Background(), request, words[2:])
+		case hasCommand(words, watchlistRemove):
+			go s.unwatch(request, words[2:])
+		case hasCommand(words, watchlistAll):
+			go s.listWatchlist(request, true)
+		case hasCommand(words, watchlistMine):
+			go s.listWatchlist(request, false)
 		}
 	}
 
@@ -381,11 +711,37 @@ func (s *service) ProcessSlackEvents(ctx context.Context, request SlackEvent) (R
 
 func (s *service) ProcessSlackActions(ctx context.Context, request SlackAction) (Response, error) {
 	if request.Type == "interactive_message" {
-		if request.CallbackID == "movieDownloadPrompt" {
-			go func() {
-				s.slack.MsgUpdate(ctx, request.OriginalMessage.Ts, request.User.Name, request.Actions[0].Value)
-				s.radarr.Download(ctx, request.Actions[0].Name)
-			}()
+		if kind, ok := s.downloadKind(request.CallbackID); ok {
+			go s.download(context.Background(), request, kind)
+		}
+		if request.CallbackID == slack.InvitePromptCallback {
+			go s.openInviteModal(context.Background(), request)
+		}
+		if request.CallbackID == slack.InviteApprovalCallback {
+			go s.resolveInvite(context.Background(), request)
+		}
+		if request.CallbackID == slack.TaskRunCallback {
+			go s.runTask(context.Background(), request)
+		}
+		if request.CallbackID == slack.TorrentCallback {
+			go s.torrentAction(context.Background(), request)
+		}
+		if request.CallbackID == slack.ReleaseGrabCallback {
+			go s.grabRelease(context.Background(), request)
+		}
+		if request.CallbackID == slack.RequestModalCallback {
+			go s.openRequestModal(context.Background(), request)
+		}
+		if request.CallbackID == slack.UnfurlRequestCallback {
+			go s.requestUnfurled(context.Background(), request)
+		}
+	}
+	if request.Type == "view_submission" {
+		if request.View.CallbackID == slack.InviteRequestCallback {
+			return s.requestInvite(ctx, request)
+		}
+		if request.View.CallbackID == slack.RequestSubmitCallback {
+			return s.submitRequest(ctx, request)
 		}
 	}
 
@@ -395,6 +751,8 @@ func (s *service) ProcessSlackActions(ctx context.Context, request SlackAction)
 }
 
 func (s *service) ProcessEmbyEvents(ctx context.Context, request EmbyEvent) (Response, error) {
+	s.recordPlayback(request)
+
 	var url string
 	if len(request.Item.ExternalUrls) > 0 {
 		url = request.Item.ExternalUrls[0].URL
@@ -410,6 +768,11 @@ func (s *service) ProcessEmbyEvents(ctx context.Context, request EmbyEvent) (Res
 			},
 		},
 	}
+	if request.Event == playbackStart {
+		if field, ok := subtitleField(request); ok {
+			attachment.Fields = append(attachment.Fields, field)
+		}
+	}
 	_, _, err := s.slack.PostMessage(slackClient.MsgOptionText("", false), slackClient.MsgOptionAttachments(attachment))
 	if err != nil {
 		level.Error(s.logger).Log("error", err)
@@ -0,0 +1,62 @@
+package warez
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// jsonFile persists state as a JSON document. A jsonFile with an empty path
+// keeps nothing, which is handy when no data directory is configured.
+type jsonFile struct {
+	path string
+}
+
+func newJSONFile(dir string, name string) jsonFile {
+	if dir == "" {
+		return jsonFile{}
+	}
+	return jsonFile{path: filepath.Join(dir, name)}
+}
+
+// load decodes the file into v. A missing file leaves v untouched.
+func (f jsonFile) load(v interface{}) error {
+	if f.path == "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", f.path, err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %v", f.path, err)
+	}
+
+	return nil
+}
+
+// save atomically replaces the file with the JSON encoding of v.
+func (f jsonFile) save(v interface{}) error {
+	if f.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := f.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %v", tmp, err)
+	}
+
+	return os.Rename(tmp, f.path)
+}
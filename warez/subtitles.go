@@ -0,0 +1,201 @@
+package warez
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/log/level"
+	slackClient "github.com/nlopes/slack"
+
+	"warezbot/bazarr"
+)
+
+// wantedLimit is how many items missing subtitles are listed at most.
+const wantedLimit = 25
+
+var (
+	// episodeTitle matches titles like "Dark S01E02".
+	episodeTitle = regexp.MustCompile(`(?i)^(.+)\s+s(\d+)\s*e(\d+)$`)
+	// languageCode matches the two letter codes languages are given in.
+	languageCode = regexp.MustCompile(`^[a-z]{2}$`)
+)
+
+// languageAliases maps the bibliographic ISO 639-2 codes some servers use to
+// the terminology codes, e.g. fre to fra.
+var languageAliases = map[string]string{
+	"alb": "sqi",
+	"arm": "hye",
+	"baq": "eus",
+	"chi": "zho",
+	"cze": "ces",
+	"dut": "nld",
+	"fre": "fra",
+	"geo": "kat",
+	"ger": "deu",
+	"gre": "ell",
+	"ice": "isl",
+	"mac": "mkd",
+	"may": "msa",
+	"per": "fas",
+	"rum": "ron",
+	"slo": "slk",
+	"wel": "cym",
+}
+
+// Subtitles finds and downloads subtitles, i.e. Bazarr.
+type Subtitles interface {
+	Wanted(ctx context.Context) ([]bazarr.Item, error)
+	Movie(ctx context.Context, title string) (bazarr.Item, error)
+	Episode(ctx context.Context, title string, season int, number int) (bazarr.Item, error)
+	SearchSubtitles(ctx context.Context, item bazarr.Item, language bazarr.Language) error
+}
+
+// subs lists what is missing subtitles, or with a title searches subtitles of
+// that movie or episode: subs <title> [lang].
+func (s *service) subs(ctx context.Context, c Chat, to Conversation, args []string) {
+	if s.subtitles == nil {
+		s.answer(c, to, "Subtitles are not set up.")
+		return
+	}
+	if len(args) == 0 {
+		s.wantedSubs(ctx, c, to)
+		return
+	}
+
+	var language string
+	if last := strings.ToLower(args[len(args)-1]); len(args) > 1 && languageCode.MatchString(last) {
+		language = last
+		args = args[:len(args)-1]
+	}
+	title := strings.Join(args, " ")
+
+	find := func() (bazarr.Item, error) {
+		if m := episodeTitle.FindStringSubmatch(title); m != nil {
+			season, _ := strconv.Atoi(m[2])
+			number, _ := strconv.Atoi(m[3])
+			return s.subtitles.Episode(ctx, m[1], season, number)
+		}
+		return s.subtitles.Movie(ctx, title)
+	}
+
+	item, err := find()
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.answer(c, to, "Failed to find %s: %v", title, err)
+		return
+	}
+
+	languages := item.Missing
+	if language != "" {
+		languages = []bazarr.Language{{Code2: language}}
+		for _, l := range item.Missing {
+			if strings.EqualFold(l.Code2, language) {
+				languages = []bazarr.Language{l}
+			}
+		}
+	}
+	if len(languages) == 0 {
+		s.answer(c, to, "%s is not missing any subtitles. Ask for a language with subs <title> <lang>.", item.Title)
+		return
+	}
+
+	for _, l := range languages {
+		s.answer(c, to, "Searching %s subtitles for %s...", l.Code2, item.Title)
+		if err := s.subtitles.SearchSubtitles(ctx, item, l); err != nil {
+			level.Error(s.logger).Log("error", err)
+			s.answer(c, to, "Failed to search %s subtitles for %s: %v", l.Code2, item.Title, err)
+		}
+	}
+
+	// Bazarr does not say whether the search found anything, so look again.
+	item, err = find()
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		return
+	}
+	var found, missing []string
+	for _, l := range languages {
+		if item.Lacks(l.Code2) {
+			missing = append(missing, l.Code2)
+		} else {
+			found = append(found, l.Code2)
+		}
+	}
+	if len(found) > 0 {
+		s.answer(c, to, "Downloaded %s subtitles for %s.", strings.Join(found, ", "), item.Title)
+	}
+	if len(missing) > 0 {
+		s.answer(c, to, "No %s subtitles found for %s.", strings.Join(missing, ", "), item.Title)
+	}
+}
+
+// wantedSubs lists the movies and episodes missing subtitles in the languages
+// set up, or in any language if none are.
+func (s *service) wantedSubs(ctx context.Context, c Chat, to Conversation) {
+	wanted, err := s.subtitles.Wanted(ctx)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.answer(c, to, "Failed to list missing subtitles: %v", err)
+		return
+	}
+
+	var rows [][]string
+	for _, item := range wanted {
+		var missing []string
+		for _, l := range item.Missing {
+			if len(s.subtitleLanguages) == 0 || s.subtitleLanguages[l.Code2] {
+				missing = append(missing, l.Code2)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+		rows = append(rows, []string{item.Title, strings.Join(missing, ", ")})
+		if len(rows) == wantedLimit {
+			break
+		}
+	}
+
+	if err := c.PostTable(to, "Missing subtitles", []string{"Title", "Languages"}, rows); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+}
+
+// subtitleField flags a playing item that has no subtitles in the language
+// the user prefers, if they set one.
+func subtitleField(request EmbyEvent) (slackClient.AttachmentField, bool) {
+	preferred := normalizeLanguage(request.User.Configuration.SubtitleLanguagePreference)
+	if preferred == "" || request.Item.Type == "Audio" || len(request.Item.MediaStreams) == 0 {
+		return slackClient.AttachmentField{}, false
+	}
+
+	for _, stream := range request.Item.MediaStreams {
+		if stream.Type == "Subtitle" && normalizeLanguage(stream.Language) == preferred {
+			return slackClient.AttachmentField{}, false
+		}
+	}
+
+	return slackClient.AttachmentField{
+		Title: "Subtitles",
+		Value: fmt.Sprintf("No %s subtitles, ask for them with `subs %s`", request.User.Configuration.SubtitleLanguagePreference, subsTitle(request)),
+	}, true
+}
+
+// subsTitle is how the item is named to the subs command.
+func subsTitle(request EmbyEvent) string {
+	if request.Item.Type == "Episode" {
+		return fmt.Sprintf("%s S%02dE%02d", request.Item.SeriesName, request.Item.ParentIndexNumber, request.Item.IndexNumber)
+	}
+	return request.Item.Name
+}
+
+func normalizeLanguage(code string) string {
+	code = strings.ToLower(code)
+	if alias, ok := languageAliases[code]; ok {
+		return alias
+	}
+	return code
+}
@@ -0,0 +1,173 @@
+package warez
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+
+	"warezbot/emby"
+	"warezbot/media"
+	"warezbot/telegram"
+)
+
+const telegramRetry = 30 * time.Second
+
+type TelegramUpdateFunc func(context.Context, telegram.Update) (Response, error)
+
+// telegramCommands maps the Telegram commands, which are single words, to the
+// chat commands.
+var telegramCommands = map[string]string{
+	"nowplaying": nowPlaying,
+	"search":     search,
+	"add":        "add",
+	"queue":      queue,
+	"requests":   listRequests,
+	"subs":       subs,
+}
+
+// telegramChat posts to the chat the command came from.
+type telegramChat struct {
+	client *telegram.Client
+}
+
+func (c telegramChat) chatID(to Conversation) int64 {
+	id, _ := strconv.ParseInt(to.Channel, 10, 64)
+	return id
+}
+
+func (c telegramChat) Reply(to Conversation, text string) error {
+	return c.client.Reply(context.Background(), c.chatID(to), text)
+}
+
+func (c telegramChat) PostTable(to Conversation, title string, header []string, rows [][]string) error {
+	return c.client.PostTable(context.Background(), c.chatID(to), title, header, rows)
+}
+
+func (c telegramChat) PostSearch(to Conversation, kind string, items media.Items) error {
+	return c.client.PostSearch(context.Background(), c.chatID(to), kind, items)
+}
+
+func (c telegramChat) PostLibrarySearch(to Conversation, results emby.SearchResults) error {
+	return c.client.PostLibrarySearch(context.Background(), c.chatID(to), results)
+}
+
+func (c telegramChat) NowPlaying(to Conversation, sessions emby.Sessions) error {
+	return c.client.NowPlaying(context.Background(), c.chatID(to), sessions)
+}
+
+// ProcessTelegramUpdates answers an update posted to the webhook.
+func (s *service) ProcessTelegramUpdates(ctx context.Context, update telegram.Update) (Response, error) {
+	if s.telegram.client == nil {
+		return Response{StatusCode: http.StatusNotFound}, nil
+	}
+
+	go s.telegramUpdate(context.Background(), update)
+
+	return Response{StatusCode: http.StatusOK}, nil
+}
+
+// pollTelegram answers the updates Telegram holds for the bot until the
+// process exits, for when the bot can't be reached by a webhook.
+func (s *service) pollTelegram() {
+	ctx := context.Background()
+	if err := s.telegram.client.DeleteWebhook(ctx); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+
+	var offset int64
+	for {
+		updates, err := s.telegram.client.Updates(ctx, offset, telegram.PollTimeout)
+		if err != nil {
+			level.Error(s.logger).Log("error", err)
+			time.Sleep(telegramRetry)
+			continue
+		}
+		for _, update := range updates {
+			offset = update.UpdateID + 1
+			go s.telegramUpdate(ctx, update)
+		}
+	}
+}
+
+// telegramUpdate runs the command in a message or the download picked with a
+// button. Only the users allowed may use the bot, as anyone can message it.
+func (s *service) telegramUpdate(ctx context.Context, update telegram.Update) {
+	switch {
+	case update.Message != nil:
+		m := update.Message
+		to := Conversation{
+			Platform: platformTelegram,
+			Channel:  strconv.FormatInt(m.Chat.ID, 10),
+			User:     strconv.FormatInt(m.From.ID, 10),
+		}
+		name, args, ok := telegram.Command(m.Text)
+		if !ok {
+			return
+		}
+		if !s.telegramUsers[m.From.ID] {
+			s.answer(s.telegram, to, "Sorry, you're not allowed to use this bot. Your user ID is %d.", m.From.ID)
+			return
+		}
+
+		if command, ok := telegramCommands[name]; ok {
+			if handler, ok := s.chatCommand(append(strings.Fields(command), args...)); ok {
+				handler(ctx, s.telegram, to)
+				return
+			}
+		}
+		if command, ok := slackOnlyCommand(append([]string{name}, args...)); ok {
+			s.answer(s.telegram, to, slackOnlyAnswer, command)
+			return
+		}
+		// Also the answer to /start, sent when someone opens the bot.
+		s.answer(s.telegram, to, "Try /search <term>, /add movie <title>, /nowplaying, /queue, /requests or /subs.")
+
+	case update.CallbackQuery != nil:
+		q := update.CallbackQuery
+		if !s.telegramUsers[q.From.ID] {
+			if err := s.telegram.client.AnswerCallbackQuery(ctx, q.ID, "Sorry, you're not allowed to use this bot."); err != nil {
+				level.Error(s.logger).Log("error", err)
+			}
+			return
+		}
+		if kind, id, ok := telegram.ParseDownloadData(q.Data); ok && q.Message != nil {
+			s.telegramDownload(ctx, q, kind, id)
+		}
+	}
+}
+
+// telegramDownload requests the item picked from the search results and
+// removes the button from its message.
+func (s *service) telegramDownload(ctx context.Context, q *telegram.CallbackQuery, kind string, id string) {
+	to := Conversation{
+		Platform: platformTelegram,
+		Channel:  strconv.FormatInt(q.Message.Chat.ID, 10),
+		User:     strconv.FormatInt(q.From.ID, 10),
+	}
+
+	r, err := s.requestMedia(ctx, kind, id, to.requester(), platformTelegram)
+	if err == errAlreadyRequested {
+		err = fmt.Errorf("it was already requested by %s on %s", s.userName(r.User), r.Requested.Format("Jan 2"))
+	}
+	answer := "Download started"
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		answer = fmt.Sprintf("Failed to add it: %v", err)
+	}
+	if err := s.telegram.client.AnswerCallbackQuery(ctx, q.ID, answer); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+	if err != nil {
+		return
+	}
+
+	if err := s.telegram.client.RemoveKeyboard(ctx, q.Message.Chat.ID, q.Message.MessageID); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+	s.answer(s.telegram, to, "Download process started by %s for %s", q.From.Name(), r.Title)
+}
@@ -0,0 +1,98 @@
+package warez
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/go-kit/kit/log/level"
+
+	"warezbot/downloader"
+	"warezbot/slack"
+)
+
+// torrentsLimit is how many torrents are listed at most, the busiest first.
+const torrentsLimit = 20
+
+// Downloader is the torrent client the media managers download with.
+type Downloader interface {
+	Torrents(ctx context.Context) ([]downloader.Torrent, error)
+	Pause(ctx context.Context, hash string) error
+	Resume(ctx context.Context, hash string) error
+	Remove(ctx context.Context, hash string, deleteData bool) error
+}
+
+// torrents lists the torrents that are downloading, stuck or still seeding.
+func (s *service) torrents(ctx context.Context, request SlackEvent) {
+	if s.torrentClient == nil {
+		s.reply(request, "No torrent client is set up.")
+		return
+	}
+
+	all, err := s.torrentClient.Torrents(ctx)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.reply(request, "Failed to list the torrents: %v", err)
+		return
+	}
+
+	var active []downloader.Torrent
+	for _, t := range all {
+		if !t.Done() || t.UploadSpeed > 0 || t.State == downloader.Error {
+			active = append(active, t)
+		}
+	}
+	sort.SliceStable(active, func(i, j int) bool {
+		return active[i].DownloadSpeed+active[i].UploadSpeed > active[j].DownloadSpeed+active[j].UploadSpeed
+	})
+	if len(active) > torrentsLimit {
+		active = active[:torrentsLimit]
+	}
+
+	s.slack.PostTorrents(active)
+}
+
+// torrentAction pauses, resumes or removes the torrent of the button that was
+// clicked.
+func (s *service) torrentAction(ctx context.Context, request SlackAction) {
+	if !s.isAdmin(request.User.ID) {
+		if err := s.slack.PostEphemeral(request.Channel.ID, request.User.ID, "Sorry, only admins can do that."); err != nil {
+			level.Error(s.logger).Log("error", err)
+		}
+		return
+	}
+	if s.torrentClient == nil {
+		return
+	}
+
+	action := request.Actions[0]
+	var err error
+	var done string
+	switch action.Name {
+	case slack.TorrentPause:
+		err = s.torrentClient.Pause(ctx, action.Value)
+		done = "paused"
+	case slack.TorrentResume:
+		err = s.torrentClient.Resume(ctx, action.Value)
+		done = "resumed"
+	case slack.TorrentRemove:
+		err = s.torrentClient.Remove(ctx, action.Value, false)
+		done = "removed"
+	default:
+		return
+	}
+
+	name := action.Value
+	if i, convErr := strconv.Atoi(request.AttachmentID); convErr == nil && i > 0 && i <= len(request.OriginalMessage.Attachments) {
+		name = request.OriginalMessage.Attachments[i-1].Title
+	}
+
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.postThread(request.Channel.ID, request.MessageTs, fmt.Sprintf("Failed to %s %s: %v", action.Name, name, err))
+		return
+	}
+	s.audit.Log("action", "torrent_"+action.Name, "admin", request.User.ID, "torrent", name, "hash", action.Value)
+	s.postThread(request.Channel.ID, request.MessageTs, fmt.Sprintf("<@%s> %s %s", request.User.ID, done, name))
+}
@@ -0,0 +1,108 @@
+package warez
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-kit/kit/log/level"
+
+	"warezbot/media"
+	"warezbot/radarr"
+	"warezbot/slack"
+)
+
+var (
+	imdbLink = regexp.MustCompile(`imdb\.com/title/(tt\d+)`)
+	tmdbLink = regexp.MustCompile(`themoviedb\.org/movie/(\d+)`)
+)
+
+// movieLookup is implemented by media managers that can find a movie by its
+// IMDb or TMDB ID, e.g. Radarr.
+type movieLookup interface {
+	Lookup(ctx context.Context, term string) (radarr.LookupResult, bool, error)
+}
+
+// lookupTerm returns the Radarr lookup term of an IMDb or TMDB movie link.
+func lookupTerm(link string) (string, bool) {
+	if m := imdbLink.FindStringSubmatch(link); m != nil {
+		return "imdb:" + m[1], true
+	}
+	if m := tmdbLink.FindStringSubmatch(link); m != nil {
+		return "tmdb:" + m[1], true
+	}
+	return "", false
+}
+
+// unfurl shows the movies IMDb and TMDB links shared in Slack point to.
+func (s *service) unfurl(ctx context.Context, request SlackEvent) {
+	lookup, ok := s.managers[media.Movie].(movieLookup)
+	if !ok {
+		return
+	}
+
+	var unfurls []slack.Unfurl
+	for _, link := range request.Event.Links {
+		term, ok := lookupTerm(link.URL)
+		if !ok {
+			continue
+		}
+		movie, found, err := lookup.Lookup(ctx, term)
+		if err != nil {
+			level.Error(s.logger).Log("error", err)
+			continue
+		}
+		if !found {
+			continue
+		}
+		unfurls = append(unfurls, slack.Unfurl{
+			URL:        link.URL,
+			Item:       movie.Item,
+			InLibrary:  s.inLibrary(ctx, movie.Item),
+			InManager:  movie.InLibrary,
+			Downloaded: movie.Downloaded,
+		})
+	}
+	if len(unfurls) == 0 {
+		return
+	}
+
+	if err := s.slack.Unfurl(ctx, request.Event.Channel, request.Event.MessageTs, unfurls); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+}
+
+// inLibrary reports whether the media server has the movie.
+func (s *service) inLibrary(ctx context.Context, movie media.Item) bool {
+	results, err := s.media.Search(ctx, strings.Fields(movie.Title))
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		return false
+	}
+	for _, hint := range results.SearchHints {
+		if hint.Type == "Movie" && strings.EqualFold(hint.Name, movie.Title) && (movie.Year == 0 || hint.ProductionYear == movie.Year) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestUnfurled requests the movie of an unfurled link and says so in the
+// thread of the message.
+func (s *service) requestUnfurled(ctx context.Context, request SlackAction) {
+	action := request.Actions[0]
+	r, err := s.requestMedia(ctx, media.Movie, action.Name, request.User.ID, sourceSlack)
+	if err == errAlreadyRequested {
+		err = fmt.Errorf("it was already requested by <@%s> on %s", r.User, r.Requested.Format("Jan 2"))
+	}
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.replyAction(request, "Failed to add %s: %v", action.Value, err)
+		return
+	}
+
+	if err := s.slack.PostThread(request.Channel.ID, request.MessageTs, fmt.Sprintf("<@%s> requested %s.", request.User.ID, action.Value)); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+}
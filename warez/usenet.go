@@ -0,0 +1,140 @@
+package warez
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+
+	"warezbot/downloader"
+	"warezbot/slack"
+)
+
+const (
+	usenetHistoryLimit = 10
+	usenetHistoryMax   = 50
+	// usenetPollInterval is how often the history is checked for failed
+	// downloads.
+	usenetPollInterval = 5 * time.Minute
+)
+
+// Usenet is the usenet client the media managers download with.
+type Usenet interface {
+	Queue(ctx context.Context) (downloader.UsenetQueue, error)
+	History(ctx context.Context, limit int) ([]downloader.HistoryItem, error)
+}
+
+// usenet shows the queue and the last downloads, usenet [n] to show n of them.
+func (s *service) usenet(ctx context.Context, request SlackEvent, args []string) {
+	if s.usenetClient == nil {
+		s.reply(request, "No usenet client is set up.")
+		return
+	}
+
+	limit := usenetHistoryLimit
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n <= 0 || n > usenetHistoryMax {
+			s.reply(request, "Usage: usenet [1-%d]", usenetHistoryMax)
+			return
+		}
+		limit = n
+	}
+
+	queue, err := s.usenetClient.Queue(ctx)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.reply(request, "Failed to get the usenet queue: %v", err)
+		return
+	}
+	history, err := s.usenetClient.History(ctx, limit)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.reply(request, "Failed to get the usenet history: %v", err)
+		return
+	}
+
+	status := "Downloading"
+	if queue.Paused {
+		status = "Paused"
+	} else if len(queue.Items) == 0 {
+		status = "Idle"
+	}
+	var failures int
+	for _, item := range history {
+		if item.Failed() {
+			failures++
+		}
+	}
+	s.slack.PostStats("Usenet", []slack.Stat{
+		{Name: "Status", Value: status},
+		{Name: "Speed", Value: downloader.FormatBytes(queue.Speed) + "/s"},
+		{Name: "Left", Value: fmt.Sprintf("%s, %s", downloader.FormatBytes(queue.SizeLeft), formatDuration(queue.TimeLeft))},
+		{Name: "Failed", Value: fmt.Sprintf("%d of the last %d", failures, len(history))},
+	})
+
+	var rows [][]string
+	for _, item := range queue.Items {
+		rows = append(rows, []string{
+			item.Name,
+			item.Status,
+			fmt.Sprintf("%.0f%%", item.Progress()*100),
+			downloader.FormatBytes(item.Size),
+		})
+	}
+	s.slack.PostTable("Queue", []string{"Name", "Status", "Done", "Size"}, rows)
+
+	rows = nil
+	for _, item := range history {
+		rows = append(rows, []string{
+			item.Completed.Local().Format("Jan 02 15:04"),
+			item.Name,
+			item.Status,
+			item.FailMessage,
+		})
+	}
+	s.slack.PostTable(fmt.Sprintf("Last %d downloads", limit), []string{"When", "Name", "Status", "Reason"}, rows)
+}
+
+// watchUsenetFailures alerts the admins about every download that fails. It
+// never returns.
+func (s *service) watchUsenetFailures(interval time.Duration) {
+	// Failures from before the bot started have been dealt with, or not,
+	// already.
+	var seen map[string]bool
+
+	for {
+		history, err := s.usenetClient.History(context.Background(), usenetHistoryMax)
+		if err != nil {
+			level.Warn(s.logger).Log("event", "failed to check usenet history", "error", err)
+			time.Sleep(interval)
+			continue
+		}
+
+		// Only the failures still in the history are remembered, older ones
+		// can't come back.
+		failed := make(map[string]bool)
+		for _, item := range history {
+			if !item.Failed() {
+				continue
+			}
+			failed[item.ID] = true
+			if seen == nil || seen[item.ID] {
+				continue
+			}
+
+			text := fmt.Sprintf(":warning: Usenet download failed: *%s*", item.Name)
+			if item.FailMessage != "" {
+				text += fmt.Sprintf("\n%s", item.FailMessage)
+			}
+			if err := s.slack.PostAdmin(text); err != nil {
+				level.Error(s.logger).Log("error", err)
+			}
+		}
+		seen = failed
+
+		time.Sleep(interval)
+	}
+}
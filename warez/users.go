@@ -0,0 +1,208 @@
+package warez
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+
+	"warezbot/emby"
+)
+
+const (
+	passwordLength  = 16
+	passwordCharset = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+func (s *service) isAdmin(user string) bool {
+	return s.admins[user]
+}
+
+// requireAdmin tells the user off and returns false if they are not an admin.
+func (s *service) requireAdmin(request SlackEvent) bool {
+	if s.isAdmin(request.Event.User) {
+		return true
+	}
+
+	if err := s.slack.PostEphemeral(request.Event.Channel, request.Event.User, "Sorry, only admins can do that."); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+	return false
+}
+
+// reply answers the user who sent the request with a message only they can
+// see.
+func (s *service) reply(request SlackEvent, format string, a ...interface{}) {
+	if err := s.slack.PostEphemeral(request.Event.Channel, request.Event.User, fmt.Sprintf(format, a...)); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+}
+
+func (s *service) createUser(ctx context.Context, request SlackEvent, args []string) {
+	if !s.requireAdmin(request) {
+		return
+	}
+	if len(args) != 1 {
+		s.reply(request, "Usage: user create <name>")
+		return
+	}
+
+	user, err := s.media.CreateUser(ctx, args[0])
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.reply(request, "Failed to create Emby user %s: %v", args[0], err)
+		return
+	}
+
+	password, err := generatePassword()
+	if err == nil {
+		err = s.media.SetPassword(ctx, user.ID, password)
+	}
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.deleteUser(ctx, user)
+		s.reply(request, "Failed to set a password for Emby user %s, so it was not created: %v", user.Name, err)
+		return
+	}
+	s.audit.Log("action", "user_create", "admin", request.Event.User, "emby_user", user.Name, "emby_user_id", user.ID)
+
+	s.reply(request, "Created Emby user %s with password `%s`", user.Name, password)
+}
+
+func (s *service) resetPassword(ctx context.Context, request SlackEvent, args []string) {
+	if !s.requireAdmin(request) {
+		return
+	}
+	if len(args) != 1 {
+		s.reply(request, "Usage: user password <name>")
+		return
+	}
+
+	user, err := s.media.UserByName(ctx, args[0])
+	if err != nil {
+		s.reply(request, "%v", err)
+		return
+	}
+
+	password, err := generatePassword()
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.reply(request, "Failed to generate a password for %s: %v", user.Name, err)
+		return
+	}
+
+	if err := s.media.SetPassword(ctx, user.ID, password); err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.reply(request, "Failed to set the password of %s: %v", user.Name, err)
+		return
+	}
+	s.audit.Log("action", "user_password", "admin", request.Event.User, "emby_user", user.Name, "emby_user_id", user.ID)
+
+	s.reply(request, "Password of %s set to `%s`", user.Name, password)
+}
+
+// deleteUser removes an account that could not be set up, so that it can be
+// created again.
+func (s *service) deleteUser(ctx context.Context, user emby.User) {
+	if err := s.media.DeleteUser(ctx, user.ID); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+}
+
+func (s *service) setUserDisabled(ctx context.Context, request SlackEvent, args []string, disabled bool) {
+	if !s.requireAdmin(request) {
+		return
+	}
+
+	action := "enable"
+	if disabled {
+		action = "disable"
+	}
+	if len(args) != 1 {
+		s.reply(request, "Usage: user %s <name>", action)
+		return
+	}
+
+	user, err := s.media.UserByName(ctx, args[0])
+	if err != nil {
+		s.reply(request, "%v", err)
+		return
+	}
+
+	if err := s.media.SetUserDisabled(ctx, user.ID, disabled); err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.reply(request, "Failed to %s %s: %v", action, user.Name, err)
+		return
+	}
+	s.audit.Log("action", "user_"+action, "admin", request.Event.User, "emby_user", user.Name, "emby_user_id", user.ID)
+
+	s.reply(request, "%s is now %sd", user.Name, action)
+}
+
+// linkUser ties an existing Emby account to a Slack user.
+func (s *service) linkUser(ctx context.Context, request SlackEvent, args []string) {
+	if !s.requireAdmin(request) {
+		return
+	}
+
+	var slackID string
+	if len(args) == 2 {
+		slackID, _ = slackUser(args[0])
+	}
+	if slackID == "" {
+		s.reply(request, "Usage: user link <@slack user> <emby name>")
+		return
+	}
+
+	user, err := s.media.UserByName(ctx, args[1])
+	if err != nil {
+		s.reply(request, "%v", err)
+		return
+	}
+
+	if err := s.accounts.link(Link{
+		SlackUser:  slackID,
+		EmbyUserID: user.ID,
+		EmbyUser:   user.Name,
+		Linked:     time.Now(),
+	}); err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.reply(request, "Failed to link %s: %v", user.Name, err)
+		return
+	}
+	s.audit.Log("action", "user_link", "admin", request.Event.User, "slack_user", slackID, "emby_user", user.Name, "emby_user_id", user.ID)
+
+	s.reply(request, "<@%s> is now linked to the Emby user %s", slackID, user.Name)
+}
+
+func (s *service) listUsers(ctx context.Context, request SlackEvent) {
+	if !s.requireAdmin(request) {
+		return
+	}
+
+	users, err := s.media.Users(ctx)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.reply(request, "Failed to list Emby users: %v", err)
+		return
+	}
+
+	s.slack.PostUsers(users)
+}
+
+func generatePassword() (string, error) {
+	b := make([]byte, passwordLength)
+	max := big.NewInt(int64(len(passwordCharset)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate password: %v", err)
+		}
+		b[i] = passwordCharset[n.Int64()]
+	}
+
+	return string(b), nil
+}
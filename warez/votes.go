@@ -0,0 +1,201 @@
+package warez
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+
+	"warezbot/media"
+	"warezbot/slack"
+)
+
+// defaultVoteWindow is how long votes are open when no window is configured.
+const defaultVoteWindow = 24 * time.Hour
+
+// Ballot is a movie put up for a vote, keyed by the ts of the message it was
+// posted in.
+type Ballot struct {
+	Channel  string    `json:"channel"`
+	MediaID  string    `json:"media_id"`
+	Title    string    `json:"title"`
+	Proposer string    `json:"proposer"`
+	Opened   time.Time `json:"opened"`
+	Voters   []string  `json:"voters"`
+	// Reactions are the thumbs up, in any skin tone, each voter reacted
+	// with. Voters only lose their vote once they took back all of them.
+	Reactions map[string][]string `json:"reactions"`
+	// Closed is set once the movie got enough votes and was requested.
+	Closed bool `json:"closed"`
+}
+
+// ballots keeps the open votes and who voted in them.
+type ballots struct {
+	mu   sync.Mutex
+	file jsonFile
+
+	Ballots map[string]*Ballot `json:"ballots"`
+}
+
+func loadBallots(file jsonFile) (*ballots, error) {
+	b := &ballots{
+		file:    file,
+		Ballots: map[string]*Ballot{},
+	}
+	if err := file.load(b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// open records the ballot posted at ts, forgetting the ones older than
+// window.
+func (b *ballots) open(ts string, ballot Ballot, window time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for k, old := range b.Ballots {
+		if time.Since(old.Opened) > window {
+			delete(b.Ballots, k)
+		}
+	}
+	b.Ballots[ts] = &ballot
+	return b.file.save(b)
+}
+
+// vote adds or takes back the reaction of the user to the ballot posted at
+// ts. passed is true for the vote that reached the threshold, the ballot is
+// then closed so the movie is requested once.
+func (b *ballots) vote(ts string, user string, reaction string, add bool, threshold int, window time.Duration) (ballot Ballot, passed bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	open, ok := b.Ballots[ts]
+	if !ok || open.Closed || time.Since(open.Opened) > window {
+		return Ballot{}, false, nil
+	}
+
+	if open.Reactions == nil {
+		open.Reactions = map[string][]string{}
+	}
+	var reactions []string
+	for _, r := range open.Reactions[user] {
+		if r != reaction {
+			reactions = append(reactions, r)
+		}
+	}
+	if add {
+		reactions = append(reactions, reaction)
+	}
+	if len(reactions) > 0 {
+		open.Reactions[user] = reactions
+	} else {
+		delete(open.Reactions, user)
+	}
+
+	var voters []string
+	for _, v := range open.Voters {
+		if v != user {
+			voters = append(voters, v)
+		}
+	}
+	if len(reactions) > 0 {
+		voters = append(voters, user)
+	}
+	open.Voters = voters
+	if len(open.Voters) >= threshold {
+		open.Closed = true
+		passed = true
+	}
+	return *open, passed, b.file.save(b)
+}
+
+// proposeVote posts the first movie matching the args for a vote.
+func (s *service) proposeVote(ctx context.Context, request SlackEvent, args []string) {
+	if !s.requireAdmin(request) {
+		return
+	}
+	if s.voteThreshold <= 0 {
+		s.reply(request, "Voting is not set up.")
+		return
+	}
+	manager, ok := s.managers[media.Movie]
+	if !ok {
+		s.reply(request, "Voting needs Radarr.")
+		return
+	}
+	if len(args) == 0 {
+		s.reply(request, "Usage: vote <movie>")
+		return
+	}
+
+	items, err := manager.Search(ctx, args)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.reply(request, "Failed to search %s: %v", strings.Join(args, " "), err)
+		return
+	}
+	if len(items) == 0 {
+		s.reply(request, "No movie matches %s.", strings.Join(args, " "))
+		return
+	}
+
+	item := items[0]
+	channel, ts, err := s.slack.PostVote(item, s.voteThreshold, s.voteWindow)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		return
+	}
+	title := item.Title
+	if item.Year > 0 {
+		title = fmt.Sprintf("%s (%d)", item.Title, item.Year)
+	}
+	ballot := Ballot{
+		Channel:  channel,
+		MediaID:  item.ID,
+		Title:    title,
+		Proposer: request.Event.User,
+		Opened:   time.Now(),
+	}
+	if err := s.ballots.open(ts, ballot, s.voteWindow); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+}
+
+// countVote counts a reaction added to or removed from a ballot, and
+// requests the movie on behalf of whoever proposed it once enough people
+// voted for it.
+func (s *service) countVote(ctx context.Context, request SlackEvent) {
+	reaction := request.Event.Reaction
+	if reaction != slack.VoteReaction && !strings.HasPrefix(reaction, slack.VoteReaction+"::") {
+		return
+	}
+	if request.Event.Item.Type != "message" {
+		return
+	}
+
+	add := request.Event.Type == "reaction_added"
+	ballot, passed, err := s.ballots.vote(request.Event.Item.Ts, request.Event.User, reaction, add, s.voteThreshold, s.voteWindow)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+	if !passed {
+		return
+	}
+
+	text := fmt.Sprintf("%d votes, %s was requested.", len(ballot.Voters), ballot.Title)
+	r, err := s.requestMedia(ctx, media.Movie, ballot.MediaID, ballot.Proposer, sourceSlack)
+	if err == errAlreadyRequested {
+		text = fmt.Sprintf("%d votes, but %s was already requested by <@%s> on %s.", len(ballot.Voters), ballot.Title, r.User, r.Requested.Format("Jan 2"))
+	} else if err != nil {
+		level.Error(s.logger).Log("error", err)
+		text = fmt.Sprintf("%d votes, but %s could not be requested: %v", len(ballot.Voters), ballot.Title, err)
+	}
+	if err := s.slack.PostThread(ballot.Channel, request.Event.Item.Ts, text); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+}
@@ -0,0 +1,43 @@
+package warez
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVoteReactions(t *testing.T) {
+	b := &ballots{Ballots: map[string]*Ballot{}}
+	if err := b.open("1.2", Ballot{Title: "Heat", Opened: time.Now()}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		user     string
+		reaction string
+		add      bool
+		voters   int
+		passed   bool
+	}{
+		{"U1", "+1", true, 1, false},
+		{"U1", "+1::skin-tone-2", true, 1, false},
+		// One thumbs up of U1 remains, so they still vote.
+		{"U1", "+1", false, 1, false},
+		{"U2", "+1::skin-tone-4", true, 2, false},
+		{"U1", "+1::skin-tone-2", false, 1, false},
+		{"U1", "+1", true, 2, false},
+		{"U3", "+1", true, 3, true},
+	}
+	for i, s := range steps {
+		ballot, passed, err := b.vote("1.2", s.user, s.reaction, s.add, 3, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ballot.Voters) != s.voters || passed != s.passed {
+			t.Errorf("step %d: %d voters, passed = %v, want %d, %v", i, len(ballot.Voters), passed, s.voters, s.passed)
+		}
+	}
+
+	if _, passed, _ := b.vote("1.2", "U4", "+1", true, 3, time.Hour); passed {
+		t.Error("a closed ballot passed again")
+	}
+}
@@ -0,0 +1,314 @@
+package warez
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log/level"
+
+	"warezbot/media"
+)
+
+// watchlistPollInterval is how often the movies on the watchlist are checked
+// for a release.
+const watchlistPollInterval = 6 * time.Hour
+
+// WatchedMovie is a movie on the watchlist and the Slack users waiting for
+// it.
+type WatchedMovie struct {
+	MediaID  string    `json:"media_id"`
+	Title    string    `json:"title"`
+	Watchers []string  `json:"watchers"`
+	Added    time.Time `json:"added"`
+	// Released is set once the watchers were told the movie is out.
+	Released bool `json:"released"`
+}
+
+func (m WatchedMovie) watchedBy(user string) bool {
+	for _, w := range m.Watchers {
+		if w == user {
+			return true
+		}
+	}
+	return false
+}
+
+// watchlist is shared: a movie is on it once, with everyone watching it.
+type watchlist struct {
+	mu   sync.Mutex
+	file jsonFile
+
+	Movies map[string]*WatchedMovie `json:"movies"`
+}
+
+func loadWatchlist(file jsonFile) (*watchlist, error) {
+	w := &watchlist{
+		file:   file,
+		Movies: map[string]*WatchedMovie{},
+	}
+	if err := file.load(w); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// add puts the user on the watchers of the movie, adding it if nobody was
+// watching it yet. It reports false if the user was already watching it.
+func (w *watchlist) add(id string, title string, user string) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	m, ok := w.Movies[id]
+	if !ok {
+		m = &WatchedMovie{MediaID: id, Title: title, Added: time.Now()}
+		w.Movies[id] = m
+	}
+	if m.watchedBy(user) {
+		return false, nil
+	}
+	m.Watchers = append(m.Watchers, user)
+	return true, w.file.save(w)
+}
+
+// remove takes the user off the watchers of the movies whose title contains
+// the term, dropping the movies nobody watches anymore, and returns their
+// titles.
+func (w *watchlist) remove(term string, user string) ([]string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var removed []string
+	for id, m := range w.Movies {
+		if !m.watchedBy(user) || !strings.Contains(strings.ToLower(m.Title), strings.ToLower(term)) {
+			continue
+		}
+		// A new slice, as copies handed out by list may still read the old.
+		var watchers []string
+		for _, u := range m.Watchers {
+			if u != user {
+				watchers = append(watchers, u)
+			}
+		}
+		m.Watchers = watchers
+		if len(m.Watchers) == 0 {
+			delete(w.Movies, id)
+		}
+		removed = append(removed, m.Title)
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+	return removed, w.file.save(w)
+}
+
+// list returns the movies the user watches, or every movie if user is empty,
+// the oldest first.
+func (w *watchlist) list(user string) []WatchedMovie {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var movies []WatchedMovie
+	for _, m := range w.Movies {
+		if user == "" || m.watchedBy(user) {
+			movie := *m
+			movie.Watchers = append([]string(nil), m.Watchers...)
+			movies = append(movies, movie)
+		}
+	}
+	sort.Slice(movies, func(i, j int) bool {
+		return movies[i].Added.Before(movies[j].Added)
+	})
+	return movies
+}
+
+func (w *watchlist) setReleased(id string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if m, ok := w.Movies[id]; ok {
+		m.Released = true
+	}
+	return w.file.save(w)
+}
+
+func (w *watchlist) drop(id string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.Movies, id)
+	return w.file.save(w)
+}
+
+// watch adds the first movie in Radarr matching the args to the watchlist of
+// the user.
+func (s *service) watch(ctx context.Context, request SlackEvent, args []string) {
+	manager, ok := s.managers[media.Movie]
+	if !ok {
+		s.reply(request, "The watchlist needs Radarr.")
+		return
+	}
+	if len(args) == 0 {
+		s.reply(request, "Usage: watchlist add <movie>")
+		return
+	}
+
+	items, err := manager.Search(ctx, args)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.reply(request, "Failed to search %s: %v", strings.Join(args, " "), err)
+		return
+	}
+	if len(items) == 0 {
+		s.reply(request, "No movie matches %s.", strings.Join(args, " "))
+		return
+	}
+
+	item := items[0]
+	title := item.Title
+	if item.Year > 0 {
+		title = fmt.Sprintf("%s (%d)", item.Title, item.Year)
+	}
+	added, err := s.watchlist.add(item.ID, title, request.Event.User)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.reply(request, "Failed to add %s to your watchlist: %v", title, err)
+		return
+	}
+	if !added {
+		s.reply(request, "%s is already on your watchlist.", title)
+		return
+	}
+	s.reply(request, "Added %s to your watchlist. You'll hear from me once it is out.", title)
+}
+
+// unwatch takes the movies matching the args off the watchlist of the user.
+func (s *service) unwatch(request SlackEvent, args []string) {
+	if len(args) == 0 {
+		s.reply(request, "Usage: watchlist remove <movie>")
+		return
+	}
+
+	term := strings.Join(args, " ")
+	removed, err := s.watchlist.remove(term, request.Event.User)
+	if err != nil {
+		level.Error(s.logger).Log("error", err)
+		s.reply(request, "Failed to update your watchlist: %v", err)
+		return
+	}
+	if len(removed) == 0 {
+		s.reply(request, "Nothing on your watchlist matches %s.", term)
+		return
+	}
+	s.reply(request, "Removed %s from your watchlist.", strings.Join(removed, ", "))
+}
+
+// listWatchlist shows the watchlist of the user, or the shared one with
+// everyone watching each movie if all is set.
+func (s *service) listWatchlist(request SlackEvent, all bool) {
+	user := request.Event.User
+	title := "Your watchlist"
+	if all {
+		user = ""
+		title = "Watchlist"
+	}
+
+	movies := s.watchlist.list(user)
+	if len(movies) == 0 {
+		s.reply(request, "The watchlist is empty.")
+		return
+	}
+
+	var rows [][]string
+	for i, m := range movies {
+		status := "Not released"
+		if m.Released {
+			status = "Released"
+		}
+		var watchers []string
+		for _, w := range m.Watchers {
+			watchers = append(watchers, s.userName(w))
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(i + 1),
+			m.Title,
+			strings.Join(watchers, ", "),
+			status,
+			m.Added.Format("Jan 2"),
+		})
+	}
+	s.slack.PostTable(title, []string{"#", "Title", "Watchers", "Status", "Added"}, rows)
+}
+
+// watchReleases checks the movies on the watchlist every interval. It never
+// returns.
+func (s *service) watchReleases(interval time.Duration) {
+	for {
+		ctx := context.Background()
+		for _, m := range s.watchlist.list("") {
+			s.checkWatched(ctx, m)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// checkWatched tells the watchers of the movie once it is out digitally or on
+// disc, requesting it if set to, and once it is on the media server, which
+// takes it off the watchlist.
+func (s *service) checkWatched(ctx context.Context, m WatchedMovie) {
+	lookup, ok := s.managers[media.Movie].(movieLookup)
+	if !ok {
+		return
+	}
+
+	movie, found, err := lookup.Lookup(ctx, "tmdb:"+m.MediaID)
+	if err != nil {
+		level.Warn(s.logger).Log("event", "failed to check watched movie", "title", m.Title, "error", err)
+		return
+	}
+	if !found {
+		return
+	}
+
+	if s.inLibrary(ctx, movie.Item) {
+		s.tellWatchers(m, fmt.Sprintf("%s from your watchlist is on the media server now.", m.Title))
+		if err := s.watchlist.drop(m.MediaID); err != nil {
+			level.Error(s.logger).Log("error", err)
+		}
+		return
+	}
+	if m.Released || !movie.Released {
+		return
+	}
+
+	text := fmt.Sprintf("%s from your watchlist is out.", m.Title)
+	if s.watchlistRequests && !movie.InLibrary && len(m.Watchers) > 0 {
+		_, err := s.requestMedia(ctx, media.Movie, m.MediaID, m.Watchers[0], sourceSlack)
+		switch err {
+		case nil:
+			text = fmt.Sprintf("%s from your watchlist is out, it was requested for you.", m.Title)
+		case errAlreadyRequested:
+		default:
+			// Not marked released so the request is retried on the next check.
+			level.Error(s.logger).Log("event", "failed to request watched movie", "title", m.Title, "error", err)
+			return
+		}
+	}
+	s.tellWatchers(m, text)
+	if err := s.watchlist.setReleased(m.MediaID); err != nil {
+		level.Error(s.logger).Log("error", err)
+	}
+}
+
+func (s *service) tellWatchers(m WatchedMovie, text string) {
+	for _, user := range m.Watchers {
+		if err := s.slack.PostDirect(user, text); err != nil {
+			level.Error(s.logger).Log("error", err)
+		}
+	}
+}